@@ -8,12 +8,23 @@ import (
 	"syscall"
 
 	"dist_task/internal/api/handler"
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/breaker"
+	"dist_task/internal/bulk"
 	"dist_task/internal/config"
+	"dist_task/internal/discovery"
 	"dist_task/internal/engine"
 	"dist_task/internal/engine/executor"
+	"dist_task/internal/messaging"
+	"dist_task/internal/outbox"
+	"dist_task/internal/ratelimit"
 	"dist_task/internal/repository"
 	"dist_task/internal/retry"
+	"dist_task/internal/tenant"
+	"dist_task/internal/timer"
 	"dist_task/pkg/logger"
+	"dist_task/pkg/secret"
+	"dist_task/pkg/taskdef"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,45 +56,140 @@ func main() {
 	taskRepo := &repository.TaskRepository{}
 	exceptionRepo := &repository.ExceptionRepository{}
 	logRepo := &repository.LogRepository{}
+	auditRepo := &repository.AuditRepository{}
+	bulkJobRepo := &repository.BulkJobRepository{}
 
-	executorFactory, err := executor.NewExecutorFactory(repository.GetDB())
+	brokers, err := messaging.NewRegistryFromConfig(cfg, executor.TaskTransactionChecker(repository.GetDB()))
 	if err != nil {
-		log.Fatalf("create executor factory failed: %v", err)
+		log.Fatalf("init message brokers failed: %v", err)
 	}
+	log.Printf("message brokers: %v", brokers.Names())
 
-	eng := engine.NewEngine(instanceRepo, taskRepo, exceptionRepo, logRepo, executorFactory)
+	serviceDiscovery, err := discovery.NewDiscovery(&cfg.Discovery)
+	if err != nil {
+		log.Fatalf("init service discovery failed: %v", err)
+	}
+
+	grpcExecutor, err := executor.NewGRPCExecutor(&cfg.GRPC, serviceDiscovery)
+	if err != nil {
+		log.Fatalf("init grpc executor failed: %v", err)
+	}
+
+	var breakers *breaker.Manager
+	if cfg.Breaker.Enabled {
+		breakers = breaker.NewManager(&cfg.Breaker)
+	}
+
+	dbExecutor, err := executor.NewDBExecutor(repository.GetDB(), &cfg.DBTask)
+	if err != nil {
+		log.Fatalf("init db executor failed: %v", err)
+	}
+
+	var commandExecutor *executor.CommandExecutor
+	if cfg.Command.Enabled {
+		commandExecutor = executor.NewCommandExecutor(&cfg.Command)
+	}
+
+	executorFactory := executor.NewExecutorFactory(dbExecutor, brokers, grpcExecutor, executor.NewTransformExecutor(&cfg.Transform), commandExecutor, serviceDiscovery, breakers, cfg.Outbox.Enabled)
+
+	cipher, err := newCipher(&cfg.Encryption)
+	if err != nil {
+		log.Fatalf("init encryption failed: %v", err)
+	}
+	// 未配置密钥时实例参数只能明文保存，声明了敏感字段则拒绝启动
+	if tasks := taskdef.SensitiveTasks(); cipher == nil && len(tasks) > 0 {
+		log.Fatalf("tasks %v declare sensitive fields, set [encryption] provider", tasks)
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store
+		if cfg.RateLimit.Distributed {
+			store = &repository.RateLimitRepository{}
+		}
+		limiter = ratelimit.NewLimiter(&cfg.RateLimit, store)
+	}
+
+	eng := engine.NewEngine(flowRepo, instanceRepo, taskRepo, exceptionRepo, logRepo, executorFactory, cipher, limiter, &repository.WorkerTaskRepository{}, &cfg.Worker)
 
 	retryScheduler := retry.NewRetryScheduler(exceptionRepo, eng, cfg.Retry.DefaultInterval)
 	retryScheduler.Start()
 
-	h := handler.NewHandler(flowRepo, instanceRepo, taskRepo, exceptionRepo, logRepo, eng, retryScheduler)
+	timerScheduler := timer.NewTimerScheduler(taskRepo, eng, cfg.Timer.ScanInterval)
+	timerScheduler.Start()
+
+	var outboxRelay *outbox.Relay
+	if cfg.Outbox.Enabled {
+		outboxRelay = outbox.NewRelay(&repository.OutboxRepository{}, executorFactory.MQExecutor(), &cfg.Outbox)
+		outboxRelay.Start()
+	}
+
+	bulkRunner := bulk.NewRunner(bulkJobRepo, exceptionRepo, auditRepo, taskRepo, instanceRepo, eng)
+	bulkRunner.Start()
+
+	tenants, err := tenant.NewManager(&cfg.Tenancy)
+	if err != nil {
+		log.Fatalf("init tenancy failed: %v", err)
+	}
+
+	h := handler.NewHandler(flowRepo, instanceRepo, taskRepo, exceptionRepo, logRepo, auditRepo, bulkJobRepo, eng, retryScheduler, bulkRunner, tenants, breakers, &repository.WorkerRepository{})
+
+	auth, err := newAuth(&cfg.Auth)
+	if err != nil {
+		log.Fatalf("init auth failed: %v", err)
+	}
 
 	r := gin.Default()
 
 	r.GET("/health", h.HealthCheck)
 
-	v1 := r.Group("/api/v1")
+	v1 := r.Group("/api/v1", auth.Authenticate(), middleware.Tenant(cfg.Tenancy.Header, cfg.Tenancy.DefaultTenant))
 	{
+		viewer := auth.Require(middleware.RoleViewer)
+		operator := auth.Require(middleware.RoleOperator)
+		flowAdmin := auth.Require(middleware.RoleFlowAdmin)
+		worker := auth.Require(middleware.RoleWorker)
+
 		flows := v1.Group("/flows")
 		{
-			flows.POST("", h.CreateFlow)
-			flows.GET("", h.ListFlows)
-			flows.GET("/:id", h.GetFlow)
+			flows.POST("", flowAdmin, h.CreateFlow)
+			flows.GET("", viewer, h.ListFlows)
+			flows.GET("/:id", viewer, h.GetFlow)
 		}
 
 		transactions := v1.Group("/transactions")
 		{
-			transactions.POST("", h.StartTransaction)
-			transactions.GET("/:id", h.GetTransaction)
-			transactions.POST("/:id/retry", h.RetryTransaction)
+			transactions.POST("", operator, h.StartTransaction)
+			transactions.GET("/waiting", viewer, h.ListWaitingTransactions)
+			transactions.GET("/:id", viewer, h.GetTransaction)
+			transactions.POST("/:id/retry", operator, h.RetryTransaction)
+			transactions.POST("/:id/signal/:task", operator, h.SignalTransaction)
+			transactions.POST("/:id/cancel", operator, h.CancelTransaction)
+			transactions.POST("/:id/pause", operator, h.PauseTransaction)
+			transactions.POST("/:id/resume", operator, h.ResumeTransaction)
 		}
 
 		exceptions := v1.Group("/exceptions")
 		{
-			exceptions.GET("", h.ListExceptions)
-			exceptions.POST("/:id/handle", h.HandleException)
-			exceptions.POST("/:id/retry", h.RetryException)
+			exceptions.GET("", viewer, h.ListExceptions)
+			exceptions.POST("/bulk", operator, h.BulkExceptions)
+			exceptions.GET("/bulk/:job_id", operator, h.GetBulkJob)
+			exceptions.POST("/:id/handle", operator, h.HandleException)
+			exceptions.POST("/:id/retry", operator, h.RetryException)
 		}
+
+		workers := v1.Group("/workers")
+		{
+			workers.POST("", worker, h.RegisterWorker)
+			workers.GET("", viewer, h.ListWorkers)
+			workers.POST("/:id/poll", worker, h.PollWorkerTask)
+			workers.POST("/tasks/:task_id/heartbeat", worker, h.HeartbeatWorkerTask)
+			workers.POST("/tasks/:task_id/complete", worker, h.CompleteWorkerTask)
+			workers.POST("/tasks/:task_id/fail", worker, h.FailWorkerTask)
+		}
+
+		v1.GET("/audit-logs", operator, h.ListAuditLogs)
+		v1.GET("/admin/breakers", operator, h.ListBreakers)
 	}
 
 	addr := fmt.Sprintf("%s:%d", cfg.App.Host, cfg.App.Port)
@@ -99,6 +205,55 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	bulkRunner.Stop()
+	if outboxRelay != nil {
+		outboxRelay.Stop()
+	}
+	timerScheduler.Stop()
 	retryScheduler.Stop()
+	brokers.Close()
+	grpcExecutor.Close()
+	dbExecutor.Close()
 	log.Println("server shutdown")
 }
+
+func newCipher(cfg *config.EncryptionConfig) (*secret.Cipher, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "keyfile":
+		provider, err := secret.NewKeyfileProvider(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return secret.NewCipher(provider), nil
+	default:
+		return nil, fmt.Errorf("unknown encryption provider: %s", cfg.Provider)
+	}
+}
+
+func newAuth(cfg *config.AuthConfig) (*middleware.Auth, error) {
+	var authenticators []middleware.Authenticator
+
+	if len(cfg.APIKeys) > 0 {
+		apiKey, err := middleware.NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, apiKey)
+	}
+
+	if cfg.JWT.Enabled {
+		jwtAuth, err := middleware.NewJWTAuthenticator(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuth)
+	}
+
+	if cfg.Enabled && len(authenticators) == 0 {
+		return nil, fmt.Errorf("auth enabled but no api_keys or jwt configured")
+	}
+
+	return middleware.NewAuth(cfg.Enabled, authenticators...), nil
+}
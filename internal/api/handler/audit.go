@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/pkg/logger"
+	"dist_task/pkg/secret"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AuditFlowCreate        = "flow.create"
+	AuditTransactionRetry  = "transaction.retry"
+	AuditTransactionSignal = "transaction.signal"
+	AuditTransactionCancel = "transaction.cancel"
+	AuditTransactionPause  = "transaction.pause"
+	AuditTransactionResume = "transaction.resume"
+	AuditExceptionHandle   = "exception.handle"
+	AuditExceptionRetry    = "exception.retry"
+)
+
+// audit 记录一次操作；审计失败只记日志，不影响操作本身
+func (h *Handler) audit(c *gin.Context, entry *model.AuditLog, before, after interface{}) {
+	entry.Actor = actor(c, entry.Actor)
+	entry.ClientIP = c.ClientIP()
+	entry.BeforeState = auditState(before)
+	entry.AfterState = auditState(after)
+
+	if err := h.audits(c).Create(entry); err != nil {
+		logger.Error().Err(err).Str("action", entry.Action).Str("target_id", entry.TargetID).Msg("write audit log failed")
+	}
+}
+
+func auditState(state interface{}) string {
+	if state == nil {
+		return "null"
+	}
+	data, _ := json.Marshal(state)
+	return secret.Redact(string(data))
+}
+
+// instanceState 实例快照，不包含启动参数
+func instanceState(instance *model.TaskGroupInstance) interface{} {
+	if instance == nil {
+		return nil
+	}
+	return gin.H{
+		"status":       instance.Status,
+		"completed_at": instance.CompletedAt,
+	}
+}
+
+func (h *Handler) auditInstance(c *gin.Context, action, instanceID, requestedActor, remark string, before *model.TaskGroupInstance) {
+	after, _ := h.instances(c).GetByID(instanceID)
+
+	entry := &model.AuditLog{
+		Actor:      requestedActor,
+		Action:     action,
+		TargetType: "instance",
+		TargetID:   instanceID,
+		InstanceID: instanceID,
+		Remark:     remark,
+	}
+	if before != nil {
+		entry.FlowID = before.FlowID
+	}
+	h.audit(c, entry, instanceState(before), instanceState(after))
+}
+
+func (h *Handler) ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	offset := (page - 1) * pageSize
+
+	filter := repository.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		FlowID:     c.Query("flow_id"),
+		InstanceID: c.Query("instance_id"),
+	}
+	for param, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": param + " must be RFC3339"})
+			return
+		}
+		*dst = &t
+	}
+
+	logs, total := h.audits(c).List(filter, offset, pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"list": logs,
+			"pagination": gin.H{
+				"page":      page,
+				"page_size": pageSize,
+				"total":     total,
+			},
+		},
+	})
+}
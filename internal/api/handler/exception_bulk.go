@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/bulk"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const AuditExceptionBulk = "exception.bulk"
+
+func (h *Handler) bulkJobs(c *gin.Context) *repository.BulkJobRepository {
+	return h.bulkJobRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+// exceptionFilter 从查询参数构造异常筛选条件
+func exceptionFilter(c *gin.Context) (repository.ExceptionFilter, error) {
+	filter := repository.ExceptionFilter{
+		FlowID:        c.Query("flow_id"),
+		GroupID:       c.Query("group_id"),
+		TaskName:      c.Query("task_name"),
+		ErrorCode:     c.Query("error_code"),
+		RetryStrategy: c.Query("retry_strategy"),
+		Assignee:      c.Query("assignee"),
+		Query:         c.Query("q"),
+	}
+
+	if value := c.Query("handled"); value != "" {
+		handled := value == "true"
+		filter.Handled = &handled
+	}
+	if value := c.Query("error_type"); value != "" {
+		errorType, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("error_type must be an integer")
+		}
+		filter.ErrorType = &errorType
+	}
+	for param, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be RFC3339", param)
+		}
+		*dst = &t
+	}
+	return filter, nil
+}
+
+type BulkExceptionRequest struct {
+	Action   string                     `json:"action" binding:"required"` // retry / handle / reassign
+	Filter   repository.ExceptionFilter `json:"filter"`
+	Remark   string                     `json:"remark"`
+	Assignee string                     `json:"assignee"`
+	Operator string                     `json:"operator"`
+	DryRun   bool                       `json:"dry_run"` // 只返回匹配数量，不执行
+}
+
+func (h *Handler) BulkExceptions(c *gin.Context) {
+	var req BulkExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	params := bulk.Params{Remark: req.Remark, Assignee: req.Assignee}
+	if err := bulk.Validate(req.Action, params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	matched, err := h.exceptions(c).Count(req.Filter)
+	if err != nil {
+		logger.Error().Err(err).Msg("count exceptions failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "count exceptions failed"})
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    0,
+			"message": "success",
+			"data": gin.H{
+				"action":  req.Action,
+				"matched": matched,
+			},
+		})
+		return
+	}
+
+	filterJSON, _ := json.Marshal(req.Filter)
+	paramsJSON, _ := json.Marshal(params)
+	job := &model.ExceptionBulkJob{
+		ID:        generateID(),
+		Action:    req.Action,
+		Filter:    string(filterJSON),
+		Params:    string(paramsJSON),
+		Status:    "pending",
+		Total:     matched,
+		CreatedBy: actor(c, req.Operator),
+		ClientIP:  c.ClientIP(),
+	}
+	if err := h.bulkJobs(c).Create(job); err != nil {
+		logger.Error().Err(err).Msg("create bulk job failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "create bulk job failed"})
+		return
+	}
+
+	h.audit(c, &model.AuditLog{
+		Actor:      req.Operator,
+		Action:     AuditExceptionBulk,
+		TargetType: "bulk_job",
+		TargetID:   job.ID,
+		Remark:     req.Action,
+	}, nil, req)
+
+	h.bulkRunner.Submit(job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"job_id":  job.ID,
+			"action":  req.Action,
+			"matched": matched,
+		},
+	})
+}
+
+func (h *Handler) GetBulkJob(c *gin.Context) {
+	job, err := h.bulkJobs(c).GetByID(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "bulk job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    job,
+	})
+}
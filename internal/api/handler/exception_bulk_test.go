@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkExceptions_DryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := repotest.Open(t)
+
+	for _, ex := range []model.ExceptionRecord{
+		{TenantID: "default", GroupID: "a", TaskName: "charge", RetryStrategy: "manual"},
+		{TenantID: "default", GroupID: "b", TaskName: "charge", RetryStrategy: "auto"},
+		{TenantID: "default", GroupID: "c", TaskName: "charge", RetryStrategy: "manual", Handled: true},
+		{TenantID: "default", GroupID: "d", TaskName: "notify", RetryStrategy: "manual"},
+		{TenantID: "other", GroupID: "e", TaskName: "charge", RetryStrategy: "manual"},
+	} {
+		ex.OccurredAt = time.Now()
+		conn.Create(&ex)
+	}
+
+	h := &Handler{exceptionRepo: &repository.ExceptionRepository{}, bulkJobRepo: &repository.BulkJobRepository{}}
+	r := gin.New()
+	r.Use(middleware.Tenant("", "default"))
+	r.POST("/exceptions/bulk", h.BulkExceptions)
+
+	tests := []struct {
+		name string
+		body string
+		want int64
+	}{
+		{"all", `{"action":"retry","dry_run":true}`, 4},
+		{"task name", `{"action":"retry","dry_run":true,"filter":{"task_name":"charge"}}`, 3},
+		{"unhandled manual", `{"action":"handle","dry_run":true,"filter":{"handled":false,"retry_strategy":"manual"}}`, 2},
+		{"no match", `{"action":"handle","dry_run":true,"filter":{"task_name":"refund"}}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/exceptions/bulk", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			var resp struct {
+				Code int `json:"code"`
+				Data struct {
+					Matched int64 `json:"matched"`
+				} `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if w.Code != http.StatusOK || resp.Code != 0 {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if resp.Data.Matched != tt.want {
+				t.Errorf("matched = %d, want %d", resp.Data.Matched, tt.want)
+			}
+		})
+	}
+
+	// 预览不创建任务
+	var jobs int64
+	conn.Model(&model.ExceptionBulkJob{}).Count(&jobs)
+	if jobs != 0 {
+		t.Errorf("dry run created %d jobs", jobs)
+	}
+}
@@ -1,15 +1,24 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/breaker"
+	"dist_task/internal/bulk"
 	"dist_task/internal/engine"
 	"dist_task/internal/model"
 	"dist_task/internal/repository"
 	"dist_task/internal/retry"
+	"dist_task/internal/tenant"
+	apperrors "dist_task/pkg/errors"
 	"dist_task/pkg/logger"
+	"dist_task/pkg/secret"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +29,14 @@ type Handler struct {
 	taskRepo       *repository.TaskRepository
 	exceptionRepo  *repository.ExceptionRepository
 	logRepo        *repository.LogRepository
+	auditRepo      *repository.AuditRepository
+	bulkJobRepo    *repository.BulkJobRepository
 	engine         *engine.Engine
 	retryScheduler *retry.RetryScheduler
+	bulkRunner     *bulk.Runner
+	tenants        *tenant.Manager
+	breakers       *breaker.Manager
+	workerRepo     *repository.WorkerRepository
 }
 
 func NewHandler(
@@ -30,8 +45,14 @@ func NewHandler(
 	taskRepo *repository.TaskRepository,
 	exceptionRepo *repository.ExceptionRepository,
 	logRepo *repository.LogRepository,
+	auditRepo *repository.AuditRepository,
+	bulkJobRepo *repository.BulkJobRepository,
 	eng *engine.Engine,
 	retryScheduler *retry.RetryScheduler,
+	bulkRunner *bulk.Runner,
+	tenants *tenant.Manager,
+	breakers *breaker.Manager,
+	workerRepo *repository.WorkerRepository,
 ) *Handler {
 	return &Handler{
 		flowRepo:       flowRepo,
@@ -39,8 +60,14 @@ func NewHandler(
 		taskRepo:       taskRepo,
 		exceptionRepo:  exceptionRepo,
 		logRepo:        logRepo,
+		auditRepo:      auditRepo,
+		bulkJobRepo:    bulkJobRepo,
 		engine:         eng,
 		retryScheduler: retryScheduler,
+		bulkRunner:     bulkRunner,
+		tenants:        tenants,
+		breakers:       breakers,
+		workerRepo:     workerRepo,
 	}
 }
 
@@ -49,7 +76,27 @@ type CreateFlowRequest struct {
 	Description string `json:"description"`
 	FlowType    string `json:"flow_type" binding:"required"`
 	Definition  string `json:"definition" binding:"required"`
-	CreateUser  string `json:"create_user" binding:"required"`
+	CreateUser  string `json:"create_user"` // 启用认证时由当前用户填充
+}
+
+func (h *Handler) flows(c *gin.Context) *repository.FlowRepository {
+	return h.flowRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+func (h *Handler) instances(c *gin.Context) *repository.InstanceRepository {
+	return h.instanceRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+func (h *Handler) tasks(c *gin.Context) *repository.TaskRepository {
+	return h.taskRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+func (h *Handler) exceptions(c *gin.Context) *repository.ExceptionRepository {
+	return h.exceptionRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+func (h *Handler) audits(c *gin.Context) *repository.AuditRepository {
+	return h.auditRepo.ForTenant(middleware.CurrentTenant(c))
 }
 
 func (h *Handler) CreateFlow(c *gin.Context) {
@@ -59,22 +106,46 @@ func (h *Handler) CreateFlow(c *gin.Context) {
 		return
 	}
 
+	createUser := actor(c, req.CreateUser)
+	if createUser == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "create_user is required"})
+		return
+	}
+
+	var definition engine.FlowDefinition
+	if err := json.Unmarshal([]byte(req.Definition), &definition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid flow definition"})
+		return
+	}
+	if err := h.tenants.CheckTaskTypes(middleware.CurrentTenant(c), definition.TaskTypes()); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": err.Error()})
+		return
+	}
+
 	flow := &model.TaskGroupFlow{
 		ID:          generateID(),
 		Name:        req.Name,
 		Description: req.Description,
 		FlowType:    req.FlowType,
 		Definition:  req.Definition,
-		CreateUser:  req.CreateUser,
-		UpdatedUser: req.CreateUser,
+		CreateUser:  createUser,
+		UpdatedUser: createUser,
 	}
 
-	if err := h.flowRepo.Create(flow); err != nil {
+	if err := h.flows(c).Create(flow); err != nil {
 		logger.Error().Err(err).Msg("create flow failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "create flow failed"})
 		return
 	}
 
+	h.audit(c, &model.AuditLog{
+		Actor:      createUser,
+		Action:     AuditFlowCreate,
+		TargetType: "flow",
+		TargetID:   flow.ID,
+		FlowID:     flow.ID,
+	}, nil, flow)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "success",
@@ -87,7 +158,7 @@ func (h *Handler) ListFlows(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	offset := (page - 1) * pageSize
 
-	flows, total := h.flowRepo.List(offset, pageSize)
+	flows, total := h.flows(c).List(offset, pageSize)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -106,7 +177,7 @@ func (h *Handler) ListFlows(c *gin.Context) {
 func (h *Handler) GetFlow(c *gin.Context) {
 	id := c.Param("id")
 
-	flow, err := h.flowRepo.GetByID(id)
+	flow, err := h.flows(c).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "flow not found"})
 		return
@@ -133,7 +204,7 @@ func (h *Handler) StartTransaction(c *gin.Context) {
 	}
 
 	// 幂等检查
-	existing, err := h.instanceRepo.GetByID(req.InstanceID)
+	existing, err := h.instances(c).GetByID(req.InstanceID)
 	if err == nil && existing != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code":    0,
@@ -147,23 +218,46 @@ func (h *Handler) StartTransaction(c *gin.Context) {
 	}
 
 	// 获取 flow 定义
-	flow, err := h.flowRepo.GetByID(req.FlowID)
+	flow, err := h.flows(c).GetByID(req.FlowID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "flow not found"})
 		return
 	}
 
+	if !h.checkTenantQuota(c, flow) {
+		return
+	}
+
+	params := req.Params
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	// 敏感字段加密后再落库，执行时由引擎解密
+	params, err = h.engine.SealParams(params)
+	if err != nil {
+		logger.Error().Err(err).Msg("seal params failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "encrypt params failed"})
+		return
+	}
+	paramsJSON, _ := json.Marshal(params)
+
 	// 创建 instance
 	now := time.Now()
 	instance := &model.TaskGroupInstance{
 		ID:        req.InstanceID,
 		FlowID:    req.FlowID,
 		Status:    "pending",
+		Params:    string(paramsJSON),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	if err := h.instanceRepo.Create(instance); err != nil {
+	if err := h.instances(c).Create(instance); err != nil {
+		// 实例 ID 全局唯一，幂等检查只在本租户内查找，主键冲突说明 ID 已被占用
+		if apperrors.Classify(err, apperrors.CategoryUnknown, "", "").Code == apperrors.CodeDuplicateKey {
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "instance id already in use"})
+			return
+		}
 		logger.Error().Err(err).Msg("create instance failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "create instance failed"})
 		return
@@ -171,11 +265,8 @@ func (h *Handler) StartTransaction(c *gin.Context) {
 
 	// 异步执行
 	go func() {
-		ctx := c.Request.Context()
-		params := req.Params
-		if params == nil {
-			params = make(map[string]interface{})
-		}
+		// 执行生命周期由引擎管理，不能使用请求上下文
+		ctx := context.Background()
 		if err := h.engine.Execute(ctx, instance, flow, params); err != nil {
 			logger.Error().Err(err).Str("instance_id", req.InstanceID).Msg("execute failed")
 		}
@@ -193,16 +284,48 @@ func (h *Handler) StartTransaction(c *gin.Context) {
 	})
 }
 
+// checkTenantQuota 校验租户的执行器白名单、启动速率和并发实例数
+func (h *Handler) checkTenantQuota(c *gin.Context, flow *model.TaskGroupFlow) bool {
+	tenantID := middleware.CurrentTenant(c)
+
+	var definition engine.FlowDefinition
+	if err := json.Unmarshal([]byte(flow.Definition), &definition); err == nil {
+		if err := h.tenants.CheckTaskTypes(tenantID, definition.TaskTypes()); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": err.Error()})
+			return false
+		}
+	}
+
+	if !h.tenants.AllowStart(tenantID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "message": "tenant rate limit exceeded"})
+		return false
+	}
+
+	active, err := h.instances(c).CountActive()
+	if err != nil {
+		logger.Error().Err(err).Str("tenant", tenantID).Msg("count active instances failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "check tenant quota failed"})
+		return false
+	}
+	if err := h.tenants.CheckConcurrency(tenantID, active); err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "message": err.Error()})
+		return false
+	}
+
+	return true
+}
+
 func (h *Handler) GetTransaction(c *gin.Context) {
 	id := c.Param("id")
 
-	instance, err := h.instanceRepo.GetByID(id)
+	instance, err := h.instances(c).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
 		return
 	}
 
-	tasks, _ := h.taskRepo.ListByGroupID(id)
+	tasks, _ := h.tasks(c).ListByGroupID(id)
+	redactTasks(tasks)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -218,18 +341,224 @@ func (h *Handler) GetTransaction(c *gin.Context) {
 	})
 }
 
+// redactTasks 隐藏任务记录中的密文，敏感数据不通过接口返回
+func redactTasks(tasks []model.DistTask) {
+	for i := range tasks {
+		tasks[i].InputData = secret.Redact(tasks[i].InputData)
+		tasks[i].OutputData = secret.Redact(tasks[i].OutputData)
+		tasks[i].Config = secret.Redact(tasks[i].Config)
+		tasks[i].ErrorMessage = secret.Redact(tasks[i].ErrorMessage)
+	}
+}
+
+type SignalRequest struct {
+	Decision string                 `json:"decision" binding:"required"`
+	Payload  map[string]interface{} `json:"payload"`
+	Operator string                 `json:"operator"`
+}
+
+func (h *Handler) SignalTransaction(c *gin.Context) {
+	id := c.Param("id")
+	taskKey := c.Param("task")
+
+	var req SignalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	before, err := h.instances(c).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
+		return
+	}
+
+	err = h.engine.Signal(id, taskKey, &engine.Signal{
+		Decision: req.Decision,
+		Payload:  req.Payload,
+		Operator: actor(c, req.Operator),
+	})
+	if err != nil {
+		writeEngineError(c, err, "signal transaction failed")
+		return
+	}
+
+	h.auditInstance(c, AuditTransactionSignal, id, req.Operator, taskKey+": "+req.Decision, before)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"instance_id": id,
+			"task":        taskKey,
+			"decision":    req.Decision,
+		},
+	})
+}
+
+type CancelRequest struct {
+	Operator   string `json:"operator"`
+	Compensate bool   `json:"compensate"`
+}
+
+func (h *Handler) CancelTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	var req CancelRequest
+	c.ShouldBindJSON(&req)
+
+	before, err := h.instances(c).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
+		return
+	}
+
+	if err := h.engine.Cancel(id, actor(c, req.Operator), req.Compensate); err != nil {
+		writeEngineError(c, err, "cancel transaction failed")
+		return
+	}
+
+	remark := ""
+	if req.Compensate {
+		remark = "with compensation"
+	}
+	h.auditInstance(c, AuditTransactionCancel, id, req.Operator, remark, before)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"instance_id": id,
+			"status":      "cancelled",
+			"compensate":  req.Compensate,
+		},
+	})
+}
+
+type OperatorRequest struct {
+	Operator string `json:"operator"`
+}
+
+func (h *Handler) PauseTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	var req OperatorRequest
+	c.ShouldBindJSON(&req)
+
+	before, err := h.instances(c).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
+		return
+	}
+
+	if err := h.engine.Pause(id, actor(c, req.Operator)); err != nil {
+		writeEngineError(c, err, "pause transaction failed")
+		return
+	}
+
+	h.auditInstance(c, AuditTransactionPause, id, req.Operator, "", before)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"instance_id": id,
+			"status":      "paused",
+		},
+	})
+}
+
+func (h *Handler) ResumeTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	var req OperatorRequest
+	c.ShouldBindJSON(&req)
+
+	before, err := h.instances(c).GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
+		return
+	}
+
+	if err := h.engine.Resume(id, actor(c, req.Operator)); err != nil {
+		writeEngineError(c, err, "resume transaction failed")
+		return
+	}
+
+	h.auditInstance(c, AuditTransactionResume, id, req.Operator, "", before)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"instance_id": id,
+			"status":      "running",
+		},
+	})
+}
+
+func writeEngineError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, apperrors.ErrInstanceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
+	case errors.Is(err, apperrors.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "task not found"})
+	case errors.Is(err, apperrors.ErrInvalidArgument),
+		errors.Is(err, apperrors.ErrInvalidStatus),
+		errors.Is(err, apperrors.ErrTaskNotWaiting):
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+	case errors.Is(err, apperrors.ErrLeaseLost):
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": err.Error()})
+	default:
+		logger.Error().Err(err).Str("instance_id", c.Param("id")).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": message})
+	}
+}
+
+func (h *Handler) ListWaitingTransactions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	offset := (page - 1) * pageSize
+
+	tasks, total := h.tasks(c).ListWaiting(c.Query("flow_id"), c.Query("task"), offset, pageSize)
+
+	list := make([]gin.H, 0, len(tasks))
+	for _, t := range tasks {
+		list = append(list, gin.H{
+			"instance_id":   t.GroupID,
+			"task":          t.TaskKey,
+			"task_id":       t.ID,
+			"waiting_since": t.StartedAt,
+			"resume_at":     t.ResumeAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"list": list,
+			"pagination": gin.H{
+				"page":      page,
+				"page_size": pageSize,
+				"total":     total,
+			},
+		},
+	})
+}
+
 func (h *Handler) ListExceptions(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	offset := (page - 1) * pageSize
 
-	var handled *bool
-	if handledStr := c.Query("handled"); handledStr != "" {
-		h := handledStr == "true"
-		handled = &h
+	filter, err := exceptionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
 	}
 
-	exceptions, total := h.exceptionRepo.List(offset, pageSize, handled)
+	exceptions, total := h.exceptions(c).List(filter, offset, pageSize)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -245,6 +574,15 @@ func (h *Handler) ListExceptions(c *gin.Context) {
 	})
 }
 
+// ListBreakers 返回各下游目标的熔断器状态
+func (h *Handler) ListBreakers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    h.breakers.States(),
+	})
+}
+
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -253,42 +591,40 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	})
 }
 
+type RetryRequest struct {
+	FromTask string `json:"from_task"` // 为空时从失败处继续，否则回退到指定任务重新执行
+	Operator string `json:"operator"`
+}
+
 func (h *Handler) RetryTransaction(c *gin.Context) {
 	id := c.Param("id")
 
-	instance, err := h.instanceRepo.GetByID(id)
+	var req RetryRequest
+	c.ShouldBindJSON(&req)
+
+	before, err := h.instances(c).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "instance not found"})
 		return
 	}
 
-	if instance.Status != "failed" {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "only failed transactions can be retried"})
+	if err := h.engine.Retry(id, req.FromTask, actor(c, req.Operator)); err != nil {
+		writeEngineError(c, err, "retry transaction failed")
 		return
 	}
 
-	flow, err := h.flowRepo.GetByID(instance.FlowID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "flow not found"})
-		return
+	remark := ""
+	if req.FromTask != "" {
+		remark = "from task " + req.FromTask
 	}
-
-	instance.Status = "pending"
-	h.instanceRepo.Update(instance)
-
-	go func() {
-		ctx := c.Request.Context()
-		if err := h.engine.Execute(ctx, instance, flow, map[string]interface{}{}); err != nil {
-			logger.Error().Err(err).Str("instance_id", id).Msg("retry transaction failed")
-		}
-	}()
+	h.auditInstance(c, AuditTransactionRetry, id, req.Operator, remark, before)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "success",
 		"data": gin.H{
 			"instance_id": id,
-			"status":      "pending",
+			"status":      "running",
 		},
 	})
 }
@@ -297,21 +633,38 @@ func (h *Handler) HandleException(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Remark string `json:"remark"`
+		Remark    string `json:"remark"`
+		HandledBy string `json:"handled_by"`
 	}
 	c.ShouldBindJSON(&req)
 
-	exception, err := h.exceptionRepo.GetByID(id)
+	exception, err := h.exceptions(c).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "exception not found"})
 		return
 	}
 
+	before := *exception
+
 	now := time.Now()
 	exception.Handled = true
 	exception.HandledAt = &now
 	exception.HandledRemark = req.Remark
-	h.exceptionRepo.Update(exception)
+	exception.HandledBy = actor(c, req.HandledBy)
+	if err := h.exceptions(c).Update(exception); err != nil {
+		logger.Error().Err(err).Str("exception_id", id).Msg("handle exception failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "handle exception failed"})
+		return
+	}
+
+	h.audit(c, &model.AuditLog{
+		Actor:      req.HandledBy,
+		Action:     AuditExceptionHandle,
+		TargetType: "exception",
+		TargetID:   id,
+		InstanceID: exception.GroupID,
+		Remark:     req.Remark,
+	}, before, exception)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -326,7 +679,12 @@ func (h *Handler) HandleException(c *gin.Context) {
 func (h *Handler) RetryException(c *gin.Context) {
 	id := c.Param("id")
 
-	exception, err := h.exceptionRepo.GetByID(id)
+	var req struct {
+		Operator string `json:"operator"`
+	}
+	c.ShouldBindJSON(&req)
+
+	exception, err := h.exceptions(c).GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "exception not found"})
 		return
@@ -342,10 +700,24 @@ func (h *Handler) RetryException(c *gin.Context) {
 		return
 	}
 
+	before := *exception
+
 	now := time.Now()
 	nextAt := now.Add(time.Duration(exception.RetryInterval) * time.Second)
 	exception.RetryNextAt = &nextAt
-	h.exceptionRepo.Update(exception)
+	if err := h.exceptions(c).Update(exception); err != nil {
+		logger.Error().Err(err).Str("exception_id", id).Msg("retry exception failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "retry exception failed"})
+		return
+	}
+
+	h.audit(c, &model.AuditLog{
+		Actor:      req.Operator,
+		Action:     AuditExceptionRetry,
+		TargetType: "exception",
+		TargetID:   id,
+		InstanceID: exception.GroupID,
+	}, before, exception)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -358,6 +730,14 @@ func (h *Handler) RetryException(c *gin.Context) {
 	})
 }
 
+// actor 返回操作人：启用认证时为当前认证主体，否则使用请求中填写的值
+func actor(c *gin.Context, requested string) string {
+	if principal := middleware.CurrentPrincipal(c); principal != nil {
+		return principal.Name
+	}
+	return requested
+}
+
 func generateID() string {
 	return strconv.FormatInt(time.Now().UnixNano(), 36)
 }
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/engine"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) workers(c *gin.Context) *repository.WorkerRepository {
+	return h.workerRepo.ForTenant(middleware.CurrentTenant(c))
+}
+
+type RegisterWorkerRequest struct {
+	WorkerID  string   `json:"worker_id" binding:"required"`
+	TaskTypes []string `json:"task_types" binding:"required,min=1"`
+	Identity  string   `json:"identity"`
+}
+
+// RegisterWorker 注册 worker 及其处理的任务类型，重复注册时覆盖
+func (h *Handler) RegisterWorker(c *gin.Context) {
+	var req RegisterWorkerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	taskTypes, _ := json.Marshal(req.TaskTypes)
+	worker := &model.Worker{
+		ID:         req.WorkerID,
+		Identity:   req.Identity,
+		TaskTypes:  string(taskTypes),
+		LastSeenAt: time.Now(),
+	}
+	if err := h.workers(c).Register(worker); err != nil {
+		logger.Error().Err(err).Str("worker_id", req.WorkerID).Msg("register worker failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "register worker failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    worker,
+	})
+}
+
+func (h *Handler) ListWorkers(c *gin.Context) {
+	workers, err := h.workers(c).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "list workers failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    workers,
+	})
+}
+
+type PollWorkerRequest struct {
+	TaskTypes []string `json:"task_types"` // 为空时使用注册时的任务类型
+	Wait      int      `json:"wait"`       // 最长等待秒数，不超过 [worker] poll_timeout
+}
+
+// PollWorkerTask 长轮询领取任务，等待期间没有任务时 data 为 null
+func (h *Handler) PollWorkerTask(c *gin.Context) {
+	workerID := c.Param("id")
+
+	var req PollWorkerRequest
+	c.ShouldBindJSON(&req)
+
+	worker, err := h.workers(c).GetByID(workerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "worker not registered"})
+		return
+	}
+	if len(req.TaskTypes) == 0 {
+		json.Unmarshal([]byte(worker.TaskTypes), &req.TaskTypes)
+	}
+	h.workers(c).Touch(workerID)
+
+	task, err := h.engine.PollWorkerTask(c.Request.Context(), middleware.CurrentTenant(c), workerID, req.TaskTypes, time.Duration(req.Wait)*time.Second)
+	if err != nil {
+		writeEngineError(c, err, "poll worker task failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    task,
+	})
+}
+
+type HeartbeatRequest struct {
+	LeaseToken string          `json:"lease_token" binding:"required"`
+	Details    json.RawMessage `json:"details"` // 执行进度，保存在 worker_task.heartbeat_details 中便于排查
+}
+
+func (h *Handler) HeartbeatWorkerTask(c *gin.Context) {
+	var req HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	cancelRequested, err := h.engine.HeartbeatWorkerTask(middleware.CurrentTenant(c), c.Param("task_id"), req.LeaseToken, req.Details)
+	if err != nil {
+		writeEngineError(c, err, "heartbeat worker task failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    gin.H{"cancel_requested": cancelRequested},
+	})
+}
+
+type CompleteWorkerTaskRequest struct {
+	LeaseToken string                 `json:"lease_token" binding:"required"`
+	Output     map[string]interface{} `json:"output"`
+}
+
+func (h *Handler) CompleteWorkerTask(c *gin.Context) {
+	var req CompleteWorkerTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	if err := h.engine.CompleteWorkerTask(middleware.CurrentTenant(c), c.Param("task_id"), req.LeaseToken, req.Output); err != nil {
+		writeEngineError(c, err, "complete worker task failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    gin.H{"task_id": c.Param("task_id"), "status": "success"},
+	})
+}
+
+type FailWorkerTaskRequest struct {
+	LeaseToken   string `json:"lease_token" binding:"required"`
+	Message      string `json:"message" binding:"required"`
+	Code         string `json:"code"`
+	NonRetryable bool   `json:"non_retryable"`
+}
+
+func (h *Handler) FailWorkerTask(c *gin.Context) {
+	var req FailWorkerTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	err := h.engine.FailWorkerTask(middleware.CurrentTenant(c), c.Param("task_id"), req.LeaseToken, &engine.WorkerFailure{
+		Message:      req.Message,
+		Code:         req.Code,
+		NonRetryable: req.NonRetryable,
+	})
+	if err != nil {
+		writeEngineError(c, err, "fail worker task failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    gin.H{"task_id": c.Param("task_id"), "status": "failed"},
+	})
+}
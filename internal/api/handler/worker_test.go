@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dist_task/internal/api/middleware"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/internal/repository/repotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterWorker_TenantIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := repotest.Open(t)
+
+	h := &Handler{workerRepo: &repository.WorkerRepository{}}
+	r := gin.New()
+	r.Use(middleware.Tenant("", "default"))
+	r.POST("/workers", h.RegisterWorker)
+
+	register := func(tenantID, body string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/workers", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant-ID", tenantID)
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("register for %s: status = %d, body = %s", tenantID, w.Code, w.Body.String())
+		}
+	}
+
+	register("acme", `{"worker_id":"w1","task_types":["inventory"],"identity":"acme-host"}`)
+	// 其他租户使用相同的 worker_id 注册，不能接管 acme 的 worker
+	register("evil", `{"worker_id":"w1","task_types":["inventory","payment"],"identity":"evil-host"}`)
+	// 同一租户重复注册时更新任务类型
+	register("acme", `{"worker_id":"w1","task_types":["inventory","shipping"],"identity":"acme-host"}`)
+
+	tests := []struct {
+		tenantID      string
+		wantIdentity  string
+		wantTaskTypes string
+	}{
+		{"acme", "acme-host", `["inventory","shipping"]`},
+		{"evil", "evil-host", `["inventory","payment"]`},
+	}
+	for _, tt := range tests {
+		worker, err := h.workerRepo.ForTenant(tt.tenantID).GetByID("w1")
+		if err != nil {
+			t.Fatalf("GetByID(%s) error = %v", tt.tenantID, err)
+		}
+		if worker.Identity != tt.wantIdentity || worker.TaskTypes != tt.wantTaskTypes {
+			t.Errorf("%s worker = %s %s, want %s %s", tt.tenantID, worker.Identity, worker.TaskTypes, tt.wantIdentity, tt.wantTaskTypes)
+		}
+	}
+
+	var count int64
+	conn.Model(&model.Worker{}).Count(&count)
+	if count != 2 {
+		t.Errorf("worker rows = %d, want 2", count)
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"dist_task/internal/config"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator 使用配置文件中的静态 API Key 认证
+type APIKeyAuthenticator struct {
+	keys []config.APIKeyConfig
+}
+
+func NewAPIKeyAuthenticator(keys []config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	for _, k := range keys {
+		if k.Key == "" || k.Name == "" {
+			return nil, fmt.Errorf("api key name and key are required")
+		}
+		if !knownRole(k.Role) {
+			return nil, fmt.Errorf("api key %s has unknown role %s", k.Name, k.Role)
+		}
+	}
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k.Key)) == 1 {
+			return &Principal{Name: k.Name, Role: k.Role, Tenant: k.Tenant}, nil
+		}
+	}
+	return nil, errors.New("invalid api key")
+}
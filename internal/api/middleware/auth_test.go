@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuth_Require(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	apiKey, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "dashboard", Key: "viewer-key", Role: RoleViewer},
+		{Name: "ops", Key: "operator-key", Role: RoleOperator},
+		{Name: "admin", Key: "admin-key", Role: RoleFlowAdmin},
+		{Name: "inventory-worker", Key: "worker-key", Role: RoleWorker},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		key     string
+		role    string
+		want    int
+	}{
+		{"disabled", false, "", RoleFlowAdmin, http.StatusOK},
+		{"missing key", true, "", RoleViewer, http.StatusUnauthorized},
+		{"invalid key", true, "bad-key", RoleViewer, http.StatusUnauthorized},
+		{"viewer reads", true, "viewer-key", RoleViewer, http.StatusOK},
+		{"viewer retries", true, "viewer-key", RoleOperator, http.StatusForbidden},
+		{"operator retries", true, "operator-key", RoleOperator, http.StatusOK},
+		{"operator creates flow", true, "operator-key", RoleFlowAdmin, http.StatusForbidden},
+		{"admin retries", true, "admin-key", RoleOperator, http.StatusOK},
+		{"worker polls", true, "worker-key", RoleWorker, http.StatusOK},
+		{"worker reads", true, "worker-key", RoleViewer, http.StatusForbidden},
+		{"worker retries", true, "worker-key", RoleOperator, http.StatusForbidden},
+		{"operator polls", true, "operator-key", RoleWorker, http.StatusForbidden},
+		{"admin polls", true, "admin-key", RoleWorker, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuth(tt.enabled, apiKey)
+
+			r := gin.New()
+			r.GET("/", auth.Authenticate(), auth.Require(tt.role), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, expected %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	authenticator, err := NewJWTAuthenticator(config.JWTConfig{
+		JWKSURL:  jwks.URL,
+		Issuer:   "https://idp.example.com",
+		Audience: "dist_task",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	valid := jwt.MapClaims{
+		"sub":  "alice",
+		"iss":  "https://idp.example.com",
+		"aud":  "dist_task",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": []interface{}{"viewer", "operator"},
+	}
+	expired := jwt.MapClaims{
+		"sub":  "alice",
+		"iss":  "https://idp.example.com",
+		"aud":  "dist_task",
+		"exp":  time.Now().Add(-time.Hour).Unix(),
+		"role": "operator",
+	}
+	worker := jwt.MapClaims{
+		"sub":  "alice",
+		"iss":  "https://idp.example.com",
+		"aud":  "dist_task",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": []interface{}{"worker", "unknown"},
+	}
+	wrongAudience := jwt.MapClaims{
+		"sub":  "alice",
+		"iss":  "https://idp.example.com",
+		"aud":  "other",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": "operator",
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		wantRole string
+		wantErr  bool
+	}{
+		{"no bearer", "", "", false},
+		{"valid", "Bearer " + sign("k1", valid), RoleOperator, false},
+		{"worker", "Bearer " + sign("k1", worker), RoleWorker, false},
+		{"expired", "Bearer " + sign("k1", expired), "", true},
+		{"wrong audience", "Bearer " + sign("k1", wrongAudience), "", true},
+		{"unknown kid", "Bearer " + sign("k2", valid), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantRole == "" {
+				if principal != nil && !tt.wantErr {
+					t.Errorf("Authenticate() = %+v, expected nil", principal)
+				}
+				return
+			}
+			if principal == nil || principal.Role != tt.wantRole || principal.Name != "alice" {
+				t.Errorf("Authenticate() = %+v, expected alice/%s", principal, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticator_SlowRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次之后的刷新一直挂起，模拟身份提供方响应缓慢
+		if fetches.Add(1) > 1 {
+			close(blocked)
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	authenticator, err := NewJWTAuthenticator(config.JWTConfig{JWKSURL: jwks.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticate := func(kid string) error {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "role": "viewer"})
+		token.Header["kid"] = kid
+		signed, _ := token.SignedString(key)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		_, err := authenticator.Authenticate(req)
+		return err
+	}
+
+	if err := authenticate("k1"); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	// 超过刷新间隔后，未知 kid 触发刷新
+	authenticator.mu.Lock()
+	authenticator.fetchedAt = time.Now().Add(-time.Hour)
+	authenticator.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			authenticate("k2")
+		}()
+	}
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("jwks refresh not started")
+	}
+
+	// 刷新期间已知 kid 的请求不受影响
+	done := make(chan error, 1)
+	go func() { done <- authenticate("k1") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Authenticate() during refresh error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Authenticate() blocked by jwks refresh")
+	}
+
+	release <- struct{}{}
+	wg.Wait()
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("jwks fetched %d times, want 2", n)
+	}
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// JWTAuthenticator 校验 Bearer Token，签名公钥从 JWKS 获取。
+// 未配置 jwks_url 时通过 issuer 的 OIDC discovery 文档查找
+type JWTAuthenticator struct {
+	cfg    config.JWTConfig
+	client *http.Client
+	parser *jwt.Parser
+
+	// 刷新 JWKS 时不持有 mu，并发请求共享同一次刷新
+	refreshing singleflight.Group
+	mu         sync.Mutex
+	jwksURL    string
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+}
+
+func NewJWTAuthenticator(cfg config.JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.JWKSURL == "" && cfg.Issuer == "" {
+		return nil, fmt.Errorf("jwt: jwks_url or issuer is required")
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "role"
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = "sub"
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 300
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &JWTAuthenticator{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		parser:  jwt.NewParser(opts...),
+		jwksURL: cfg.JWKSURL,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(strings.TrimPrefix(header, "Bearer "), claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	name, _ := claims[a.cfg.NameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("invalid token: missing claim %s", a.cfg.NameClaim)
+	}
+
+	role := highestRole(claims[a.cfg.RoleClaim])
+	if role == "" {
+		return nil, fmt.Errorf("invalid token: no known role in claim %s", a.cfg.RoleClaim)
+	}
+
+	principal := &Principal{Name: name, Role: role}
+	if a.cfg.TenantClaim != "" {
+		principal.Tenant, _ = claims[a.cfg.TenantClaim].(string)
+	}
+	return principal, nil
+}
+
+// highestRole 角色声明可以是字符串或字符串数组，取其中权限最高的已知角色；
+// 只声明了 worker 时返回 worker
+func highestRole(claim interface{}) string {
+	var roles []string
+	switch v := claim.(type) {
+	case string:
+		roles = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+
+	best := ""
+	for _, role := range roles {
+		if roleRank[role] > roleRank[best] || (best == "" && role == RoleWorker) {
+			best = role
+		}
+	}
+	return best
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	a.mu.Lock()
+	key, ok := a.keys[kid]
+	recent := a.keys != nil && time.Since(a.fetchedAt) < time.Duration(a.cfg.RefreshInterval)*time.Second
+	a.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	// 未知 kid 可能是密钥轮换，按最小间隔刷新 JWKS
+	if recent {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if _, err, _ := a.refreshing.Do("jwks", func() (interface{}, error) { return nil, a.refresh() }); err != nil {
+		logger.Error().Err(err).Msg("refresh jwks failed")
+		return nil, errors.New("signing keys unavailable")
+	}
+
+	a.mu.Lock()
+	key, ok = a.keys[kid]
+	a.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// refresh 在锁外请求 JWKS，完成后替换密钥集合
+func (a *JWTAuthenticator) refresh() error {
+	a.mu.Lock()
+	a.fetchedAt = time.Now()
+	jwksURL := a.jwksURL
+	a.mu.Unlock()
+
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := a.getJSON(strings.TrimSuffix(a.cfg.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return err
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("discovery document has no jwks_uri")
+		}
+		jwksURL = discovery.JWKSURI
+		a.mu.Lock()
+		a.jwksURL = jwksURL
+		a.mu.Unlock()
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := a.getJSON(jwksURL, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			logger.Warn().Err(err).Str("kid", k.Kid).Msg("skip unsupported jwk")
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *JWTAuthenticator) getJSON(url string, v interface{}) error {
+	resp, err := a.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode jwk field failed: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"dist_task/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tenantKey = "tenant.id"
+
+// Tenant 解析当前请求的租户：认证主体绑定的租户优先，其次是请求头，最后使用默认租户
+func Tenant(header, defaultTenant string) gin.HandlerFunc {
+	if header == "" {
+		header = "X-Tenant-ID"
+	}
+	if defaultTenant == "" {
+		defaultTenant = tenant.DefaultTenant
+	}
+
+	return func(c *gin.Context) {
+		requested := c.GetHeader(header)
+
+		tenantID := requested
+		if principal := CurrentPrincipal(c); principal != nil && principal.Tenant != "" {
+			if requested != "" && requested != principal.Tenant {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": 403, "message": "tenant mismatch"})
+				return
+			}
+			tenantID = principal.Tenant
+		}
+		if tenantID == "" {
+			tenantID = defaultTenant
+		}
+
+		if !tenant.ValidID(tenantID) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid tenant id"})
+			return
+		}
+
+		c.Set(tenantKey, tenantID)
+		c.Next()
+	}
+}
+
+func CurrentTenant(c *gin.Context) string {
+	return c.GetString(tenantKey)
+}
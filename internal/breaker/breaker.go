@@ -0,0 +1,293 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Manager 按下游目标维护熔断器和并发舱壁，为 nil 时不做任何限制
+type Manager struct {
+	defaults config.BreakerPolicy
+	targets  map[string]config.BreakerPolicy
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	key    string
+	policy config.BreakerPolicy
+	slots  chan struct{} // 舱壁，MaxConcurrent 为 0 时为 nil
+
+	state    string
+	failures int // 关闭状态下的连续失败次数
+	probes   int // 半开状态下进行中的探测请求
+	openedAt time.Time
+}
+
+// State 熔断器状态快照
+type State struct {
+	Key           string     `json:"key"`
+	State         string     `json:"state"`
+	Failures      int        `json:"failures"`
+	InFlight      int        `json:"in_flight"`
+	MaxConcurrent int        `json:"max_concurrent"`
+	OpenedAt      *time.Time `json:"opened_at,omitempty"`
+	RetryAt       *time.Time `json:"retry_at,omitempty"` // 熔断结束、进入半开的时间
+}
+
+func NewManager(cfg *config.BreakerConfig) *Manager {
+	return &Manager{
+		defaults: withDefaults(cfg.BreakerPolicy),
+		targets:  cfg.Targets,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+func withDefaults(p config.BreakerPolicy) config.BreakerPolicy {
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = 5
+	}
+	if p.OpenTimeout <= 0 {
+		p.OpenTimeout = 30
+	}
+	if p.HalfOpenRequests <= 0 {
+		p.HalfOpenRequests = 1
+	}
+	return p
+}
+
+// policy 目标配置中非 0 的字段覆盖默认值
+func (m *Manager) policy(key string) config.BreakerPolicy {
+	p := m.defaults
+	override, ok := m.targets[key]
+	if !ok {
+		return p
+	}
+	if override.FailureThreshold > 0 {
+		p.FailureThreshold = override.FailureThreshold
+	}
+	if override.OpenTimeout > 0 {
+		p.OpenTimeout = override.OpenTimeout
+	}
+	if override.HalfOpenRequests > 0 {
+		p.HalfOpenRequests = override.HalfOpenRequests
+	}
+	if override.MaxConcurrent > 0 {
+		p.MaxConcurrent = override.MaxConcurrent
+	}
+	if override.MaxWait > 0 {
+		p.MaxWait = override.MaxWait
+	}
+	return p
+}
+
+func (m *Manager) get(key string) *breaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.breakers[key]
+	if !ok {
+		b = &breaker{key: key, policy: m.policy(key), state: StateClosed}
+		if b.policy.MaxConcurrent > 0 {
+			b.slots = make(chan struct{}, b.policy.MaxConcurrent)
+		}
+		m.breakers[key] = b
+	}
+	return b
+}
+
+// Do 熔断或并发已满时直接返回 CategoryCircuitOpen / CategoryBulkheadFull 错误，不调用 fn
+func (m *Manager) Do(ctx context.Context, key string, fn func() error) error {
+	if m == nil || key == "" {
+		return fn()
+	}
+
+	b := m.get(key)
+	probe, err := m.allow(b)
+	if err != nil {
+		return err
+	}
+
+	if err := b.acquire(ctx); err != nil {
+		if probe {
+			m.cancelProbe(b)
+		}
+		return err
+	}
+	defer b.release()
+
+	err = fn()
+	m.record(b, probe, err)
+	return err
+}
+
+// allow 判断是否放行，半开状态下放行的请求作为探测请求
+func (m *Manager) allow(b *breaker) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	openTimeout := time.Duration(b.policy.OpenTimeout) * time.Second
+	if b.state == StateOpen {
+		if wait := time.Until(b.openedAt.Add(openTimeout)); wait > 0 {
+			e := apperrors.Newf(apperrors.CategoryCircuitOpen, apperrors.CodeCircuitOpen, "circuit breaker %s is open", b.key)
+			e.RetryAfter = wait
+			return false, e
+		}
+		b.state = StateHalfOpen
+		b.probes = 0
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probes >= b.policy.HalfOpenRequests {
+			e := apperrors.Newf(apperrors.CategoryCircuitOpen, apperrors.CodeCircuitOpen, "circuit breaker %s is half open, probing", b.key)
+			e.RetryAfter = openTimeout
+			return false, e
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *Manager) cancelProbe(b *breaker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// record 半开状态下探测成功则关闭，失败则重新熔断；关闭状态下连续失败达到阈值后熔断
+func (m *Manager) record(b *breaker, probe bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failed := downstreamFailure(err)
+	switch {
+	case probe:
+		if b.state != StateHalfOpen {
+			return
+		}
+		b.probes--
+		if failed {
+			m.open(b)
+		} else {
+			b.state = StateClosed
+			b.failures = 0
+			logger.Info().Str("target", b.key).Msg("circuit breaker closed")
+		}
+	case b.state == StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			m.open(b)
+		}
+	}
+}
+
+func (m *Manager) open(b *breaker) {
+	b.state = StateOpen
+	b.failures = 0
+	b.probes = 0
+	b.openedAt = time.Now()
+	logger.Warn().Str("target", b.key).Int("open_timeout", b.policy.OpenTimeout).Msg("circuit breaker opened")
+}
+
+func (b *breaker) acquire(ctx context.Context) error {
+	if b.slots == nil {
+		return nil
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if b.policy.MaxWait > 0 {
+		timer := time.NewTimer(time.Duration(b.policy.MaxWait) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case b.slots <- struct{}{}:
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+			return apperrors.Classify(ctx.Err(), apperrors.CategoryTimeout, apperrors.CodeTimeout, "wait for bulkhead failed")
+		}
+	}
+	return apperrors.Newf(apperrors.CategoryBulkheadFull, apperrors.CodeBulkheadFull, "bulkhead %s is full (max_concurrent=%d)", b.key, b.policy.MaxConcurrent)
+}
+
+func (b *breaker) release() {
+	if b.slots != nil {
+		<-b.slots
+	}
+}
+
+// downstreamFailure 超时、连接失败、5xx 和 broker 错误说明下游异常；
+// 4xx、校验和配置错误是请求本身的问题，不计入熔断
+func downstreamFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var typed *apperrors.Error
+	if !errors.As(err, &typed) {
+		return true
+	}
+	switch typed.Category {
+	case apperrors.CategoryUnknown, apperrors.CategoryTimeout, apperrors.CategoryConnection,
+		apperrors.CategoryServer, apperrors.CategoryBroker:
+		return true
+	}
+	return false
+}
+
+// States 返回所有已使用过的目标的状态，按 key 排序
+func (m *Manager) States() []State {
+	if m == nil {
+		return []State{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make([]State, 0, len(m.breakers))
+	for _, b := range m.breakers {
+		s := State{
+			Key:           b.key,
+			State:         b.state,
+			Failures:      b.failures,
+			InFlight:      len(b.slots),
+			MaxConcurrent: b.policy.MaxConcurrent,
+		}
+		if b.state != StateClosed {
+			openedAt := b.openedAt
+			retryAt := openedAt.Add(time.Duration(b.policy.OpenTimeout) * time.Second)
+			s.OpenedAt, s.RetryAt = &openedAt, &retryAt
+			if b.state == StateOpen && !time.Now().Before(retryAt) {
+				s.State = StateHalfOpen
+			}
+		}
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Key < states[j].Key })
+	return states
+}
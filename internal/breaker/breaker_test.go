@@ -0,0 +1,92 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+)
+
+var serverErr = apperrors.HTTPStatus(503, "service unavailable")
+
+func category(err error) apperrors.Category {
+	var typed *apperrors.Error
+	if errors.As(err, &typed) {
+		return typed.Category
+	}
+	return 0
+}
+
+func TestBreakerStates(t *testing.T) {
+	m := NewManager(&config.BreakerConfig{
+		BreakerPolicy: config.BreakerPolicy{FailureThreshold: 2, OpenTimeout: 30},
+	})
+	ctx := context.Background()
+	key := "rpc:inventory-service"
+
+	// 4xx 不计入熔断
+	for i := 0; i < 3; i++ {
+		m.Do(ctx, key, func() error { return apperrors.HTTPStatus(400, "bad request") })
+	}
+	if s := m.States()[0]; s.State != StateClosed || s.Failures != 0 {
+		t.Fatalf("after client errors: %+v", s)
+	}
+
+	m.Do(ctx, key, func() error { return serverErr })
+	m.Do(ctx, key, func() error { return serverErr })
+
+	called := false
+	err := m.Do(ctx, key, func() error { called = true; return nil })
+	var typed *apperrors.Error
+	if called || !errors.As(err, &typed) || typed.Category != apperrors.CategoryCircuitOpen || typed.RetryAfter <= 0 {
+		t.Fatalf("open breaker: called = %v, err = %v", called, err)
+	}
+	if s := m.States()[0]; s.State != StateOpen || s.RetryAt == nil {
+		t.Fatalf("states = %+v, want open", s)
+	}
+
+	// 冷却结束后进入半开，探测失败重新熔断
+	m.breakers[key].openedAt = time.Now().Add(-time.Minute)
+	if err := m.Do(ctx, key, func() error { return serverErr }); category(err) != apperrors.CategoryServer {
+		t.Fatalf("probe err = %v", err)
+	}
+	if err := m.Do(ctx, key, func() error { return nil }); category(err) != apperrors.CategoryCircuitOpen {
+		t.Fatalf("after failed probe err = %v, want circuit open", err)
+	}
+
+	// 探测成功则关闭
+	m.breakers[key].openedAt = time.Now().Add(-time.Minute)
+	if err := m.Do(ctx, key, func() error { return nil }); err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if s := m.States()[0]; s.State != StateClosed {
+		t.Errorf("state = %s, want closed", s.State)
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	m := NewManager(&config.BreakerConfig{
+		Targets: map[string]config.BreakerPolicy{"db:orders": {MaxConcurrent: 1}},
+	})
+	ctx := context.Background()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	go m.Do(ctx, "db:orders", func() error {
+		close(entered)
+		<-release
+		return nil
+	})
+	<-entered
+
+	if err := m.Do(ctx, "db:orders", func() error { return nil }); category(err) != apperrors.CategoryBulkheadFull {
+		t.Errorf("err = %v, want bulkhead full", err)
+	}
+	// 其他目标不受影响
+	if err := m.Do(ctx, "db:payments", func() error { return nil }); err != nil {
+		t.Errorf("other target err = %v", err)
+	}
+	close(release)
+}
@@ -0,0 +1,275 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"dist_task/internal/engine"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/pkg/logger"
+)
+
+const (
+	ActionRetry    = "retry"
+	ActionHandle   = "handle"
+	ActionReassign = "reassign"
+)
+
+const batchSize = 100
+
+var ErrInvalidAction = errors.New("invalid bulk action")
+
+// Params 批量操作参数，随任务一起持久化
+type Params struct {
+	Remark   string `json:"remark,omitempty"`
+	Assignee string `json:"assignee,omitempty"`
+}
+
+func Validate(action string, params Params) error {
+	switch action {
+	case ActionRetry, ActionHandle:
+		return nil
+	case ActionReassign:
+		if params.Assignee == "" {
+			return errors.New("assignee is required for reassign")
+		}
+		return nil
+	default:
+		return ErrInvalidAction
+	}
+}
+
+// Runner 在后台按 ID 游标分批处理异常批量操作，进度写回任务记录，
+// 服务重启后从 last_id 继续
+type Runner struct {
+	jobRepo       *repository.BulkJobRepository
+	exceptionRepo *repository.ExceptionRepository
+	auditRepo     *repository.AuditRepository
+	taskRepo      *repository.TaskRepository
+	instanceRepo  *repository.InstanceRepository
+	engine        *engine.Engine
+
+	jobs   chan string
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewRunner(jobRepo *repository.BulkJobRepository, exceptionRepo *repository.ExceptionRepository, auditRepo *repository.AuditRepository,
+	taskRepo *repository.TaskRepository, instanceRepo *repository.InstanceRepository, eng *engine.Engine) *Runner {
+	return &Runner{
+		jobRepo:       jobRepo,
+		exceptionRepo: exceptionRepo,
+		auditRepo:     auditRepo,
+		taskRepo:      taskRepo,
+		instanceRepo:  instanceRepo,
+		engine:        eng,
+		jobs:          make(chan string, 64),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+func (r *Runner) Start() {
+	r.wg.Add(1)
+	go r.run()
+
+	jobs, err := r.jobRepo.ListUnfinished()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load unfinished bulk jobs")
+	}
+	for _, job := range jobs {
+		r.Submit(job.ID)
+	}
+	log.Printf("Bulk runner started, %d unfinished jobs resumed", len(jobs))
+}
+
+func (r *Runner) Stop() {
+	close(r.stopCh)
+	r.wg.Wait()
+	log.Println("Bulk runner stopped")
+}
+
+// Submit 提交已创建的任务；队列满时任务保持 pending，下次启动时继续
+func (r *Runner) Submit(jobID string) {
+	select {
+	case r.jobs <- jobID:
+	default:
+		logger.Warn().Str("job_id", jobID).Msg("bulk job queue full")
+	}
+}
+
+func (r *Runner) run() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case jobID := <-r.jobs:
+			r.process(jobID)
+		}
+	}
+}
+
+func (r *Runner) process(jobID string) {
+	job, err := r.jobRepo.GetByID(jobID)
+	if err != nil {
+		logger.Error().Err(err).Str("job_id", jobID).Msg("Failed to load bulk job")
+		return
+	}
+	if job.Status == "completed" || job.Status == "failed" {
+		return
+	}
+
+	var filter repository.ExceptionFilter
+	var params Params
+	if err := json.Unmarshal([]byte(job.Filter), &filter); err != nil {
+		r.fail(job, fmt.Errorf("decode filter: %w", err))
+		return
+	}
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		r.fail(job, fmt.Errorf("decode params: %w", err))
+		return
+	}
+
+	// 任务在提交请求的租户范围内执行
+	exceptions := r.exceptionRepo.ForTenant(job.TenantID)
+	audits := r.auditRepo.ForTenant(job.TenantID)
+
+	job.Status = "running"
+	if err := r.jobRepo.Update(job); err != nil {
+		logger.Error().Err(err).Str("job_id", jobID).Msg("Failed to update bulk job")
+		return
+	}
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+
+		batch, err := exceptions.ListAfter(filter, job.LastID, batchSize)
+		if err != nil {
+			r.fail(job, err)
+			return
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			if err := r.apply(job, &batch[i], params, exceptions, audits); err != nil {
+				job.Failed++
+				logger.Warn().Err(err).Str("job_id", jobID).Int64("exception_id", batch[i].ID).Msg("bulk operation skipped")
+			} else {
+				job.Succeeded++
+			}
+			job.Processed++
+			job.LastID = batch[i].ID
+		}
+
+		if err := r.jobRepo.Update(job); err != nil {
+			logger.Error().Err(err).Str("job_id", jobID).Msg("Failed to update bulk job progress")
+			return
+		}
+	}
+
+	now := time.Now()
+	job.Status = "completed"
+	job.CompletedAt = &now
+	if err := r.jobRepo.Update(job); err != nil {
+		logger.Error().Err(err).Str("job_id", jobID).Msg("Failed to complete bulk job")
+	}
+}
+
+func (r *Runner) apply(job *model.ExceptionBulkJob, exception *model.ExceptionRecord, params Params,
+	exceptions *repository.ExceptionRepository, audits *repository.AuditRepository) error {
+	before := *exception
+	now := time.Now()
+
+	var action string
+	switch job.Action {
+	case ActionRetry:
+		if exception.Handled {
+			return errors.New("handled exception cannot be retried")
+		}
+		if exception.RetryStrategy == "no_retry" {
+			return errors.New("exception is configured to not retry")
+		}
+		if err := r.retry(job.TenantID, exception); err != nil {
+			return err
+		}
+		action = "exception.retry"
+	case ActionHandle:
+		if exception.Handled {
+			return errors.New("exception already handled")
+		}
+		exception.Handled = true
+		exception.HandledAt = &now
+		exception.HandledBy = job.CreatedBy
+		exception.HandledRemark = params.Remark
+		action = "exception.handle"
+	case ActionReassign:
+		exception.Assignee = params.Assignee
+		action = "exception.reassign"
+	default:
+		return ErrInvalidAction
+	}
+
+	if err := exceptions.Update(exception); err != nil {
+		return err
+	}
+
+	beforeState, _ := json.Marshal(before)
+	afterState, _ := json.Marshal(exception)
+	entry := &model.AuditLog{
+		Actor:       job.CreatedBy,
+		Action:      action,
+		TargetType:  "exception",
+		TargetID:    strconv.FormatInt(exception.ID, 10),
+		InstanceID:  exception.GroupID,
+		ClientIP:    job.ClientIP,
+		BeforeState: string(beforeState),
+		AfterState:  string(afterState),
+		Remark:      "bulk job " + job.ID,
+	}
+	if err := audits.Create(entry); err != nil {
+		logger.Error().Err(err).Str("job_id", job.ID).Msg("write audit log failed")
+	}
+	return nil
+}
+
+// retry 立即重跑异常对应的任务，不经过重试调度器，人工处理的异常同样生效；
+// 成功后将重试次数记满，避免调度器再次重试
+func (r *Runner) retry(tenantID string, exception *model.ExceptionRecord) error {
+	task, err := r.taskRepo.ForTenant(tenantID).GetByID(exception.TaskID)
+	if err != nil {
+		return fmt.Errorf("load task: %w", err)
+	}
+	instance, err := r.instanceRepo.ForTenant(tenantID).GetByID(exception.GroupID)
+	if err != nil {
+		return fmt.Errorf("load instance: %w", err)
+	}
+	if err := r.engine.RetryTask(context.Background(), instance, task.TaskKey); err != nil {
+		return err
+	}
+	exception.RetryTimes = exception.RetryMax
+	return nil
+}
+
+func (r *Runner) fail(job *model.ExceptionBulkJob, err error) {
+	now := time.Now()
+	job.Status = "failed"
+	job.ErrorMessage = err.Error()
+	job.CompletedAt = &now
+	if updateErr := r.jobRepo.Update(job); updateErr != nil {
+		logger.Error().Err(updateErr).Str("job_id", job.ID).Msg("Failed to update bulk job")
+	}
+}
@@ -0,0 +1,268 @@
+package bulk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dist_task/internal/engine"
+	"dist_task/internal/engine/executor"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/internal/repository/repotest"
+
+	"gorm.io/gorm"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		params  Params
+		wantErr bool
+	}{
+		{name: "retry", action: ActionRetry},
+		{name: "handle", action: ActionHandle, params: Params{Remark: "ok"}},
+		{name: "reassign", action: ActionReassign, params: Params{Assignee: "bob"}},
+		{name: "reassign without assignee", action: ActionReassign, wantErr: true},
+		{name: "unknown action", action: "delete", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.action, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newTestRunner(t *testing.T) (*Runner, *gorm.DB) {
+	conn := repotest.Open(t)
+	instanceRepo := &repository.InstanceRepository{}
+	taskRepo := &repository.TaskRepository{}
+	exceptionRepo := &repository.ExceptionRepository{}
+	eng := engine.NewEngine(
+		&repository.FlowRepository{},
+		instanceRepo,
+		taskRepo,
+		exceptionRepo,
+		&repository.LogRepository{},
+		executor.NewExecutorFactory(nil, nil, nil, nil, nil, nil, nil, false),
+		nil,
+		nil,
+		&repository.WorkerTaskRepository{},
+		nil,
+	)
+	runner := NewRunner(&repository.BulkJobRepository{}, exceptionRepo, &repository.AuditRepository{}, taskRepo, instanceRepo, eng)
+	return runner, conn
+}
+
+func createJob(t *testing.T, conn *gorm.DB, action string, filter repository.ExceptionFilter, params Params) *model.ExceptionBulkJob {
+	t.Helper()
+	filterJSON, _ := json.Marshal(filter)
+	paramsJSON, _ := json.Marshal(params)
+	job := &model.ExceptionBulkJob{ID: "job", TenantID: "default", Action: action, Filter: string(filterJSON), Params: string(paramsJSON), Status: "pending", CreatedBy: "alice"}
+	if err := conn.Create(job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func getJob(t *testing.T, conn *gorm.DB) *model.ExceptionBulkJob {
+	t.Helper()
+	var job model.ExceptionBulkJob
+	if err := conn.First(&job, "id = ?", "job").Error; err != nil {
+		t.Fatal(err)
+	}
+	return &job
+}
+
+func exception(taskName string) model.ExceptionRecord {
+	return model.ExceptionRecord{TenantID: "default", GroupID: "inst", TaskID: "inst_" + taskName, TaskName: taskName, RetryStrategy: "manual", RetryMax: 3, OccurredAt: time.Now()}
+}
+
+func TestProcess_CursorBatches(t *testing.T) {
+	r, conn := newTestRunner(t)
+
+	// 超过一个批次，且穿插不匹配筛选条件的记录
+	var exceptions []model.ExceptionRecord
+	for i := 0; i < batchSize*2+50; i++ {
+		exceptions = append(exceptions, exception("charge"), exception("notify"))
+	}
+	if err := conn.CreateInBatches(exceptions, 100).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 处理后的记录不再匹配 handled=false，按偏移分页会漏处理
+	handled := false
+	filter := repository.ExceptionFilter{Handled: &handled, TaskName: "charge"}
+	createJob(t, conn, ActionHandle, filter, Params{Remark: "refunded"})
+
+	r.process("job")
+
+	job := getJob(t, conn)
+	want := int64(batchSize*2 + 50)
+	if job.Status != "completed" || job.CompletedAt == nil {
+		t.Errorf("job status = %s, completed_at = %v", job.Status, job.CompletedAt)
+	}
+	if job.Processed != want || job.Succeeded != want || job.Failed != 0 {
+		t.Errorf("processed/succeeded/failed = %d/%d/%d, want %d/%d/0", job.Processed, job.Succeeded, job.Failed, want, want)
+	}
+
+	var remaining, audits int64
+	conn.Model(&model.ExceptionRecord{}).Where("task_name = ? AND handled = ?", "charge", false).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("%d matching exceptions left unhandled", remaining)
+	}
+	var untouched int64
+	conn.Model(&model.ExceptionRecord{}).Where("task_name = ? AND handled = ?", "notify", false).Count(&untouched)
+	if untouched != want {
+		t.Errorf("non-matching exceptions unhandled = %d, want %d", untouched, want)
+	}
+	conn.Model(&model.AuditLog{}).Where("action = ? AND actor = ?", "exception.handle", "alice").Count(&audits)
+	if audits != want {
+		t.Errorf("audit entries = %d, want %d", audits, want)
+	}
+
+	var last model.ExceptionRecord
+	conn.Where("task_name = ?", "charge").Order("id DESC").First(&last)
+	if job.LastID != last.ID {
+		t.Errorf("last_id = %d, want %d", job.LastID, last.ID)
+	}
+}
+
+func TestProcess_Retry(t *testing.T) {
+	r, conn := newTestRunner(t)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	config, _ := json.Marshal(map[string]interface{}{"url": srv.URL, "method": "POST"})
+	definition, _ := json.Marshal(engine.FlowDefinition{Name: "test", Tasks: []engine.FlowTask{{ID: "charge", TaskName: "http_request", Config: config}}})
+	conn.Create(&model.TaskGroupFlow{ID: "flow", TenantID: "default", Name: "test", FlowType: "sequential", Definition: string(definition)})
+	conn.Create(&model.TaskGroupInstance{ID: "inst", TenantID: "default", FlowID: "flow", Status: "failed", Params: "{}"})
+	conn.Create(&model.DistTask{ID: "inst_charge", TenantID: "default", GroupID: "inst", TaskKey: "charge", Type: "http", Status: "failed"})
+
+	manual := exception("charge")
+	handled := exception("charge")
+	handled.Handled = true
+	noRetry := exception("charge")
+	noRetry.RetryStrategy = "no_retry"
+	missing := exception("charge")
+	missing.TaskID = "inst_unknown"
+	for _, ex := range []*model.ExceptionRecord{&manual, &handled, &noRetry, &missing} {
+		conn.Create(ex)
+	}
+
+	createJob(t, conn, ActionRetry, repository.ExceptionFilter{}, Params{})
+	r.process("job")
+
+	job := getJob(t, conn)
+	if job.Status != "completed" || job.Processed != 4 || job.Succeeded != 1 || job.Failed != 3 {
+		t.Errorf("job = %s, processed/succeeded/failed = %d/%d/%d, want completed 4/1/3", job.Status, job.Processed, job.Succeeded, job.Failed)
+	}
+
+	// 人工重试的异常不经过重试调度器，立即重跑
+	if calls != 1 {
+		t.Errorf("task executed %d times, want 1", calls)
+	}
+	var record model.DistTask
+	conn.First(&record, "id = ?", "inst_charge")
+	if record.Status != "success" {
+		t.Errorf("task status = %s, want success", record.Status)
+	}
+	var retried model.ExceptionRecord
+	conn.First(&retried, manual.ID)
+	if retried.RetryTimes != retried.RetryMax {
+		t.Errorf("retry_times = %d, want %d", retried.RetryTimes, retried.RetryMax)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var instance model.TaskGroupInstance
+		conn.First(&instance, "id = ?", "inst")
+		if instance.Status == "success" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("instance status = %s, want success", instance.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcess_Status(t *testing.T) {
+	tests := []struct {
+		name          string
+		job           model.ExceptionBulkJob
+		wantStatus    string
+		wantError     bool
+		wantSucceeded int64
+		wantFailed    int64
+		wantAssignee  string
+	}{
+		{
+			name:       "invalid filter",
+			job:        model.ExceptionBulkJob{Action: ActionHandle, Filter: "{", Params: "{}", Status: "pending"},
+			wantStatus: "failed",
+			wantError:  true,
+		},
+		{
+			name:       "unknown action",
+			job:        model.ExceptionBulkJob{Action: "delete", Filter: "{}", Params: "{}", Status: "pending"},
+			wantStatus: "completed",
+			wantFailed: 1,
+		},
+		{
+			name:          "resumed running job",
+			job:           model.ExceptionBulkJob{Action: ActionReassign, Filter: "{}", Params: `{"assignee":"bob"}`, Status: "running"},
+			wantStatus:    "completed",
+			wantSucceeded: 1,
+			wantAssignee:  "bob",
+		},
+		{
+			name:       "already completed",
+			job:        model.ExceptionBulkJob{Action: ActionReassign, Filter: "{}", Params: `{"assignee":"bob"}`, Status: "completed"},
+			wantStatus: "completed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, conn := newTestRunner(t)
+			ex := exception("charge")
+			conn.Create(&ex)
+
+			tt.job.ID = "job"
+			tt.job.TenantID = "default"
+			conn.Create(&tt.job)
+
+			r.process("job")
+
+			job := getJob(t, conn)
+			if job.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", job.Status, tt.wantStatus)
+			}
+			if (job.ErrorMessage != "") != tt.wantError {
+				t.Errorf("error_message = %q", job.ErrorMessage)
+			}
+			if job.Succeeded != tt.wantSucceeded || job.Failed != tt.wantFailed {
+				t.Errorf("succeeded/failed = %d/%d, want %d/%d", job.Succeeded, job.Failed, tt.wantSucceeded, tt.wantFailed)
+			}
+
+			var stored model.ExceptionRecord
+			conn.First(&stored, ex.ID)
+			if stored.Assignee != tt.wantAssignee {
+				t.Errorf("assignee = %q, want %q", stored.Assignee, tt.wantAssignee)
+			}
+		})
+	}
+}
@@ -8,11 +8,27 @@ import (
 )
 
 type Config struct {
-	App      AppConfig      `toml:"app"`
-	Database DatabaseConfig `toml:"database"`
-	RocketMQ RocketMQConfig `toml:"rocketmq"`
-	Log      LogConfig      `toml:"log"`
-	Retry    RetryConfig    `toml:"retry"`
+	App        AppConfig        `toml:"app"`
+	Database   DatabaseConfig   `toml:"database"`
+	DBTask     DBTaskConfig     `toml:"db_task"`
+	RocketMQ   RocketMQConfig   `toml:"rocketmq"`
+	Kafka      KafkaConfig      `toml:"kafka"`
+	NATS       NATSConfig       `toml:"nats"`
+	Messaging  MessagingConfig  `toml:"messaging"`
+	GRPC       GRPCConfig       `toml:"grpc"`
+	Transform  TransformConfig  `toml:"transform"`
+	Command    CommandConfig    `toml:"command"`
+	Worker     WorkerConfig     `toml:"worker"`
+	Discovery  DiscoveryConfig  `toml:"discovery"`
+	Breaker    BreakerConfig    `toml:"breaker"`
+	RateLimit  RateLimitConfig  `toml:"ratelimit"`
+	Log        LogConfig        `toml:"log"`
+	Retry      RetryConfig      `toml:"retry"`
+	Timer      TimerConfig      `toml:"timer"`
+	Encryption EncryptionConfig `toml:"encryption"`
+	Auth       AuthConfig       `toml:"auth"`
+	Tenancy    TenancyConfig    `toml:"tenancy"`
+	Outbox     OutboxConfig     `toml:"outbox"`
 }
 
 type AppConfig struct {
@@ -37,10 +53,135 @@ func (d *DatabaseConfig) DSN() string {
 		d.Username, d.Password, d.Host, d.Port, d.Name)
 }
 
+// DBTaskConfig DB 任务可访问的数据源和表，未指定 datasource 的任务使用平台数据库
+type DBTaskConfig struct {
+	Tables      []string                    `toml:"tables"` // 平台数据库中允许访问的表，为空时不能访问任何表，不能包含平台自身的表
+	Datasources map[string]DatasourceConfig `toml:"datasources"`
+}
+
+type DatasourceConfig struct {
+	DSN             string   `toml:"dsn"`    // MySQL DSN，如 user:pass@tcp(host:3306)/db?parseTime=True&loc=Local
+	Tables          []string `toml:"tables"` // 允许访问的表，为空时不能访问任何表
+	MaxOpenConns    int      `toml:"max_open_conns"`
+	MaxIdleConns    int      `toml:"max_idle_conns"`
+	ConnMaxLifetime int      `toml:"conn_max_lifetime"`
+}
+
 type RocketMQConfig struct {
-	NameServer    string `toml:"namesrv"`
-	ProducerGroup string `toml:"producer_group"`
-	ConsumerGroup string `toml:"consumer_group"`
+	NameServer       string `toml:"namesrv"`
+	ProducerGroup    string `toml:"producer_group"`
+	ConsumerGroup    string `toml:"consumer_group"`
+	TransactionGroup string `toml:"transaction_group"` // 事务消息生产者组，为空时不支持事务消息
+}
+
+// KafkaConfig brokers 为空时不启用
+type KafkaConfig struct {
+	Brokers       []string `toml:"brokers"`
+	ClientID      string   `toml:"client_id"`
+	Acks          string   `toml:"acks"`           // all / leader / none，默认 all，仅 all 时启用幂等生产
+	SASLMechanism string   `toml:"sasl_mechanism"` // PLAIN / SCRAM-SHA-256 / SCRAM-SHA-512，为空时不认证
+	Username      string   `toml:"username"`
+	Password      string   `toml:"password"`
+	TLS           bool     `toml:"tls"`
+	Timeout       int      `toml:"timeout"` // 单条消息投递超时（秒）
+}
+
+// NATSConfig url 为空时不启用
+type NATSConfig struct {
+	URL       string `toml:"url"`
+	Token     string `toml:"token"`
+	Username  string `toml:"username"`
+	Password  string `toml:"password"`
+	JetStream bool   `toml:"jetstream"` // 使用 JetStream 发布，支持按消息 Key 去重
+	Timeout   int    `toml:"timeout"`   // 连接超时（秒）
+}
+
+type MessagingConfig struct {
+	DefaultBroker string `toml:"default_broker"` // MQ 任务未指定 broker 时使用，默认 rocketmq
+	Memory        bool   `toml:"memory"`         // 注册内存 broker，用于本地开发
+}
+
+// GRPCConfig gRPC 协议的 RPC 任务按 descriptor_sets 或服务端反射解析方法
+// TransformConfig transform 任务的资源上限，任务配置只能在此范围内调低
+type TransformConfig struct {
+	Timeout  int    `toml:"timeout"`   // 单次执行超时（毫秒）
+	MaxSteps uint64 `toml:"max_steps"` // 单次执行最多执行的 Starlark 指令数
+}
+
+// CommandConfig command 任务可执行的程序和目录，默认关闭
+type CommandConfig struct {
+	Enabled     bool              `toml:"enabled"`
+	Allowed     []string          `toml:"allowed"`      // 允许执行的程序，绝对路径
+	WorkDir     string            `toml:"work_dir"`     // 默认工作目录
+	AllowedDirs []string          `toml:"allowed_dirs"` // 任务可以指定的工作目录（含子目录），work_dir 总是允许
+	Env         map[string]string `toml:"env"`          // 所有命令共用的环境变量，不继承服务进程的环境变量
+	Timeout     int               `toml:"timeout"`      // 默认超时（秒），也是任务配置的上限
+	MaxOutput   int               `toml:"max_output"`   // stdout、stderr 各自保留的最大字节数
+}
+
+// WorkerConfig 外部 worker 进程领取 worker 任务的协议参数
+type WorkerConfig struct {
+	PollTimeout         int `toml:"poll_timeout"`           // 长轮询最长等待秒数
+	StartToCloseTimeout int `toml:"start_to_close_timeout"` // 任务领取后必须完成的默认时限（秒）
+}
+
+type GRPCConfig struct {
+	DescriptorSets []string `toml:"descriptor_sets"` // protoc --descriptor_set_out 生成的文件，需包含依赖（--include_imports）
+	Reflection     bool     `toml:"reflection"`      // descriptor_sets 中找不到服务时通过服务端反射获取
+	Timeout        int      `toml:"timeout"`         // 默认调用超时（秒）
+}
+
+// DiscoveryConfig RPC / HTTP 任务的服务发现，未被任何 provider 识别的服务名按字面地址访问
+type DiscoveryConfig struct {
+	Providers    []string                    `toml:"providers"`     // static / file / dns，按顺序查找
+	Policy       string                      `toml:"policy"`        // round_robin（默认）/ weighted
+	Retries      int                         `toml:"retries"`       // 连接失败时换节点重试的次数
+	MaxFailures  int                         `toml:"max_failures"`  // 连续失败多少次后摘除节点，0 表示不摘除
+	EjectionTime int                         `toml:"ejection_time"` // 摘除时长（秒）
+	Static       map[string][]EndpointConfig `toml:"static"`
+	File         string                      `toml:"file"` // JSON 格式的本地注册表，修改后自动重新加载
+	DNS          DNSDiscoveryConfig          `toml:"dns"`
+}
+
+type EndpointConfig struct {
+	Address string `toml:"address" json:"address"`
+	Weight  int    `toml:"weight" json:"weight"`
+}
+
+type DNSDiscoveryConfig struct {
+	NameFormat string `toml:"name_format"` // SRV 记录名格式，如 "%s.service.consul"
+	TTL        int    `toml:"ttl"`         // 解析结果缓存时长（秒）
+}
+
+// BreakerConfig 按下游目标熔断和限制并发，targets 中的配置覆盖默认值
+type BreakerConfig struct {
+	Enabled bool `toml:"enabled"`
+	BreakerPolicy
+	Targets map[string]BreakerPolicy `toml:"targets"` // 键为目标，如 rpc:inventory-service、http:api.example.com
+}
+
+// BreakerPolicy 为 0 的字段使用默认值
+type BreakerPolicy struct {
+	FailureThreshold int `toml:"failure_threshold"`  // 连续失败多少次后熔断
+	OpenTimeout      int `toml:"open_timeout"`       // 熔断时长（秒），之后进入半开状态
+	HalfOpenRequests int `toml:"half_open_requests"` // 半开状态允许的探测请求数
+	MaxConcurrent    int `toml:"max_concurrent"`     // 最大并发，0 表示不限制
+	MaxWait          int `toml:"max_wait"`           // 并发已满时等待的毫秒数
+}
+
+// RateLimitConfig 出站调用的令牌桶限流，按任务定义、下游目标和事务流分别计数
+type RateLimitConfig struct {
+	Enabled     bool                     `toml:"enabled"`
+	Distributed bool                     `toml:"distributed"` // 令牌桶保存在数据库中，多副本共享配额
+	InlineWait  int                      `toml:"inline_wait"` // 等待不超过该毫秒数时原地等待，否则挂起任务
+	Tasks       map[string]RateLimitRule `toml:"tasks"`       // 键为任务名，覆盖任务定义中的 rate_limit
+	Targets     map[string]RateLimitRule `toml:"targets"`     // 键与熔断相同，如 http:api.partner.com
+	Flows       map[string]RateLimitRule `toml:"flows"`       // 键为事务流 ID
+}
+
+type RateLimitRule struct {
+	Rate  float64 `toml:"rate"`  // 每秒令牌数
+	Burst int     `toml:"burst"` // 桶容量，默认为 rate 向上取整
 }
 
 type LogConfig struct {
@@ -54,6 +195,62 @@ type RetryConfig struct {
 	DefaultInterval    int `toml:"default_interval"`
 }
 
+type TimerConfig struct {
+	ScanInterval int `toml:"scan_interval"`
+}
+
+type EncryptionConfig struct {
+	Provider string `toml:"provider"` // 为空时不加密，目前支持 keyfile
+	KeyFile  string `toml:"key_file"`
+}
+
+type AuthConfig struct {
+	Enabled bool           `toml:"enabled"`
+	APIKeys []APIKeyConfig `toml:"api_keys"`
+	JWT     JWTConfig      `toml:"jwt"`
+}
+
+type APIKeyConfig struct {
+	Name   string `toml:"name"`
+	Key    string `toml:"key"`
+	Role   string `toml:"role"`   // viewer / operator / flow-admin / worker
+	Tenant string `toml:"tenant"` // 为空时从请求头读取租户
+}
+
+type JWTConfig struct {
+	Enabled         bool   `toml:"enabled"`
+	JWKSURL         string `toml:"jwks_url"` // 为空时通过 issuer 的 OIDC discovery 获取
+	Issuer          string `toml:"issuer"`
+	Audience        string `toml:"audience"`
+	RoleClaim       string `toml:"role_claim"`
+	NameClaim       string `toml:"name_claim"`
+	TenantClaim     string `toml:"tenant_claim"`
+	RefreshInterval int    `toml:"refresh_interval"` // JWKS 最小刷新间隔（秒）
+}
+
+type TenancyConfig struct {
+	Header        string         `toml:"header"`
+	DefaultTenant string         `toml:"default_tenant"`
+	Defaults      TenantConfig   `toml:"defaults"` // 未单独配置的租户使用
+	Tenants       []TenantConfig `toml:"tenants"`
+}
+
+type TenantConfig struct {
+	ID               string   `toml:"id"`
+	MaxConcurrent    int      `toml:"max_concurrent"`     // 未结束实例数上限，0 表示不限
+	RateLimit        float64  `toml:"rate_limit"`         // 每秒可启动的实例数，0 表示不限
+	Burst            int      `toml:"burst"`              // 允许的突发数
+	AllowedTaskTypes []string `toml:"allowed_task_types"` // 为空表示不限制
+}
+
+type OutboxConfig struct {
+	Enabled       bool `toml:"enabled"`        // 开启后 MQ 任务先写入 outbox，由 relay 异步投递
+	PollInterval  int  `toml:"poll_interval"`  // 扫描间隔（秒）
+	BatchSize     int  `toml:"batch_size"`     // 每次领取的消息数
+	MaxAttempts   int  `toml:"max_attempts"`   // 最大投递次数，超过后标记为 failed
+	RetryInterval int  `toml:"retry_interval"` // 重试间隔（秒），按投递次数线性增长
+}
+
 var GlobalConfig *Config
 
 func Load(path string) (*Config, error) {
@@ -0,0 +1,233 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+const (
+	ProviderStatic = "static"
+	ProviderFile   = "file"
+	ProviderDNS    = "dns"
+
+	PolicyRoundRobin = "round_robin"
+	PolicyWeighted   = "weighted"
+)
+
+// Discovery 按 provider 顺序解析服务名，在健康节点间负载均衡，
+// 连续失败的节点被临时摘除，连接失败时换节点重试
+type Discovery struct {
+	resolvers    []Resolver
+	policy       string
+	retries      int
+	maxFailures  int
+	ejectionTime time.Duration
+
+	mu      sync.Mutex
+	next    map[string]int           // round_robin：服务 -> 下一个节点序号
+	current map[string]int           // weighted：节点 -> 当前权重
+	states  map[string]*outlierState // 节点 -> 失败状态
+}
+
+type outlierState struct {
+	failures     int
+	ejectedUntil time.Time
+}
+
+func NewDiscovery(cfg *config.DiscoveryConfig) (*Discovery, error) {
+	policy := cfg.Policy
+	if policy == "" {
+		policy = PolicyRoundRobin
+	}
+	if policy != PolicyRoundRobin && policy != PolicyWeighted {
+		return nil, fmt.Errorf("unknown discovery policy: %s", cfg.Policy)
+	}
+
+	var resolvers []Resolver
+	for _, provider := range cfg.Providers {
+		switch provider {
+		case ProviderStatic:
+			resolvers = append(resolvers, NewStaticResolver(cfg.Static))
+		case ProviderFile:
+			if cfg.File == "" {
+				return nil, fmt.Errorf("discovery file provider requires file")
+			}
+			resolvers = append(resolvers, NewFileResolver(cfg.File))
+		case ProviderDNS:
+			resolvers = append(resolvers, NewDNSResolver(&cfg.DNS))
+		default:
+			return nil, fmt.Errorf("unknown discovery provider: %s", provider)
+		}
+	}
+
+	return New(policy, cfg.Retries, cfg.MaxFailures, time.Duration(cfg.EjectionTime)*time.Second, resolvers...), nil
+}
+
+func New(policy string, retries, maxFailures int, ejectionTime time.Duration, resolvers ...Resolver) *Discovery {
+	return &Discovery{
+		resolvers:    resolvers,
+		policy:       policy,
+		retries:      retries,
+		maxFailures:  maxFailures,
+		ejectionTime: ejectionTime,
+		next:         make(map[string]int),
+		current:      make(map[string]int),
+		states:       make(map[string]*outlierState),
+	}
+}
+
+// Do 选择节点执行 fn，连接失败时换一个未尝试过的节点重试。
+// 服务名未被任何 resolver 识别时按字面地址直接调用；d 为 nil 时同样直接调用
+func (d *Discovery) Do(ctx context.Context, service string, fn func(address string) error) error {
+	if d == nil {
+		return fn(service)
+	}
+
+	endpoints, err := d.resolve(ctx, service)
+	if errors.Is(err, ErrNotFound) {
+		return fn(service)
+	}
+	if err != nil {
+		return apperrors.Classify(err, apperrors.CategoryConnection, apperrors.CodeConnectionFailed, "resolve service "+service+" failed")
+	}
+	if len(endpoints) == 0 {
+		return apperrors.Newf(apperrors.CategoryConnection, apperrors.CodeConnectionFailed, "no endpoints for service %s", service)
+	}
+
+	tried := make(map[string]bool)
+	var lastErr error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		ep, ok := d.pick(service, endpoints, tried)
+		if !ok {
+			break
+		}
+		tried[ep.Address] = true
+
+		err := fn(ep.Address)
+		d.report(ep.Address, err)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		logger.Warn().Err(err).Str("service", service).Str("endpoint", ep.Address).Msg("endpoint failed, trying next")
+	}
+	return lastErr
+}
+
+func (d *Discovery) resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	for _, r := range d.resolvers {
+		endpoints, err := r.Resolve(ctx, service)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return endpoints, err
+	}
+	return nil, ErrNotFound
+}
+
+// pick 从未尝试过的节点中选择，优先未被摘除的节点；全部被摘除时忽略摘除状态
+func (d *Discovery) pick(service string, endpoints []Endpoint, tried map[string]bool) (Endpoint, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var healthy, untried []Endpoint
+	for _, ep := range endpoints {
+		if tried[ep.Address] {
+			continue
+		}
+		untried = append(untried, ep)
+		if state, ok := d.states[ep.Address]; !ok || !now.Before(state.ejectedUntil) {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = untried
+	}
+	if len(candidates) == 0 {
+		return Endpoint{}, false
+	}
+
+	if d.policy == PolicyWeighted {
+		return d.pickWeighted(candidates), true
+	}
+	i := d.next[service] % len(candidates)
+	d.next[service] = i + 1
+	return candidates[i], true
+}
+
+// pickWeighted 平滑加权轮询，权重小于 1 按 1 处理
+func (d *Discovery) pickWeighted(candidates []Endpoint) Endpoint {
+	total := 0
+	best := -1
+	for i, ep := range candidates {
+		weight := ep.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		total += weight
+		d.current[ep.Address] += weight
+		if best < 0 || d.current[ep.Address] > d.current[candidates[best].Address] {
+			best = i
+		}
+	}
+	d.current[candidates[best].Address] -= total
+	return candidates[best]
+}
+
+// report 记录调用结果，连续失败达到 maxFailures 次的节点摘除 ejectionTime
+func (d *Discovery) report(address string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.states[address]
+	if !ok {
+		state = &outlierState{}
+		d.states[address] = state
+	}
+	if !endpointFailure(err) {
+		state.failures = 0
+		return
+	}
+
+	state.failures++
+	if d.maxFailures > 0 && state.failures >= d.maxFailures {
+		state.failures = 0
+		state.ejectedUntil = time.Now().Add(d.ejectionTime)
+		logger.Warn().Str("endpoint", address).Dur("ejection_time", d.ejectionTime).Msg("endpoint ejected")
+	}
+}
+
+// endpointFailure 超时、连接失败和服务端错误计入节点失败次数，4xx 等客户端错误不计入
+func endpointFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var typed *apperrors.Error
+	if !errors.As(err, &typed) {
+		return true
+	}
+	switch typed.Category {
+	case apperrors.CategoryTimeout, apperrors.CategoryConnection, apperrors.CategoryServer:
+		return true
+	}
+	return false
+}
+
+// retryable 只有连接失败时请求未送达，换节点重试是安全的
+func retryable(err error) bool {
+	var typed *apperrors.Error
+	return errors.As(err, &typed) && typed.Category == apperrors.CategoryConnection
+}
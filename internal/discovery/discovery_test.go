@@ -0,0 +1,163 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+)
+
+var connErr = apperrors.New(apperrors.CategoryConnection, apperrors.CodeConnectionRefused, "connection refused")
+
+func staticDiscovery(policy string, retries, maxFailures int, endpoints ...config.EndpointConfig) *Discovery {
+	return New(policy, retries, maxFailures, time.Minute, NewStaticResolver(map[string][]config.EndpointConfig{"payment-service": endpoints}))
+}
+
+func TestDiscoveryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   []string
+	}{
+		{"round robin ignores weight", PolicyRoundRobin, []string{"a:80", "b:80", "a:80", "b:80"}},
+		{"smooth weighted", PolicyWeighted, []string{"b:80", "a:80", "b:80", "b:80"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := staticDiscovery(tt.policy, 0, 0,
+				config.EndpointConfig{Address: "a:80", Weight: 1},
+				config.EndpointConfig{Address: "b:80", Weight: 3},
+			)
+
+			var got []string
+			for range tt.want {
+				d.Do(context.Background(), "payment-service", func(address string) error {
+					got = append(got, address)
+					return nil
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("picked %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRetryAndEjection(t *testing.T) {
+	d := staticDiscovery(PolicyRoundRobin, 1, 1,
+		config.EndpointConfig{Address: "down:80"},
+		config.EndpointConfig{Address: "up:80"},
+	)
+
+	var tried []string
+	err := d.Do(context.Background(), "payment-service", func(address string) error {
+		tried = append(tried, address)
+		if address == "down:80" {
+			return connErr
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(tried, []string{"down:80", "up:80"}) {
+		t.Fatalf("Do() error = %v, tried %v", err, tried)
+	}
+
+	// down:80 已被摘除，之后只会选到 up:80
+	for i := 0; i < 3; i++ {
+		d.Do(context.Background(), "payment-service", func(address string) error {
+			if address != "up:80" {
+				t.Errorf("picked ejected endpoint %s", address)
+			}
+			return nil
+		})
+	}
+
+	// 非连接错误不换节点重试
+	calls := 0
+	err = d.Do(context.Background(), "payment-service", func(address string) error {
+		calls++
+		return apperrors.HTTPStatus(400, "bad request")
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("client error: calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestDiscoveryFallback(t *testing.T) {
+	var d *Discovery
+	d.Do(context.Background(), "localhost:8080", func(address string) error {
+		if address != "localhost:8080" {
+			t.Errorf("nil discovery address = %s", address)
+		}
+		return nil
+	})
+
+	d = staticDiscovery(PolicyRoundRobin, 0, 0)
+	d.Do(context.Background(), "order-service:8080", func(address string) error {
+		if address != "order-service:8080" {
+			t.Errorf("unknown service address = %s", address)
+		}
+		return nil
+	})
+}
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	r := NewFileResolver(path)
+	write(`{"payment-service": [{"address": "127.0.0.1:8081", "weight": 2}]}`, time.Unix(1000, 0))
+	endpoints, err := r.Resolve(context.Background(), "payment-service")
+	if err != nil || !reflect.DeepEqual(endpoints, []Endpoint{{Address: "127.0.0.1:8081", Weight: 2}}) {
+		t.Fatalf("Resolve() = %v, %v", endpoints, err)
+	}
+	if _, err := r.Resolve(context.Background(), "order-service"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	write(`{"payment-service": [{"address": "127.0.0.1:8082"}]}`, time.Unix(2000, 0))
+	endpoints, _ = r.Resolve(context.Background(), "payment-service")
+	if len(endpoints) != 1 || endpoints[0].Address != "127.0.0.1:8082" {
+		t.Errorf("Resolve() after reload = %v", endpoints)
+	}
+}
+
+func TestDNSResolver(t *testing.T) {
+	r := NewDNSResolver(&config.DNSDiscoveryConfig{})
+	var queried string
+	r.lookup = func(ctx context.Context, name string) ([]*net.SRV, error) {
+		queried = name
+		if name != "payment-service.service.consul" {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return []*net.SRV{
+			{Target: "node1.", Port: 8080, Priority: 1, Weight: 10},
+			{Target: "node2.", Port: 8080, Priority: 1, Weight: 5},
+			{Target: "backup.", Port: 8080, Priority: 2, Weight: 1},
+		}, nil
+	}
+
+	endpoints, err := r.Resolve(context.Background(), "payment-service")
+	want := []Endpoint{{Address: "node1:8080", Weight: 10}, {Address: "node2:8080", Weight: 5}}
+	if err != nil || !reflect.DeepEqual(endpoints, want) {
+		t.Errorf("Resolve() = %v, %v, want %v", endpoints, err, want)
+	}
+	if queried != "payment-service.service.consul" {
+		t.Errorf("queried %s", queried)
+	}
+	if _, err := r.Resolve(context.Background(), "order-service"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(unknown) error = %v, want ErrNotFound", err)
+	}
+}
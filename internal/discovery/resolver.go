@@ -0,0 +1,175 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+)
+
+// ErrNotFound 服务不由该 resolver 管理
+var ErrNotFound = errors.New("service not found")
+
+type Endpoint struct {
+	Address string `json:"address"`
+	Weight  int    `json:"weight"`
+}
+
+// Resolver 将服务名解析为节点列表，未知服务返回 ErrNotFound
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]Endpoint, error)
+}
+
+// StaticResolver 使用 app.toml 中配置的节点
+type StaticResolver struct {
+	services map[string][]Endpoint
+}
+
+func NewStaticResolver(services map[string][]config.EndpointConfig) *StaticResolver {
+	r := &StaticResolver{services: make(map[string][]Endpoint, len(services))}
+	for name, endpoints := range services {
+		for _, ep := range endpoints {
+			r.services[name] = append(r.services[name], Endpoint{Address: ep.Address, Weight: ep.Weight})
+		}
+	}
+	return r
+}
+
+func (r *StaticResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	endpoints, ok := r.services[service]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return endpoints, nil
+}
+
+// FileResolver 从 JSON 文件读取节点，格式为 {"service": [{"address": "...", "weight": 1}]}，
+// 文件修改时间变化后重新加载，用于本地开发
+type FileResolver struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	services map[string][]Endpoint
+}
+
+func NewFileResolver(path string) *FileResolver {
+	return &FileResolver{path: path}
+}
+
+func (r *FileResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	endpoints, ok := r.services[service]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return endpoints, nil
+}
+
+func (r *FileResolver) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("stat registry file failed: %w", err)
+	}
+	if r.services != nil && info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("read registry file failed: %w", err)
+	}
+	var services map[string][]Endpoint
+	if err := json.Unmarshal(data, &services); err != nil {
+		return fmt.Errorf("parse registry file failed: %w", err)
+	}
+	r.services = services
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// DNSResolver 查询 SRV 记录，只使用优先级最高（数值最小）的一组记录
+type DNSResolver struct {
+	nameFormat string
+	ttl        time.Duration
+	lookup     func(ctx context.Context, name string) ([]*net.SRV, error)
+
+	mu    sync.Mutex
+	cache map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	endpoints []Endpoint
+	expireAt  time.Time
+}
+
+func NewDNSResolver(cfg *config.DNSDiscoveryConfig) *DNSResolver {
+	nameFormat := cfg.NameFormat
+	if nameFormat == "" {
+		nameFormat = "%s.service.consul"
+	}
+	ttl := time.Duration(cfg.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &DNSResolver{
+		nameFormat: nameFormat,
+		ttl:        ttl,
+		lookup: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return records, err
+		},
+		cache: make(map[string]dnsEntry),
+	}
+}
+
+func (r *DNSResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[service]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expireAt) {
+		return entry.endpoints, nil
+	}
+
+	records, err := r.lookup(ctx, fmt.Sprintf(r.nameFormat, service))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, ErrNotFound
+		}
+		// 解析失败时继续使用过期的结果
+		if ok {
+			return entry.endpoints, nil
+		}
+		return nil, err
+	}
+
+	var endpoints []Endpoint
+	for _, srv := range records {
+		if srv.Priority != records[0].Priority {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{
+			Address: net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))),
+			Weight:  int(srv.Weight),
+		})
+	}
+
+	r.mu.Lock()
+	r.cache[service] = dnsEntry{endpoints: endpoints, expireAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return endpoints, nil
+}
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+// Cancel 取消实例：中断执行中的任务，未开始和等待中的任务标记为 cancelled，可选触发补偿
+func (e *Engine) Cancel(instanceID, operator string, compensate bool) error {
+	instance, err := e.instanceRepo.GetByID(instanceID)
+	if err != nil {
+		return apperrors.ErrInstanceNotFound
+	}
+
+	e.mu.Lock()
+	exec, running := e.executions[instanceID]
+	if running {
+		exec.cancelled = true
+		exec.compensate = compensate
+		exec.operator = operator
+		exec.cancel()
+	}
+	e.mu.Unlock()
+
+	if !running {
+		now := time.Now()
+		ok, err := e.instanceRepo.UpdateStatusIf(instanceID, []string{"pending", "running", "waiting", "paused"}, map[string]interface{}{
+			"status":       "cancelled",
+			"completed_at": now,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: instance is %s", apperrors.ErrInvalidStatus, instance.Status)
+		}
+	}
+
+	if _, err := e.taskRepo.CancelPending(instanceID); err != nil {
+		logger.Error().Err(err).Str("instance_id", instanceID).Msg("cancel pending tasks failed")
+	}
+
+	e.logInstance(instanceID, "cancel", operator, fmt.Sprintf("instance cancelled by %s", operator))
+	logger.Info().Str("instance_id", instanceID).Str("operator", operator).Bool("compensate", compensate).Msg("instance cancelled")
+
+	if !running && compensate {
+		go e.compensateInstance(instanceID, operator)
+	}
+
+	return nil
+}
+
+// Pause 暂停实例：执行中的任务继续完成，但不再启动新任务
+func (e *Engine) Pause(instanceID, operator string) error {
+	instance, err := e.instanceRepo.GetByID(instanceID)
+	if err != nil {
+		return apperrors.ErrInstanceNotFound
+	}
+
+	e.mu.Lock()
+	exec, running := e.executions[instanceID]
+	if running && !exec.cancelled {
+		exec.paused = true
+		exec.operator = operator
+	}
+	e.mu.Unlock()
+
+	if !running {
+		ok, err := e.instanceRepo.UpdateStatusIf(instanceID, []string{"pending", "running", "waiting"}, map[string]interface{}{
+			"status": "paused",
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: instance is %s", apperrors.ErrInvalidStatus, instance.Status)
+		}
+	}
+
+	e.logInstance(instanceID, "pause", operator, fmt.Sprintf("instance paused by %s", operator))
+	logger.Info().Str("instance_id", instanceID).Str("operator", operator).Msg("instance paused")
+
+	return nil
+}
+
+// Resume 恢复已暂停的实例
+func (e *Engine) Resume(instanceID, operator string) error {
+	instance, err := e.instanceRepo.GetByID(instanceID)
+	if err != nil {
+		return apperrors.ErrInstanceNotFound
+	}
+
+	e.mu.Lock()
+	exec, running := e.executions[instanceID]
+	if running && exec.paused && !exec.cancelled {
+		// 暂停请求尚未生效，直接撤销
+		exec.paused = false
+		e.mu.Unlock()
+		e.logInstance(instanceID, "resume", operator, fmt.Sprintf("instance resumed by %s", operator))
+		return nil
+	}
+	e.mu.Unlock()
+
+	ok, err := e.instanceRepo.UpdateStatusIf(instanceID, []string{"paused"}, map[string]interface{}{
+		"status": "running",
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: instance is %s", apperrors.ErrInvalidStatus, instance.Status)
+	}
+
+	e.logInstance(instanceID, "resume", operator, fmt.Sprintf("instance resumed by %s", operator))
+	logger.Info().Str("instance_id", instanceID).Str("operator", operator).Msg("instance resumed")
+
+	return e.wake(instanceID)
+}
+
+func (e *Engine) compensateInstance(instanceID, operator string) {
+	instance, flow, params, err := e.loadInstance(instanceID)
+	if err != nil {
+		logger.Error().Err(err).Str("instance_id", instanceID).Msg("load instance for compensation failed")
+		return
+	}
+
+	var flowDefinition FlowDefinition
+	if err := json.Unmarshal([]byte(flow.Definition), &flowDefinition); err != nil {
+		logger.Error().Err(err).Str("instance_id", instanceID).Msg("parse flow definition for compensation failed")
+		return
+	}
+
+	e.compensate(instance, &flowDefinition, params, operator)
+}
+
+func compensateKey(taskKey string) string {
+	return taskKey + "#compensate"
+}
+
+// compensate 按完成时间倒序执行已成功任务的补偿任务
+func (e *Engine) compensate(instance *model.TaskGroupInstance, flowDefinition *FlowDefinition, globalParams map[string]interface{}, operator string) {
+	instanceID := instance.ID
+	records, err := e.loadTaskRecords(instanceID)
+	if err != nil {
+		logger.Error().Err(err).Str("instance_id", instanceID).Msg("load tasks for compensation failed")
+		return
+	}
+
+	tasks := make(map[string]*FlowTask, len(flowDefinition.Tasks))
+	for i := range flowDefinition.Tasks {
+		tasks[flowDefinition.Tasks[i].ID] = &flowDefinition.Tasks[i]
+	}
+
+	var completed []*model.DistTask
+	for key, record := range records {
+		task, ok := tasks[key]
+		if !ok || task.Compensate == nil || record.Status != "success" {
+			continue
+		}
+		if _, done := records[compensateKey(key)]; done {
+			continue
+		}
+		completed = append(completed, record)
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		var ti, tj time.Time
+		if completed[i].CompletedAt != nil {
+			ti = *completed[i].CompletedAt
+		}
+		if completed[j].CompletedAt != nil {
+			tj = *completed[j].CompletedAt
+		}
+		return ti.After(tj)
+	})
+
+	outputs := collectOutputs(records)
+	for _, record := range completed {
+		task := tasks[record.TaskKey]
+		compensation := &FlowTask{
+			ID:          compensateKey(task.ID),
+			TaskName:    task.Compensate.TaskName,
+			Description: fmt.Sprintf("%s（补偿）", task.Description),
+			Config:      task.Compensate.Config,
+			Retry:       &RetryConfig{Strategy: "manual"},
+		}
+
+		e.logInstance(instanceID, "compensate", operator, fmt.Sprintf("compensating task %s with %s", task.ID, compensation.TaskName))
+
+		if err := e.executeTask(context.Background(), instance, compensation, globalParams, outputs, true); err != nil {
+			logger.Error().Err(err).Str("instance_id", instanceID).Str("task_key", task.ID).Msg("compensation failed")
+		}
+	}
+}
+
+func (e *Engine) logInstance(instanceID, action, operator, message string) {
+	details, _ := json.Marshal(map[string]interface{}{"operator": operator})
+	e.logRepo.Create(&model.ExecutionLog{
+		GroupID: instanceID,
+		Action:  action,
+		Message: message,
+		Details: string(details),
+	})
+}
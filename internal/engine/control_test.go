@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dist_task/internal/engine/executor"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/internal/repository/repotest"
+	apperrors "dist_task/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+func newTestEngine(t *testing.T) (*Engine, *gorm.DB) {
+	conn := repotest.Open(t)
+	e := NewEngine(
+		&repository.FlowRepository{},
+		&repository.InstanceRepository{},
+		&repository.TaskRepository{},
+		&repository.ExceptionRepository{},
+		&repository.LogRepository{},
+		executor.NewExecutorFactory(nil, nil, nil, nil, nil, nil, nil, false),
+		nil,
+		nil,
+		&repository.WorkerTaskRepository{},
+		nil,
+	)
+	return e, conn
+}
+
+// createInstance 保存流程和实例，返回实例及解析后的流程记录
+func createInstance(t *testing.T, conn *gorm.DB, id, status string, tasks []FlowTask) (*model.TaskGroupInstance, *model.TaskGroupFlow) {
+	t.Helper()
+
+	definition, _ := json.Marshal(FlowDefinition{Name: "test", Tasks: tasks})
+	flow := &model.TaskGroupFlow{ID: "flow_" + id, TenantID: "default", Name: "test", FlowType: "sequential", Definition: string(definition)}
+	instance := &model.TaskGroupInstance{ID: id, TenantID: "default", FlowID: flow.ID, Status: status, Params: "{}"}
+	if err := conn.Create(flow).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Create(instance).Error; err != nil {
+		t.Fatal(err)
+	}
+	return instance, flow
+}
+
+func getInstance(t *testing.T, conn *gorm.DB, id string) *model.TaskGroupInstance {
+	t.Helper()
+	var instance model.TaskGroupInstance
+	if err := conn.First(&instance, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &instance
+}
+
+func getTask(t *testing.T, conn *gorm.DB, id string) *model.DistTask {
+	t.Helper()
+	var task model.DistTask
+	if err := conn.First(&task, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &task
+}
+
+// waitFor 轮询直到 cond 成立，用于等待引擎的异步推进
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func httpTask(id, url string) FlowTask {
+	config, _ := json.Marshal(map[string]interface{}{"url": url, "method": "POST"})
+	return FlowTask{ID: id, TaskName: "http_request", Config: config}
+}
+
+func TestCancel_Pending(t *testing.T) {
+	e, conn := newTestEngine(t)
+	createInstance(t, conn, "inst", "pending", []FlowTask{{ID: "sleep", TaskName: "delay"}})
+
+	resumeAt := time.Now().Add(time.Hour)
+	conn.Create(&model.DistTask{ID: "inst_sleep", GroupID: "inst", TaskKey: "sleep", Type: "delay", Status: "waiting", ResumeAt: &resumeAt})
+
+	if err := e.Cancel("inst", "alice", false); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	instance := getInstance(t, conn, "inst")
+	if instance.Status != "cancelled" || instance.CompletedAt == nil {
+		t.Errorf("instance = %s, completed_at = %v", instance.Status, instance.CompletedAt)
+	}
+	task := getTask(t, conn, "inst_sleep")
+	if task.Status != "cancelled" || task.ResumeAt != nil {
+		t.Errorf("task = %s, resume_at = %v", task.Status, task.ResumeAt)
+	}
+
+	// 已结束的实例不能再次取消
+	if err := e.Cancel("inst", "alice", false); !errors.Is(err, apperrors.ErrInvalidStatus) {
+		t.Errorf("Cancel() on cancelled instance error = %v", err)
+	}
+}
+
+func TestCancel_Running(t *testing.T) {
+	e, conn := newTestEngine(t)
+
+	entered := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	instance, flow := createInstance(t, conn, "inst", "pending", []FlowTask{httpTask("call", srv.URL)})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Execute(context.Background(), instance, flow, map[string]interface{}{})
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not started")
+	}
+
+	if err := e.Cancel("inst", "alice", false); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("execution not interrupted")
+	}
+
+	if got := getInstance(t, conn, "inst").Status; got != "cancelled" {
+		t.Errorf("instance status = %s, want cancelled", got)
+	}
+	if got := getTask(t, conn, "inst_call").Status; got != "cancelled" {
+		t.Errorf("task status = %s, want cancelled", got)
+	}
+}
+
+func TestCancel_Compensate(t *testing.T) {
+	e, conn := newTestEngine(t)
+
+	compensated := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compensated <- r.URL.Path
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	reserve := httpTask("reserve", srv.URL+"/reserve")
+	reserve.Compensate = &CompensateTask{TaskName: "http_request", Config: json.RawMessage(`{"url":"` + srv.URL + `/release","method":"POST"}`)}
+	createInstance(t, conn, "inst", "waiting", []FlowTask{reserve, {ID: "approve", TaskName: "wait", DependsOn: []string{"reserve"}}})
+
+	now := time.Now()
+	conn.Create(&model.DistTask{ID: "inst_reserve", GroupID: "inst", TaskKey: "reserve", Type: "http", Status: "success", CompletedAt: &now})
+	conn.Create(&model.DistTask{ID: "inst_approve", GroupID: "inst", TaskKey: "approve", Type: "wait", Status: "waiting"})
+
+	if err := e.Cancel("inst", "alice", true); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	select {
+	case path := <-compensated:
+		if path != "/release" {
+			t.Errorf("compensation path = %s, want /release", path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("compensation not triggered")
+	}
+	waitFor(t, "compensation recorded", func() bool {
+		var task model.DistTask
+		return conn.First(&task, "id = ?", "inst_reserve#compensate").Error == nil && task.Status == "success"
+	})
+	if got := getTask(t, conn, "inst_approve").Status; got != "cancelled" {
+		t.Errorf("waiting task status = %s, want cancelled", got)
+	}
+}
+
+func TestPauseResume_WithTimer(t *testing.T) {
+	e, conn := newTestEngine(t)
+	instance, flow := createInstance(t, conn, "inst", "pending", []FlowTask{
+		{ID: "sleep", TaskName: "delay", Config: json.RawMessage(`{"duration":"1h"}`)},
+	})
+
+	if err := e.Execute(context.Background(), instance, flow, map[string]interface{}{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := getInstance(t, conn, "inst").Status; got != "waiting" {
+		t.Fatalf("instance status = %s, want waiting", got)
+	}
+
+	if err := e.Pause("inst", "alice"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	// 暂停期间定时器到期，任务保持等待
+	past := time.Now().Add(-time.Second)
+	conn.Model(&model.DistTask{}).Where("id = ?", "inst_sleep").Update("resume_at", past)
+	if err := e.Wake(getTask(t, conn, "inst_sleep")); !errors.Is(err, apperrors.ErrInstancePaused) {
+		t.Fatalf("Wake() while paused error = %v", err)
+	}
+	if task := getTask(t, conn, "inst_sleep"); task.Status != "waiting" || task.ResumeAt == nil {
+		t.Fatalf("task = %s, resume_at = %v, want waiting timer", task.Status, task.ResumeAt)
+	}
+
+	if err := e.Resume("inst", "alice"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	waitFor(t, "instance back to waiting", func() bool {
+		return getInstance(t, conn, "inst").Status == "waiting"
+	})
+
+	// 恢复后定时器再次触发，实例继续推进到结束
+	if err := e.Wake(getTask(t, conn, "inst_sleep")); err != nil {
+		t.Fatalf("Wake() error = %v", err)
+	}
+	waitFor(t, "instance success", func() bool {
+		return getInstance(t, conn, "inst").Status == "success"
+	})
+
+	if err := e.Resume("inst", "alice"); !errors.Is(err, apperrors.ErrInvalidStatus) {
+		t.Errorf("Resume() on finished instance error = %v", err)
+	}
+}
+
+func TestCancel_LosesRace(t *testing.T) {
+	e, conn := newTestEngine(t)
+	createInstance(t, conn, "inst", "running", nil)
+
+	// 读取实例后、条件更新前，实例被其他节点推进到 success
+	fired := false
+	conn.Callback().Update().Before("gorm:update").Register("test:race", func(tx *gorm.DB) {
+		if fired || tx.Statement.Table != "task_group_instance" {
+			return
+		}
+		fired = true
+		tx.Statement.ConnPool.ExecContext(tx.Statement.Context, "UPDATE task_group_instance SET status = 'success' WHERE id = ?", "inst")
+	})
+
+	err := e.Cancel("inst", "alice", false)
+	if !errors.Is(err, apperrors.ErrInvalidStatus) {
+		t.Fatalf("Cancel() error = %v, want ErrInvalidStatus", err)
+	}
+	if got := getInstance(t, conn, "inst").Status; got != "success" {
+		t.Errorf("instance status = %s, want success", got)
+	}
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+type DelayConfig struct {
+	Duration string `json:"duration,omitempty"` // 延迟时长，如 30s、24h，支持占位符
+	Until    string `json:"until,omitempty"`    // 触发时间，RFC3339 / 2006-01-02 15:04:05 / Unix 秒，支持占位符
+}
+
+func (c *DelayConfig) fireAt(start time.Time) (time.Time, error) {
+	switch {
+	case c.Until != "":
+		return parseFireTime(c.Until)
+	case c.Duration != "":
+		d, err := time.ParseDuration(c.Duration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid delay duration %q: %w", c.Duration, err)
+		}
+		return start.Add(d), nil
+	default:
+		return time.Time{}, fmt.Errorf("delay config requires duration or until")
+	}
+}
+
+func parseFireTime(value string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+
+	formats := []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+	}
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid delay until %q", value)
+}
+
+func scalarString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func (e *Engine) startDelay(taskRecord *model.DistTask, task *FlowTask, globalParams, outputs map[string]interface{}) error {
+	resolved, err := resolveConfig(task.Config, newScope(nil, globalParams, outputs))
+	if err != nil {
+		return fmt.Errorf("parse delay config failed: %w", err)
+	}
+	stored, err := resolveConfig(task.Config, newScope(nil, e.storedParams(globalParams), outputs))
+	if err != nil {
+		return fmt.Errorf("parse delay config failed: %w", err)
+	}
+
+	// 占位符可能解析为数字（如 Unix 秒），统一转为字符串
+	var raw map[string]interface{}
+	json.Unmarshal(resolved, &raw)
+	if raw, err = e.revealParams(raw); err != nil {
+		return err
+	}
+	cfg := DelayConfig{
+		Duration: scalarString(raw["duration"]),
+		Until:    scalarString(raw["until"]),
+	}
+
+	fireAt, err := cfg.fireAt(*taskRecord.StartedAt)
+	if err != nil {
+		return err
+	}
+
+	taskRecord.Config = string(stored)
+	if !fireAt.After(time.Now()) {
+		completedAt := time.Now()
+		output, _ := json.Marshal(map[string]interface{}{"fired_at": completedAt})
+		taskRecord.Status = "success"
+		taskRecord.CompletedAt = &completedAt
+		taskRecord.OutputData = string(output)
+		return e.saveTaskStart(taskRecord)
+	}
+
+	taskRecord.Status = "waiting"
+	taskRecord.ResumeAt = &fireAt
+	if err := e.saveTaskStart(taskRecord); err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "wait",
+		Message: fmt.Sprintf("task %s delayed until %s", task.ID, fireAt.Format(time.RFC3339)),
+	})
+
+	logger.Info().Str("task_id", taskRecord.ID).Time("fire_at", fireAt).Msg("task delayed")
+
+	return nil
+}
+
+// Wake 处理到期的定时任务：wait 超时、delay 到期或 worker 任务超时
+func (e *Engine) Wake(taskRecord *model.DistTask) error {
+	instance, err := e.instanceRepo.GetByID(taskRecord.GroupID)
+	if err != nil {
+		return apperrors.ErrInstanceNotFound
+	}
+
+	switch instance.Status {
+	case "waiting", "running":
+	case "paused":
+		// 暂停期间保留定时器，恢复后再触发
+		return apperrors.ErrInstancePaused
+	default:
+		// 实例已结束（失败或取消）时丢弃定时器
+		return e.dropTimer(taskRecord, instance.Status)
+	}
+
+	switch taskRecord.Type {
+	case "wait":
+		return e.expireWait(taskRecord)
+	case "delay":
+		return e.fireDelay(taskRecord)
+	case "worker":
+		return e.expireWorkerTask(taskRecord)
+	default:
+		return e.releaseThrottled(taskRecord)
+	}
+}
+
+// releaseThrottled 限流等待结束，任务重新进入调度
+func (e *Engine) releaseThrottled(taskRecord *model.DistTask) error {
+	ok, err := e.taskRepo.CompareAndSwapStatus(taskRecord.ID, "waiting", "pending")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrTaskNotWaiting
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "resume",
+		Message: fmt.Sprintf("task %s rate limit wait finished", taskRecord.TaskKey),
+	})
+
+	return e.wake(taskRecord.GroupID)
+}
+
+func (e *Engine) fireDelay(taskRecord *model.DistTask) error {
+	ok, err := e.taskRepo.CompareAndSwapStatus(taskRecord.ID, "waiting", "running")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrTaskNotWaiting
+	}
+
+	now := time.Now()
+	output, _ := json.Marshal(map[string]interface{}{"fired_at": now})
+	taskRecord.Status = "success"
+	taskRecord.ResumeAt = nil
+	taskRecord.CompletedAt = &now
+	taskRecord.OutputData = string(output)
+	if err := e.taskRepo.Update(taskRecord); err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "fire",
+		Message: fmt.Sprintf("task %s delay fired", taskRecord.TaskKey),
+	})
+
+	return e.wake(taskRecord.GroupID)
+}
+
+func (e *Engine) dropTimer(taskRecord *model.DistTask, instanceStatus string) error {
+	ok, err := e.taskRepo.CompareAndSwapStatus(taskRecord.ID, "waiting", "cancelled")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrTaskNotWaiting
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "cancel",
+		Message: fmt.Sprintf("timer dropped, instance is %s", instanceStatus),
+	})
+
+	return nil
+}
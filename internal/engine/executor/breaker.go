@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"dist_task/internal/breaker"
+	"dist_task/pkg/taskdef"
+)
+
+// guardedExecutor 按下游目标经过熔断器和舱壁执行
+type guardedExecutor struct {
+	TaskExecutor
+	taskType string
+	breakers *breaker.Manager
+}
+
+func (e *guardedExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var output map[string]interface{}
+	err := e.breakers.Do(ctx, BreakerKey(e.taskType, cfgBytes), func() error {
+		var err error
+		output, err = e.TaskExecutor.Execute(ctx, cfgBytes, input)
+		return err
+	})
+	return output, err
+}
+
+// BreakerKey 返回任务的下游目标：rpc:<service>、http:<host>、mq:<topic>、db:<table> 或 db:<datasource>，
+// 配置无法解析时返回空，不经过熔断
+func BreakerKey(taskType string, cfgBytes []byte) string {
+	if taskType == "db" {
+		var cfg DBConfig
+		if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+			return ""
+		}
+		// 外部数据源整体作为一个目标
+		if cfg.Datasource != "" {
+			return "db:" + cfg.Datasource
+		}
+		table := cfg.Table
+		if table == "" && len(cfg.Statements) > 0 {
+			table = cfg.Statements[0].Table
+		}
+		if table == "" {
+			return ""
+		}
+		return "db:" + table
+	}
+
+	var cfg taskdef.TaskConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return ""
+	}
+
+	var target string
+	switch taskType {
+	case "rpc":
+		target = cfg.Service
+		if cfg.Protocol == ProtocolGRPC {
+			target = cfg.Target
+		}
+	case "http":
+		if u, err := url.Parse(cfg.URL); err == nil {
+			target = u.Host
+		}
+	case "mq":
+		target = cfg.Topic
+	}
+	if target == "" {
+		return ""
+	}
+	return taskType + ":" + target
+}
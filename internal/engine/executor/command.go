@@ -0,0 +1,236 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+const (
+	defaultCommandTimeout   = 300 * time.Second
+	defaultCommandMaxOutput = 64 * 1024
+	defaultCommandPath      = "/usr/local/bin:/usr/bin:/bin"
+)
+
+// CommandExecutor 只执行白名单中的程序，不经过 shell，参数原样传给程序
+type CommandExecutor struct {
+	allowed     map[string]string // 程序名和绝对路径 -> 绝对路径
+	workDir     string
+	allowedDirs []string
+	env         map[string]string
+	timeout     time.Duration
+	maxOutput   int
+}
+
+func NewCommandExecutor(cfg *config.CommandConfig) *CommandExecutor {
+	e := &CommandExecutor{
+		allowed:   make(map[string]string, len(cfg.Allowed)*2),
+		workDir:   cfg.WorkDir,
+		env:       cfg.Env,
+		timeout:   defaultCommandTimeout,
+		maxOutput: defaultCommandMaxOutput,
+	}
+	for _, path := range cfg.Allowed {
+		path = filepath.Clean(path)
+		e.allowed[path] = path
+		e.allowed[filepath.Base(path)] = path
+	}
+	for _, dir := range append([]string{cfg.WorkDir}, cfg.AllowedDirs...) {
+		if dir != "" {
+			e.allowedDirs = append(e.allowedDirs, filepath.Clean(dir))
+		}
+	}
+	if cfg.Timeout > 0 {
+		e.timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	if cfg.MaxOutput > 0 {
+		e.maxOutput = cfg.MaxOutput
+	}
+	return e
+}
+
+type CommandConfig struct {
+	Command      string                 `json:"command"`                 // 白名单中的绝对路径或程序名
+	Args         []interface{}          `json:"args,omitempty"`          // 参数，可使用占位符
+	Env          map[string]interface{} `json:"env,omitempty"`           // 追加的环境变量，可使用占位符
+	Dir          string                 `json:"dir,omitempty"`           // 工作目录，默认 [command] work_dir
+	Timeout      int                    `json:"timeout,omitempty"`       // 秒，不能超过 [command] timeout
+	SuccessCodes []int                  `json:"success_codes,omitempty"` // 视为成功的退出码，默认 [0]
+	RetryCodes   []int                  `json:"retry_codes,omitempty"`   // 可以自动重试的退出码，其余失败不自动重试
+	ParseJSON    bool                   `json:"parse_json,omitempty"`    // stdout 为 JSON 时解析到输出的 result 字段
+}
+
+func (e *CommandExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var cfg CommandConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse command config failed")
+	}
+
+	path, ok := e.allowed[cfg.Command]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "command %q is not allowed", cfg.Command)
+	}
+	dir, err := e.workingDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := e.timeout
+	if cfg.Timeout > 0 && time.Duration(cfg.Timeout)*time.Second < timeout {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := make([]string, len(cfg.Args))
+	for i, arg := range cfg.Args {
+		args[i] = formatValue(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	cmd.Env = e.environ(ctx, cfg.Env)
+	// 超时后结束整个进程组；仍有进程持有输出管道时最多再等待 5 秒
+	killProcessGroup(cmd)
+	cmd.WaitDelay = 5 * time.Second
+	stdout := &cappedBuffer{limit: e.maxOutput}
+	stderr := &cappedBuffer{limit: e.maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	duration := time.Since(start)
+
+	exitCode := 0
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, apperrors.Classify(ctx.Err(), apperrors.CategoryTimeout, apperrors.CodeTimeout, "command killed after "+timeout.String())
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "start command failed")
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	logger.Info().
+		Str("command", path).
+		Str("dir", dir).
+		Int("exit_code", exitCode).
+		Dur("duration", duration).
+		Msg("Command executor completed")
+
+	if !containsCode(cfg.SuccessCodes, exitCode, 0) {
+		failure := apperrors.Newf(apperrors.CategoryUnknown, fmt.Sprintf("COMMAND_EXIT_%d", exitCode), "command exited with code %d: %s", exitCode, stderr.String())
+		failure.Retryable = containsCode(cfg.RetryCodes, exitCode, -1)
+		return nil, failure
+	}
+
+	output := map[string]interface{}{
+		"exit_code":   exitCode,
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"truncated":   stdout.truncated || stderr.truncated,
+		"duration_ms": duration.Milliseconds(),
+	}
+	if cfg.ParseJSON {
+		var result interface{}
+		if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+			return nil, apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeValidationFailed, err, "parse command stdout as json failed")
+		}
+		output["result"] = result
+	}
+	return output, nil
+}
+
+// workingDir 任务指定的目录必须位于 work_dir 或 allowed_dirs 之下
+func (e *CommandExecutor) workingDir(dir string) (string, error) {
+	if dir == "" {
+		return e.workDir, nil
+	}
+
+	// 相对路径基于 work_dir，Clean 后再比较，防止通过 .. 跳出白名单目录
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(e.workDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	for _, allowed := range e.allowedDirs {
+		if dir == allowed || strings.HasPrefix(dir, allowed+string(filepath.Separator)) {
+			return dir, nil
+		}
+	}
+	return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "working directory %s is not allowed", dir)
+}
+
+// environ 不继承服务进程的环境变量，避免数据库密码等配置泄露给子进程
+func (e *CommandExecutor) environ(ctx context.Context, taskEnv map[string]interface{}) []string {
+	env := map[string]string{"PATH": defaultCommandPath}
+	for k, v := range e.env {
+		env[k] = v
+	}
+	for k, v := range taskEnv {
+		env[k] = formatValue(v)
+	}
+	if key := IdempotencyKey(ctx); key != "" {
+		env["IDEMPOTENCY_KEY"] = key
+	}
+	if taskID := TaskID(ctx); taskID != "" {
+		env["TASK_ID"] = taskID
+	}
+
+	result := make([]string, 0, len(env))
+	for k, v := range env {
+		result = append(result, k+"="+v)
+	}
+	return result
+}
+
+// containsCode codes 为空时只有 fallback 匹配
+func containsCode(codes []int, code, fallback int) bool {
+	if len(codes) == 0 {
+		return code == fallback
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer 只保留前 limit 个字节，超出部分丢弃但不报错，避免子进程因管道写失败退出。
+// 不内嵌 bytes.Buffer，否则 io.Copy 会通过 ReadFrom 绕过 Write 的长度限制
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.buf.Len(); remain < len(p) {
+		b.truncated = true
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
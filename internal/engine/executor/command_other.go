@@ -0,0 +1,8 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// killProcessGroup 非 unix 平台只结束命令本身
+func killProcessGroup(cmd *exec.Cmd) {}
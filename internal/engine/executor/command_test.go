@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+)
+
+func TestCommandExecutor(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	os.Mkdir(workDir+"/jobs", 0o755)
+	os.Setenv("DIST_TASK_SECRET", "leak")
+	defer os.Unsetenv("DIST_TASK_SECRET")
+
+	e := NewCommandExecutor(&config.CommandConfig{
+		Allowed:   []string{"/bin/sh"},
+		WorkDir:   workDir,
+		Env:       map[string]string{"STAGE": "prod"},
+		MaxOutput: 16,
+	})
+
+	tests := []struct {
+		name     string
+		config   string
+		check    func(output map[string]interface{}) bool
+		category apperrors.Category
+		retry    bool
+	}{
+		{
+			name:   "args env and dir",
+			config: `{"command": "sh", "args": ["-c", "echo $STAGE-$ORDER-$DIST_TASK_SECRET; pwd", "x"], "env": {"ORDER": 42}, "dir": "jobs"}`,
+			check: func(output map[string]interface{}) bool {
+				return output["stdout"] == "prod-42-\n"+workDir[:7] && output["truncated"] == true
+			},
+		},
+		{
+			name:   "json stdout",
+			config: `{"command": "/bin/sh", "args": ["-c", "echo '{\"n\":1}'"], "parse_json": true}`,
+			check: func(output map[string]interface{}) bool {
+				result, _ := output["result"].(map[string]interface{})
+				return result["n"] == float64(1)
+			},
+		},
+		{
+			name:   "custom success code",
+			config: `{"command": "sh", "args": ["-c", "exit 3"], "success_codes": [0, 3]}`,
+			check:  func(output map[string]interface{}) bool { return output["exit_code"] == 3 },
+		},
+		{
+			name:     "retryable exit code",
+			config:   `{"command": "sh", "args": ["-c", "echo busy >&2; exit 75"], "retry_codes": [75]}`,
+			category: apperrors.CategoryUnknown,
+			retry:    true,
+		},
+		{
+			name:     "failed exit code",
+			config:   `{"command": "sh", "args": ["-c", "exit 1"]}`,
+			category: apperrors.CategoryUnknown,
+		},
+		{
+			name:     "not allowed",
+			config:   `{"command": "/bin/rm", "args": ["-rf", "/"]}`,
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "dir escape",
+			config:   `{"command": "sh", "args": ["-c", "true"], "dir": "../"}`,
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "timeout",
+			config:   `{"command": "sh", "args": ["-c", "sleep 5"], "timeout": 1}`,
+			category: apperrors.CategoryTimeout,
+			retry:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := e.Execute(context.Background(), []byte(tt.config), nil)
+			if tt.category != 0 {
+				var typed *apperrors.Error
+				if !errors.As(err, &typed) || typed.Category != tt.category || typed.Retryable != tt.retry {
+					t.Fatalf("Execute() error = %v, want category %s retryable %v", err, tt.category, tt.retry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !tt.check(output) {
+				t.Errorf("Execute() output = %v", output)
+			}
+		})
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 5}
+	b.Write([]byte("abc"))
+	if n, err := b.Write([]byte("defgh")); n != 5 || err != nil {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if b.String() != "abcde" || !b.truncated || strings.Contains(b.String(), "f") {
+		t.Errorf("buffer = %q, truncated = %v", b.String(), b.truncated)
+	}
+}
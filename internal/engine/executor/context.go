@@ -0,0 +1,45 @@
+package executor
+
+import "context"
+
+// IdempotencyHeader HTTP / RPC 请求中携带幂等键的请求头
+const IdempotencyHeader = "Idempotency-Key"
+
+// IdempotencyProperty MQ 消息中携带幂等键的属性名
+const IdempotencyProperty = "idempotency_key"
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey 将本次执行的幂等键放入 context，由执行器透传给下游
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
+
+type taskIDCtx struct{}
+
+// WithTaskID 将任务记录 ID 放入 context，事务消息回查时据此查询任务状态
+func WithTaskID(ctx context.Context, taskID string) context.Context {
+	return context.WithValue(ctx, taskIDCtx{}, taskID)
+}
+
+func TaskID(ctx context.Context) string {
+	taskID, _ := ctx.Value(taskIDCtx{}).(string)
+	return taskID
+}
+
+type outputsCtx struct{}
+
+// WithOutputs 将上游任务的输出放入 context，供 transform 任务在脚本中读取
+func WithOutputs(ctx context.Context, outputs map[string]interface{}) context.Context {
+	return context.WithValue(ctx, outputsCtx{}, outputs)
+}
+
+func Outputs(ctx context.Context) map[string]interface{} {
+	outputs, _ := ctx.Value(outputsCtx{}).(map[string]interface{})
+	return outputs
+}
@@ -0,0 +1,518 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	defaultSelectLimit = 100
+	maxSelectLimit     = 1000
+)
+
+var (
+	identifierPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	placeholderPattern = regexp.MustCompile(`\$\{[^}]+\}`)
+)
+
+// platformTables 平台自身的表，不允许加入平台数据源的白名单
+var platformTables = map[string]bool{
+	"task_group_flow":     true,
+	"task_group_instance": true,
+	"dist_task":           true,
+	"exception_record":    true,
+	"execution_log":       true,
+	"audit_log":           true,
+	"exception_bulk_job":  true,
+	"mq_outbox":           true,
+	"rate_limit_bucket":   true,
+	"worker":              true,
+	"worker_task":         true,
+	"goose_db_version":    true,
+}
+
+// DBExecutor 表名和列名经过校验并加引号，值一律通过参数绑定传入
+type DBExecutor struct {
+	defaultSource *datasource
+	datasources   map[string]*datasource
+}
+
+type datasource struct {
+	name   string
+	db     *gorm.DB
+	tables map[string]bool // 允许访问的表，为空时不能访问任何表
+}
+
+// NewDBExecutor db 为平台数据库；外部数据源在首次使用时才建立连接。
+// 只能访问 tables 中列出的表，平台自身的表不能加入平台数据源的白名单
+func NewDBExecutor(db *gorm.DB, cfg *config.DBTaskConfig) (*DBExecutor, error) {
+	for _, t := range cfg.Tables {
+		name := strings.ToLower(t)
+		if platformTables[name[strings.LastIndex(name, ".")+1:]] {
+			return nil, fmt.Errorf("db_task.tables: %s is a platform table", t)
+		}
+	}
+
+	e := &DBExecutor{
+		defaultSource: &datasource{db: db, tables: tableSet(cfg.Tables)},
+		datasources:   make(map[string]*datasource, len(cfg.Datasources)),
+	}
+
+	for name, dsCfg := range cfg.Datasources {
+		if dsCfg.DSN == "" {
+			return nil, fmt.Errorf("datasource %s: dsn is required", name)
+		}
+		dsDB, err := gorm.Open(mysql.Open(dsCfg.DSN), &gorm.Config{
+			Logger:               gormlogger.Default.LogMode(gormlogger.Warn),
+			DisableAutomaticPing: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("open datasource %s failed: %w", name, err)
+		}
+		sqlDB, err := dsDB.DB()
+		if err != nil {
+			return nil, fmt.Errorf("open datasource %s failed: %w", name, err)
+		}
+		if dsCfg.MaxOpenConns > 0 {
+			sqlDB.SetMaxOpenConns(dsCfg.MaxOpenConns)
+		}
+		if dsCfg.MaxIdleConns > 0 {
+			sqlDB.SetMaxIdleConns(dsCfg.MaxIdleConns)
+		}
+		if dsCfg.ConnMaxLifetime > 0 {
+			sqlDB.SetConnMaxLifetime(time.Duration(dsCfg.ConnMaxLifetime) * time.Second)
+		}
+		e.datasources[name] = &datasource{name: name, db: dsDB, tables: tableSet(dsCfg.Tables)}
+	}
+	return e, nil
+}
+
+func tableSet(tables []string) map[string]bool {
+	set := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		set[strings.ToLower(t)] = true
+	}
+	return set
+}
+
+// Close 关闭外部数据源的连接，平台数据库由调用方管理
+func (e *DBExecutor) Close() error {
+	for _, ds := range e.datasources {
+		if sqlDB, err := ds.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	return nil
+}
+
+type DBStatement struct {
+	Operation     string                 `json:"operation"` // insert / update / delete / upsert / select
+	Table         string                 `json:"table"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	Where         map[string]interface{} `json:"where,omitempty"`          // 等值条件，值为数组时为 IN，为 null 时为 IS NULL
+	Columns       []string               `json:"columns,omitempty"`        // select 返回的列，默认全部
+	OrderBy       string                 `json:"order_by,omitempty"`       // select 排序，如 "created_at desc, id"
+	Limit         int                    `json:"limit,omitempty"`          // select 最多返回的行数，默认 100，最大 1000
+	UpdateColumns []string               `json:"update_columns,omitempty"` // upsert 主键或唯一键冲突时更新的列，默认 data 中的全部列
+	Version       *DBVersion             `json:"version,omitempty"`        // update / delete 的乐观锁条件
+}
+
+// DBVersion 乐观锁：条件中加入 column = value，update 时同时将该列加 1；未命中任何行时任务失败
+type DBVersion struct {
+	Column string      `json:"column"`
+	Value  interface{} `json:"value"`
+}
+
+// DBConfig 配置单条语句，或通过 statements 在同一个事务中执行多条语句
+type DBConfig struct {
+	Datasource string `json:"datasource,omitempty"`
+	DBStatement
+	Statements []DBStatement `json:"statements,omitempty"`
+}
+
+func (e *DBExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var cfg DBConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse db config failed")
+	}
+
+	ds := e.defaultSource
+	if cfg.Datasource != "" {
+		var ok bool
+		if ds, ok = e.datasources[cfg.Datasource]; !ok {
+			return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unknown datasource: %s", cfg.Datasource)
+		}
+	}
+
+	statements := cfg.Statements
+	if len(statements) == 0 {
+		statements = []DBStatement{cfg.DBStatement}
+	} else if cfg.Operation != "" {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "db operation and statements are mutually exclusive")
+	}
+
+	built := make([]*builtStatement, 0, len(statements))
+	for i := range statements {
+		b, err := buildStatement(&statements[i], ds.tables)
+		if err != nil {
+			return nil, err
+		}
+		built = append(built, b)
+	}
+
+	if len(built) == 1 {
+		return e.run(ctx, ds, built[0])
+	}
+
+	results := make([]interface{}, 0, len(built))
+	var affected int64
+	err := ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, b := range built {
+			output, err := runStatement(tx, b)
+			if err != nil {
+				return err
+			}
+			if n, ok := output["affected"].(int64); ok {
+				affected += n
+			}
+			results = append(results, output)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("datasource", ds.name).
+		Int("statements", len(built)).
+		Int64("affected", affected).
+		Msg("DB transaction completed")
+
+	return map[string]interface{}{"results": results, "affected": affected}, nil
+}
+
+func (e *DBExecutor) run(ctx context.Context, ds *datasource, b *builtStatement) (map[string]interface{}, error) {
+	output, err := runStatement(ds.db.WithContext(ctx), b)
+	if err != nil {
+		return nil, err
+	}
+
+	event := logger.Info().Str("datasource", ds.name).Str("table", b.table)
+	if n, ok := output["affected"].(int64); ok {
+		event = event.Int64("affected", n)
+	} else {
+		event = event.Interface("count", output["count"])
+	}
+	event.Msgf("DB %s completed", b.operation)
+	return output, nil
+}
+
+type builtStatement struct {
+	operation string
+	table     string
+	query     string
+	args      []interface{}
+	version   bool // 带乐观锁条件，未命中任何行时失败
+}
+
+func runStatement(tx *gorm.DB, b *builtStatement) (map[string]interface{}, error) {
+	if b.operation == "select" {
+		var rows []map[string]interface{}
+		if err := tx.Raw(b.query, b.args...).Scan(&rows).Error; err != nil {
+			return nil, apperrors.Classify(err, apperrors.CategoryUnknown, apperrors.CodeDBError, "select failed")
+		}
+		for _, row := range rows {
+			for k, v := range row {
+				if data, ok := v.([]byte); ok {
+					row[k] = string(data)
+				}
+			}
+		}
+		if rows == nil {
+			rows = []map[string]interface{}{}
+		}
+		return map[string]interface{}{"rows": rows, "count": len(rows)}, nil
+	}
+
+	result := tx.Exec(b.query, b.args...)
+	if result.Error != nil {
+		return nil, apperrors.Classify(result.Error, apperrors.CategoryUnknown, apperrors.CodeDBError, b.operation+" failed")
+	}
+	if b.version && result.RowsAffected == 0 {
+		return nil, apperrors.Newf(apperrors.CategoryConstraint, apperrors.CodeVersionConflict, "%s on %s matched no rows, version changed", b.operation, b.table)
+	}
+	return map[string]interface{}{"affected": result.RowsAffected}, nil
+}
+
+// buildStatement 校验语句并生成 SQL，不访问数据库
+func buildStatement(stmt *DBStatement, allowed map[string]bool) (*builtStatement, error) {
+	operation := strings.ToLower(stmt.Operation)
+	if operation == "" || stmt.Table == "" {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "db config incomplete: operation=%s, table=%s", stmt.Operation, stmt.Table)
+	}
+	table, err := quoteTable(stmt.Table, allowed)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlaceholders(stmt.Data); err != nil {
+		return nil, err
+	}
+	if err := checkPlaceholders(stmt.Where); err != nil {
+		return nil, err
+	}
+
+	b := &builtStatement{operation: operation, table: stmt.Table}
+	switch operation {
+	case "insert", "upsert":
+		if len(stmt.Data) == 0 {
+			return nil, apperrors.Newf(apperrors.CategoryValidation, apperrors.CodeValidationFailed, "%s data is required", operation)
+		}
+		columns, values, err := columnValues(stmt.Data)
+		if err != nil {
+			return nil, err
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+		b.query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ","), placeholders)
+		b.args = values
+
+		if operation == "upsert" {
+			updates := columns
+			if len(stmt.UpdateColumns) > 0 {
+				if updates, err = quoteColumns(stmt.UpdateColumns); err != nil {
+					return nil, err
+				}
+			}
+			assignments := make([]string, len(updates))
+			for i, c := range updates {
+				assignments[i] = fmt.Sprintf("%s = VALUES(%s)", c, c)
+			}
+			b.query += " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ",")
+		}
+
+	case "update":
+		if len(stmt.Data) == 0 {
+			return nil, apperrors.New(apperrors.CategoryValidation, apperrors.CodeValidationFailed, "update data is required")
+		}
+		columns, values, err := columnValues(stmt.Data)
+		if err != nil {
+			return nil, err
+		}
+		assignments := make([]string, len(columns))
+		for i, c := range columns {
+			assignments[i] = c + " = ?"
+		}
+		if stmt.Version != nil {
+			column, err := quoteIdentifier(stmt.Version.Column)
+			if err != nil {
+				return nil, err
+			}
+			assignments = append(assignments, fmt.Sprintf("%s = %s + 1", column, column))
+		}
+
+		where, whereArgs, err := buildWhere(stmt.Where, stmt.Version)
+		if err != nil {
+			return nil, err
+		}
+		b.query = fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ","), where)
+		b.args = append(values, whereArgs...)
+		b.version = stmt.Version != nil
+
+	case "delete":
+		where, whereArgs, err := buildWhere(stmt.Where, stmt.Version)
+		if err != nil {
+			return nil, err
+		}
+		b.query = fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)
+		b.args = whereArgs
+		b.version = stmt.Version != nil
+
+	case "select":
+		columns := "*"
+		if len(stmt.Columns) > 0 {
+			quoted, err := quoteColumns(stmt.Columns)
+			if err != nil {
+				return nil, err
+			}
+			columns = strings.Join(quoted, ",")
+		}
+		b.query = fmt.Sprintf("SELECT %s FROM %s", columns, table)
+		if len(stmt.Where) > 0 {
+			where, whereArgs, err := buildWhere(stmt.Where, nil)
+			if err != nil {
+				return nil, err
+			}
+			b.query += " WHERE " + where
+			b.args = whereArgs
+		}
+		if stmt.OrderBy != "" {
+			orderBy, err := buildOrderBy(stmt.OrderBy)
+			if err != nil {
+				return nil, err
+			}
+			b.query += " ORDER BY " + orderBy
+		}
+		limit := stmt.Limit
+		if limit <= 0 {
+			limit = defaultSelectLimit
+		}
+		if limit > maxSelectLimit {
+			limit = maxSelectLimit
+		}
+		b.query += fmt.Sprintf(" LIMIT %d", limit)
+
+	default:
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unsupported db operation: %s", stmt.Operation)
+	}
+	return b, nil
+}
+
+// buildWhere update / delete 必须带条件，避免误改全表
+func buildWhere(where map[string]interface{}, version *DBVersion) (string, []interface{}, error) {
+	if len(where) == 0 {
+		return "", nil, apperrors.New(apperrors.CategoryValidation, apperrors.CodeValidationFailed, "where condition is required")
+	}
+
+	keys := sortedKeys(where)
+	clauses := make([]string, 0, len(keys)+1)
+	args := make([]interface{}, 0, len(keys)+1)
+	for _, k := range keys {
+		column, err := quoteIdentifier(k)
+		if err != nil {
+			return "", nil, err
+		}
+		switch v := where[k].(type) {
+		case nil:
+			clauses = append(clauses, column+" IS NULL")
+		case []interface{}:
+			if len(v) == 0 {
+				return "", nil, apperrors.Newf(apperrors.CategoryValidation, apperrors.CodeValidationFailed, "where %s has an empty value list", k)
+			}
+			clauses = append(clauses, column+" IN ?")
+			args = append(args, v)
+		default:
+			clauses = append(clauses, column+" = ?")
+			args = append(args, v)
+		}
+	}
+
+	if version != nil {
+		column, err := quoteIdentifier(version.Column)
+		if err != nil {
+			return "", nil, err
+		}
+		clauses = append(clauses, column+" = ?")
+		args = append(args, version.Value)
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+func buildOrderBy(orderBy string) (string, error) {
+	parts := strings.Split(orderBy, ",")
+	terms := make([]string, 0, len(parts))
+	for _, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || len(fields) > 2 {
+			return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid order_by: %s", orderBy)
+		}
+		column, err := quoteIdentifier(fields[0])
+		if err != nil {
+			return "", err
+		}
+		if len(fields) == 2 {
+			direction := strings.ToUpper(fields[1])
+			if direction != "ASC" && direction != "DESC" {
+				return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid order_by direction: %s", fields[1])
+			}
+			column += " " + direction
+		}
+		terms = append(terms, column)
+	}
+	return strings.Join(terms, ","), nil
+}
+
+// columnValues 按列名排序，生成的 SQL 保持稳定
+func columnValues(data map[string]interface{}) ([]string, []interface{}, error) {
+	keys := sortedKeys(data)
+	columns := make([]string, len(keys))
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		column, err := quoteIdentifier(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns[i], values[i] = column, data[k]
+	}
+	return columns, values, nil
+}
+
+func quoteColumns(names []string) ([]string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		column, err := quoteIdentifier(name)
+		if err != nil {
+			return nil, err
+		}
+		quoted[i] = column
+	}
+	return quoted, nil
+}
+
+// quoteTable 支持 schema.table，表名必须在白名单中
+func quoteTable(name string, allowed map[string]bool) (string, error) {
+	if !allowed[strings.ToLower(name)] {
+		return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "table %s is not allowed", name)
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid table name: %s", name)
+	}
+	for i, part := range parts {
+		quoted, err := quoteIdentifier(part)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = quoted
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func quoteIdentifier(name string) (string, error) {
+	if !identifierPattern.MatchString(name) {
+		return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid identifier: %q", name)
+	}
+	return "`" + name + "`", nil
+}
+
+// checkPlaceholders 占位符未能解析时直接失败，避免把 ${input.xxx} 原样写入数据库
+func checkPlaceholders(values map[string]interface{}) error {
+	for k, v := range values {
+		if s, ok := v.(string); ok && placeholderPattern.MatchString(s) {
+			return apperrors.Newf(apperrors.CategoryValidation, apperrors.CodeValidationFailed, "unresolved placeholder in %s: %s", k, s)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBuildStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		stmt     DBStatement
+		allowed  map[string]bool
+		query    string
+		args     []interface{}
+		category apperrors.Category
+	}{
+		{
+			name:  "insert",
+			stmt:  DBStatement{Operation: "insert", Table: "orders", Data: map[string]interface{}{"user_id": "u1", "amount": 100.0}},
+			query: "INSERT INTO `orders` (`amount`,`user_id`) VALUES (?,?)",
+			args:  []interface{}{100.0, "u1"},
+		},
+		{
+			name:  "upsert with update columns",
+			stmt:  DBStatement{Operation: "upsert", Table: "shop.stock", Data: map[string]interface{}{"sku": "A1", "qty": 5.0}, UpdateColumns: []string{"qty"}},
+			query: "INSERT INTO `shop`.`stock` (`qty`,`sku`) VALUES (?,?) ON DUPLICATE KEY UPDATE `qty` = VALUES(`qty`)",
+			args:  []interface{}{5.0, "A1"},
+		},
+		{
+			name: "update with version",
+			stmt: DBStatement{Operation: "update", Table: "orders", Data: map[string]interface{}{"status": "paid"},
+				Where: map[string]interface{}{"id": 7.0}, Version: &DBVersion{Column: "version", Value: 3.0}},
+			query: "UPDATE `orders` SET `status` = ?,`version` = `version` + 1 WHERE `id` = ? AND `version` = ?",
+			args:  []interface{}{"paid", 7.0, 3.0},
+		},
+		{
+			name: "select",
+			stmt: DBStatement{Operation: "select", Table: "orders", Columns: []string{"id", "status"},
+				Where: map[string]interface{}{"status": []interface{}{"paid", "shipped"}, "deleted_at": nil}, OrderBy: "created_at desc, id", Limit: 5000},
+			query: "SELECT `id`,`status` FROM `orders` WHERE `deleted_at` IS NULL AND `status` IN ? ORDER BY `created_at` DESC,`id` LIMIT 1000",
+			args:  []interface{}{[]interface{}{"paid", "shipped"}},
+		},
+		{
+			name:     "update without where",
+			stmt:     DBStatement{Operation: "update", Table: "orders", Data: map[string]interface{}{"status": "paid"}},
+			category: apperrors.CategoryValidation,
+		},
+		{
+			name:     "injected column",
+			stmt:     DBStatement{Operation: "insert", Table: "orders", Data: map[string]interface{}{"id) VALUES (1); --": 1}},
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "injected order by",
+			stmt:     DBStatement{Operation: "select", Table: "orders", OrderBy: "id; drop table orders"},
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "table not allowed",
+			stmt:     DBStatement{Operation: "delete", Table: "dist_task", Where: map[string]interface{}{"id": 1}},
+			allowed:  map[string]bool{"orders": true},
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "empty allowlist",
+			stmt:     DBStatement{Operation: "select", Table: "orders"},
+			allowed:  map[string]bool{},
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "unresolved placeholder",
+			stmt:     DBStatement{Operation: "insert", Table: "orders", Data: map[string]interface{}{"user_id": "${input.user_id}"}},
+			category: apperrors.CategoryValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := tt.allowed
+			if allowed == nil {
+				allowed = map[string]bool{"orders": true, "shop.stock": true}
+			}
+			b, err := buildStatement(&tt.stmt, allowed)
+			if tt.category != 0 {
+				var typed *apperrors.Error
+				if !errors.As(err, &typed) || typed.Category != tt.category {
+					t.Fatalf("buildStatement() error = %v, want category %s", err, tt.category)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildStatement() error = %v", err)
+			}
+			if b.query != tt.query || !reflect.DeepEqual(b.args, tt.args) {
+				t.Errorf("buildStatement() = %s %v, want %s %v", b.query, b.args, tt.query, tt.args)
+			}
+		})
+	}
+}
+
+func TestNewDBExecutor_Tables(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shipped, err := config.Load("../../../configs/app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cfg        config.DBTaskConfig
+		wantErr    bool
+		deniedStmt string // 应被拒绝的语句
+	}{
+		{name: "shipped config", cfg: shipped.DBTask, deniedStmt: `{"operation":"delete","table":"dist_task","where":{"id":"t1"}}`},
+		{name: "empty allowlist", cfg: config.DBTaskConfig{}, deniedStmt: `{"operation":"select","table":"orders"}`},
+		{name: "allowlisted", cfg: config.DBTaskConfig{Tables: []string{"orders"}}, deniedStmt: `{"operation":"select","table":"audit_log"}`},
+		{name: "platform table", cfg: config.DBTaskConfig{Tables: []string{"orders", "DIST_TASK"}}, wantErr: true},
+		{name: "schema qualified platform table", cfg: config.DBTaskConfig{Tables: []string{"dist_task.worker_task"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewDBExecutor(conn, &tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDBExecutor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer e.Close()
+
+			_, err = e.Execute(context.Background(), []byte(tt.deniedStmt), nil)
+			var typed *apperrors.Error
+			if !errors.As(err, &typed) || typed.Category != apperrors.CategoryConfig {
+				t.Errorf("Execute(%s) error = %v, want table not allowed", tt.deniedStmt, err)
+			}
+		})
+	}
+}
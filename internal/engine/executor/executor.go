@@ -7,43 +7,64 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
-	"dist_task/internal/config"
+	"dist_task/internal/breaker"
+	"dist_task/internal/discovery"
+	"dist_task/internal/messaging"
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
 	"dist_task/pkg/logger"
 	"dist_task/pkg/taskdef"
-
-	"github.com/apache/rocketmq-client-go/v2"
-	"github.com/apache/rocketmq-client-go/v2/primitive"
-	"github.com/apache/rocketmq-client-go/v2/producer"
-	"gorm.io/gorm"
 )
 
 type TaskExecutor interface {
-	Execute(ctx context.Context, config []byte, input map[string]interface{}) error
+	Execute(ctx context.Context, config []byte, input map[string]interface{}) (map[string]interface{}, error)
 }
 
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// RPCExecutor 按 protocol 发起 RPC 调用：http 为 JSON over HTTP，grpc 交给 GRPCExecutor
 type RPCExecutor struct {
-	client *http.Client
+	client    *http.Client
+	grpc      *GRPCExecutor
+	discovery *discovery.Discovery
 }
 
-func NewRPCExecutor() *RPCExecutor {
+func NewRPCExecutor(grpc *GRPCExecutor, d *discovery.Discovery) *RPCExecutor {
 	return &RPCExecutor{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		grpc:      grpc,
+		discovery: d,
 	}
 }
 
-func (e *RPCExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) error {
+func (e *RPCExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
 	var cfg taskdef.TaskConfig
 	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
-		return fmt.Errorf("parse rpc config failed: %w", err)
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse rpc config failed")
+	}
+
+	switch cfg.Protocol {
+	case "", ProtocolHTTP:
+	case ProtocolGRPC:
+		if e.grpc == nil {
+			return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "grpc executor is not configured")
+		}
+		return e.grpc.Execute(ctx, cfgBytes, input)
+	default:
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unsupported rpc protocol: %s", cfg.Protocol)
 	}
 
 	if cfg.Service == "" || cfg.Method == "" {
-		return fmt.Errorf("rpc config incomplete: service=%s, method=%s", cfg.Service, cfg.Method)
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "rpc config incomplete: service=%s, method=%s", cfg.Service, cfg.Method)
 	}
 
 	payload := map[string]interface{}{
@@ -52,311 +73,282 @@ func (e *RPCExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[st
 	}
 	bodyBytes, _ := json.Marshal(payload)
 
-	url := fmt.Sprintf("http://%s/rpc", cfg.Service)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+	var endpoint string
+	var statusCode int
+	var respBody []byte
+	err := e.discovery.Do(ctx, cfg.Service, func(address string) error {
+		req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("http://%s/rpc", address), bytes.NewReader(bodyBytes))
+		if err != nil {
+			return apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "create rpc request failed")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if key := IdempotencyKey(ctx); key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+
+		resp, err := e.client.Do(req)
+		if err != nil {
+			return apperrors.Classify(err, apperrors.CategoryConnection, apperrors.CodeConnectionFailed, "rpc call failed")
+		}
+		defer resp.Body.Close()
+
+		endpoint, statusCode = address, resp.StatusCode
+		respBody, _ = io.ReadAll(resp.Body)
+		if resp.StatusCode >= 400 {
+			return apperrors.HTTPStatus(resp.StatusCode, fmt.Sprintf("rpc call failed with status %d: %s", resp.StatusCode, string(respBody)))
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("create rpc request failed: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("rpc call failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("rpc call failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	logger.Info().
 		Str("service", cfg.Service).
+		Str("endpoint", endpoint).
 		Str("method", cfg.Method).
-		Interface("input", input).
-		Int("status", resp.StatusCode).
+		Strs("input_fields", inputFields(input)).
+		Int("status", statusCode).
 		Msg("RPC executor completed")
 
-	return nil
+	return decodeOutput(respBody), nil
 }
 
+// MQExecutor 按任务配置的 broker 发送消息，未指定时使用默认 broker
 type MQExecutor struct {
-	producer rocketmq.Producer
+	brokers *messaging.Registry
 }
 
-func NewMQExecutor() (*MQExecutor, error) {
-	cfg := config.GlobalConfig
-	if cfg == nil {
-		return nil, fmt.Errorf("config not initialized")
-	}
+func NewMQExecutor(brokers *messaging.Registry) *MQExecutor {
+	return &MQExecutor{brokers: brokers}
+}
 
-	p, err := rocketmq.NewProducer(
-		producer.WithNsResolver(primitive.NewPassthroughResolver([]string{cfg.RocketMQ.NameServer})),
-		producer.WithRetry(2),
-	)
+func (e *MQExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	cfg, err := parseMQConfig(cfgBytes)
 	if err != nil {
-		return nil, fmt.Errorf("create mq producer failed: %w", err)
-	}
-
-	if err := p.Start(); err != nil {
-		return nil, fmt.Errorf("start mq producer failed: %w", err)
+		return nil, err
 	}
 
-	return &MQExecutor{producer: p}, nil
-}
-
-func (e *MQExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) error {
-	var cfg taskdef.TaskConfig
-	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
-		return fmt.Errorf("parse mq config failed: %w", err)
+	message, err := buildMessage(ctx, cfg, input)
+	if err != nil {
+		return nil, err
 	}
 
-	if cfg.Topic == "" {
-		return fmt.Errorf("mq topic is required")
+	var msgID string
+	if cfg.Transactional {
+		msgID, err = e.sendInTransaction(ctx, message)
+	} else {
+		msgID, err = e.Send(ctx, message)
 	}
-
-	messageBody, _ := json.Marshal(input)
-	msg := primitive.NewMessage(cfg.Topic, messageBody)
-
-	result, err := e.producer.SendSync(ctx, msg)
 	if err != nil {
-		return fmt.Errorf("send mq message failed: %w", err)
+		return nil, err
 	}
 
 	logger.Info().
-		Str("topic", cfg.Topic).
-		RawJSON("message", messageBody).
-		Str("msg_id", result.MsgID).
+		Str("broker", message.Broker).
+		Str("topic", message.Topic).
+		Str("tags", message.Tags).
+		Bool("transactional", cfg.Transactional).
+		Strs("input_fields", inputFields(input)).
+		Str("msg_id", msgID).
 		Msg("MQ executor completed")
 
-	return nil
-}
-
-type HTTPExecutor struct {
-	client *http.Client
+	return map[string]interface{}{"msg_id": msgID}, nil
 }
 
-func NewHTTPExecutor() *HTTPExecutor {
-	return &HTTPExecutor{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
-}
-
-func (e *HTTPExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) error {
-	var cfg taskdef.TaskConfig
-	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
-		return fmt.Errorf("parse http config failed: %w", err)
-	}
-
-	if cfg.URL == "" {
-		return fmt.Errorf("http url is required")
-	}
-
-	method := "POST"
-	if cfg.Method != "" {
-		method = strings.ToUpper(cfg.Method)
+// Send 投递一条消息，outbox relay 也通过它发送
+func (e *MQExecutor) Send(ctx context.Context, message *model.OutboxMessage) (string, error) {
+	broker, err := e.brokers.Get(message.Broker)
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "")
 	}
 
-	var body io.Reader
-	if bodyStr, ok := input["body"].(string); ok && bodyStr != "" {
-		body = bytes.NewBufferString(bodyStr)
+	msg, err := toMessage(message)
+	if err != nil {
+		return "", err
 	}
+	return broker.Send(ctx, msg)
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, cfg.URL, body)
+// sendInTransaction 发送事务半消息，由 broker 回查 dist_task 决定提交或回滚
+func (e *MQExecutor) sendInTransaction(ctx context.Context, message *model.OutboxMessage) (string, error) {
+	broker, err := e.brokers.Get(message.Broker)
 	if err != nil {
-		return fmt.Errorf("create http request failed: %w", err)
+		return "", apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "")
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	for k, v := range cfg.Headers {
-		req.Header.Set(k, v)
+	txBroker, ok := broker.(messaging.TransactionalBroker)
+	if !ok {
+		return "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "broker %s does not support transactional messages", message.Broker)
 	}
 
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("http request failed: %w", err)
+	taskID := TaskID(ctx)
+	if taskID == "" {
+		return "", apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "transactional message requires task id")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("http request failed with status %d: %s", resp.StatusCode, string(respBody))
+	msg, err := toMessage(message)
+	if err != nil {
+		return "", err
 	}
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	logger.Info().
-		Str("url", cfg.URL).
-		Str("method", method).
-		Int("status", resp.StatusCode).
-		Int("body_size", len(respBody)).
-		Msg("HTTP executor completed")
-
-	return nil
-}
-
-type DBExecutor struct {
-	db *gorm.DB
-}
-
-func NewDBExecutor(db *gorm.DB) *DBExecutor {
-	return &DBExecutor{db: db}
-}
-
-type DBConfig struct {
-	Operation string                 `json:"operation"`
-	Table     string                 `json:"table"`
-	Data      map[string]interface{} `json:"data"`
-	Where     map[string]interface{} `json:"where"`
+	return txBroker.SendInTransaction(ctx, msg, taskID)
 }
 
-func (e *DBExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) error {
-	var cfg DBConfig
+func parseMQConfig(cfgBytes []byte) (*taskdef.TaskConfig, error) {
+	var cfg taskdef.TaskConfig
 	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
-		return fmt.Errorf("parse db config failed: %w", err)
-	}
-
-	if cfg.Operation == "" || cfg.Table == "" {
-		return fmt.Errorf("db config incomplete: operation=%s, table=%s", cfg.Operation, cfg.Table)
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse mq config failed")
 	}
 
-	switch strings.ToLower(cfg.Operation) {
-	case "insert":
-		return e.insert(ctx, cfg.Table, cfg.Data)
-	case "update":
-		return e.update(ctx, cfg.Table, cfg.Data, cfg.Where)
-	case "delete":
-		return e.delete(ctx, cfg.Table, cfg.Where)
-	default:
-		return fmt.Errorf("unsupported db operation: %s", cfg.Operation)
-	}
-}
-
-func (e *DBExecutor) insert(ctx context.Context, table string, data map[string]interface{}) error {
-	if data == nil || len(data) == 0 {
-		return fmt.Errorf("insert data is required")
+	if cfg.Topic == "" {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "mq topic is required")
 	}
-
-	columns := make([]string, 0, len(data))
-	placeholders := make([]string, 0, len(data))
-	values := make([]interface{}, 0, len(data))
-
-	for k, v := range data {
-		columns = append(columns, k)
-		placeholders = append(placeholders, "?")
-		values = append(values, v)
+	if cfg.DelayLevel < 0 || cfg.DelayLevel > 18 {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "mq delay_level must be between 1 and 18, got %d", cfg.DelayLevel)
 	}
-
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ","), strings.Join(placeholders, ","))
-
-	result := e.db.WithContext(ctx).Exec(query, values...)
-	if result.Error != nil {
-		return fmt.Errorf("insert failed: %w", result.Error)
+	if cfg.DelayLevel > 0 && cfg.DeliverAt != "" {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "mq delay_level and deliver_at are mutually exclusive")
 	}
-
-	logger.Info().
-		Str("table", table).
-		Int("affected", int(result.RowsAffected)).
-		Msg("DB insert completed")
-
-	return nil
+	return &cfg, nil
 }
 
-func (e *DBExecutor) update(ctx context.Context, table string, data, where map[string]interface{}) error {
-	if data == nil || len(data) == 0 {
-		return fmt.Errorf("update data is required")
-	}
-
-	setClauses := make([]string, 0, len(data))
-	values := make([]interface{}, 0, len(data)+len(where))
-
-	for k, v := range data {
-		setClauses = append(setClauses, fmt.Sprintf("%s = ?", k))
-		values = append(values, v)
-	}
+// buildMessage 按任务配置生成消息，幂等键作为第一个消息 Key
+func buildMessage(ctx context.Context, cfg *taskdef.TaskConfig, input map[string]interface{}) (*model.OutboxMessage, error) {
+	body, _ := json.Marshal(input)
+	message := &model.OutboxMessage{
+		Broker:      cfg.Broker,
+		Topic:       cfg.Topic,
+		Tags:        cfg.Tags,
+		Body:        string(body),
+		ShardingKey: cfg.ShardingKey,
+		DelayLevel:  cfg.DelayLevel,
+	}
+
+	keys := cfg.Keys
+	properties := make(map[string]string, len(cfg.Properties)+1)
+	for k, v := range cfg.Properties {
+		properties[k] = v
+	}
+	if key := IdempotencyKey(ctx); key != "" {
+		keys = append([]string{key}, keys...)
+		properties[IdempotencyProperty] = key
+	}
+	message.MessageKey = strings.Join(keys, " ")
+	if len(properties) > 0 {
+		data, _ := json.Marshal(properties)
+		message.Properties = string(data)
+	}
+
+	if cfg.DeliverAt != "" {
+		deliverAt, err := time.Parse(time.RFC3339, cfg.DeliverAt)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "mq deliver_at must be RFC3339")
+		}
+		message.DeliverAt = &deliverAt
+	}
+	return message, nil
+}
 
-	whereClauses := make([]string, 0, len(where))
-	for k, v := range where {
-		whereClauses = append(whereClauses, fmt.Sprintf("%s = ?", k))
-		values = append(values, v)
+func toMessage(message *model.OutboxMessage) (*messaging.Message, error) {
+	msg := &messaging.Message{
+		Topic:       message.Topic,
+		Tags:        message.Tags,
+		Body:        []byte(message.Body),
+		ShardingKey: message.ShardingKey,
+		DelayLevel:  message.DelayLevel,
+		DeliverAt:   message.DeliverAt,
 	}
-
-	query := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(setClauses, ","))
-	if len(whereClauses) > 0 {
-		query += fmt.Sprintf(" WHERE %s", strings.Join(whereClauses, " AND "))
+	if message.MessageKey != "" {
+		msg.Keys = strings.Split(message.MessageKey, " ")
 	}
-
-	result := e.db.WithContext(ctx).Exec(query, values...)
-	if result.Error != nil {
-		return fmt.Errorf("update failed: %w", result.Error)
+	if message.Properties != "" {
+		if err := json.Unmarshal([]byte(message.Properties), &msg.Properties); err != nil {
+			return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse message properties failed")
+		}
 	}
-
-	logger.Info().
-		Str("table", table).
-		Int("affected", int(result.RowsAffected)).
-		Msg("DB update completed")
-
-	return nil
+	return msg, nil
 }
 
-func (e *DBExecutor) delete(ctx context.Context, table string, where map[string]interface{}) error {
-	if where == nil || len(where) == 0 {
-		return fmt.Errorf("delete where condition is required")
-	}
-
-	whereClauses := make([]string, 0, len(where))
-	values := make([]interface{}, 0, len(where))
-
-	for k, v := range where {
-		whereClauses = append(whereClauses, fmt.Sprintf("%s = ?", k))
-		values = append(values, v)
+// inputFields 只记录入参字段名，避免敏感值写入日志
+func inputFields(input map[string]interface{}) []string {
+	fields := make([]string, 0, len(input))
+	for k := range input {
+		fields = append(fields, k)
 	}
+	sort.Strings(fields)
+	return fields
+}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s", table, strings.Join(whereClauses, " AND "))
-
-	result := e.db.WithContext(ctx).Exec(query, values...)
-	if result.Error != nil {
-		return fmt.Errorf("delete failed: %w", result.Error)
+func decodeOutput(body []byte) map[string]interface{} {
+	var output map[string]interface{}
+	if err := json.Unmarshal(body, &output); err != nil {
+		return nil
 	}
-
-	logger.Info().
-		Str("table", table).
-		Int("affected", int(result.RowsAffected)).
-		Msg("DB delete completed")
-
-	return nil
+	return output
 }
 
 type ExecutorFactory struct {
-	db         *gorm.DB
-	mqExecutor *MQExecutor
+	dbExecutor   *DBExecutor
+	rpcExecutor  *RPCExecutor
+	mqExecutor   *MQExecutor
+	httpExecutor *HTTPExecutor
+	transform    *TransformExecutor
+	command      *CommandExecutor
+	breakers     *breaker.Manager
+	outbox       bool
 }
 
-func NewExecutorFactory(db *gorm.DB) (*ExecutorFactory, error) {
-	mqExec, err := NewMQExecutor()
-	if err != nil {
-		return nil, err
+// NewExecutorFactory 创建执行器工厂；outbox 为 true 时 MQ 任务只写入 outbox，由 relay 异步投递；
+// command 为 nil 时不允许执行 command 任务；breakers 为 nil 时不启用熔断和舱壁
+func NewExecutorFactory(dbExecutor *DBExecutor, brokers *messaging.Registry, grpc *GRPCExecutor, transform *TransformExecutor, command *CommandExecutor, d *discovery.Discovery, breakers *breaker.Manager, outbox bool) *ExecutorFactory {
+	return &ExecutorFactory{
+		dbExecutor:   dbExecutor,
+		rpcExecutor:  NewRPCExecutor(grpc, d),
+		mqExecutor:   NewMQExecutor(brokers),
+		httpExecutor: NewHTTPExecutor(d),
+		transform:    transform,
+		command:      command,
+		breakers:     breakers,
+		outbox:       outbox,
 	}
+}
 
-	return &ExecutorFactory{
-		db:         db,
-		mqExecutor: mqExec,
-	}, nil
+func (f *ExecutorFactory) MQExecutor() *MQExecutor {
+	return f.mqExecutor
 }
 
 func (f *ExecutorFactory) Create(taskType string) (TaskExecutor, error) {
+	exec, err := f.create(taskType)
+	if err != nil || f.breakers == nil {
+		return exec, err
+	}
+	// 写入 outbox 不访问下游，无需熔断
+	if _, ok := exec.(Stager); ok {
+		return exec, nil
+	}
+	return &guardedExecutor{TaskExecutor: exec, taskType: taskType, breakers: f.breakers}, nil
+}
+
+func (f *ExecutorFactory) create(taskType string) (TaskExecutor, error) {
 	switch taskType {
 	case "rpc":
-		return NewRPCExecutor(), nil
+		return f.rpcExecutor, nil
 	case "mq":
+		if f.outbox {
+			return &MQOutboxExecutor{}, nil
+		}
 		return f.mqExecutor, nil
 	case "http":
-		return NewHTTPExecutor(), nil
+		return f.httpExecutor, nil
 	case "db":
-		return NewDBExecutor(f.db), nil
+		return f.dbExecutor, nil
+	case "transform":
+		return f.transform, nil
+	case "command":
+		if f.command == nil {
+			return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeCommandDisabled, "command tasks are disabled, set [command] enabled = true")
+		}
+		return f.command, nil
 	default:
-		return nil, fmt.Errorf("unsupported task type: %s", taskType)
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeUnsupportedType, "unsupported task type: %s", taskType)
 	}
 }
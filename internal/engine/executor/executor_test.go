@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"dist_task/internal/messaging"
+)
+
+func TestBuildMessage(t *testing.T) {
+	cfg, err := parseMQConfig([]byte(`{
+		"topic": "payment.completed",
+		"tags": "paid",
+		"keys": ["o_001"],
+		"properties": {"source": "dist_task"},
+		"sharding_key": "u_001",
+		"deliver_at": "2024-01-31T10:00:00Z"
+	}`))
+	if err != nil {
+		t.Fatalf("parseMQConfig() error = %v", err)
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "inst_1:notify")
+	message, err := buildMessage(ctx, cfg, map[string]interface{}{"order_id": "o_001"})
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+
+	msg, err := toMessage(message)
+	if err != nil {
+		t.Fatalf("toMessage() error = %v", err)
+	}
+
+	if msg.Tags != "paid" || msg.ShardingKey != "u_001" || msg.DeliverAt == nil || msg.DeliverAt.UnixMilli() != 1706695200000 {
+		t.Errorf("toMessage() = %+v", msg)
+	}
+	if !reflect.DeepEqual(msg.Keys, []string{"inst_1:notify", "o_001"}) {
+		t.Errorf("Keys = %v, want idempotency key first", msg.Keys)
+	}
+	expected := map[string]string{IdempotencyProperty: "inst_1:notify", "source": "dist_task"}
+	if !reflect.DeepEqual(msg.Properties, expected) {
+		t.Errorf("Properties = %v, want %v", msg.Properties, expected)
+	}
+}
+
+func TestParseMQConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"plain", `{"topic": "t"}`, false},
+		{"delay level", `{"topic": "t", "delay_level": 3}`, false},
+		{"missing topic", `{}`, true},
+		{"delay level out of range", `{"topic": "t", "delay_level": 19}`, true},
+		{"delay and deliver_at", `{"topic": "t", "delay_level": 3, "deliver_at": "2024-01-31T10:00:00Z"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMQConfig([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseMQConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMQOutboxExecutor_Stage(t *testing.T) {
+	e := &MQOutboxExecutor{}
+	ctx := WithIdempotencyKey(context.Background(), "inst_1:notify")
+
+	message, output, err := e.Stage(ctx, []byte(`{"topic":"payment.completed"}`), map[string]interface{}{"order_id": "o_001"})
+	if err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+	if message.Topic != "payment.completed" || output["message_key"] != message.MessageKey {
+		t.Errorf("Stage() message = %+v, output = %v", message, output)
+	}
+
+	if _, _, err := e.Stage(ctx, []byte(`{"topic":"payment.completed","transactional":true}`), nil); err == nil {
+		t.Error("Stage() with transactional config error = nil")
+	}
+}
+
+func TestTransactionState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   messaging.TxState
+	}{
+		{"success", messaging.TxCommit},
+		{"failed", messaging.TxRollback},
+		{"cancelled", messaging.TxRollback},
+		{"running", messaging.TxUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := transactionState(tt.status); got != tt.want {
+			t.Errorf("transactionState(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestBreakerKey(t *testing.T) {
+	tests := []struct {
+		taskType string
+		config   string
+		want     string
+	}{
+		{"rpc", `{"service": "inventory-service", "method": "lock"}`, "rpc:inventory-service"},
+		{"rpc", `{"protocol": "grpc", "target": "inventory:9090", "service": "inventory.v1.Inventory"}`, "rpc:inventory:9090"},
+		{"http", `{"url": "https://api.example.com/v1/pay"}`, "http:api.example.com"},
+		{"mq", `{"topic": "payment.completed"}`, "mq:payment.completed"},
+		{"db", `{"operation": "insert", "table": "orders"}`, "db:orders"},
+		{"db", `{"datasource": "billing", "operation": "insert", "table": "invoices"}`, "db:billing"},
+		{"db", `{"statements": [{"operation": "update", "table": "stock"}]}`, "db:stock"},
+		{"http", `{}`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := BreakerKey(tt.taskType, []byte(tt.config)); got != tt.want {
+			t.Errorf("BreakerKey(%s, %s) = %q, want %q", tt.taskType, tt.config, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/internal/discovery"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+	"dist_task/pkg/taskdef"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCExecutor 以动态消息调用 gRPC 一元方法，任务入参按 protojson 规则映射为请求消息
+type GRPCExecutor struct {
+	files      *protoregistry.Files // descriptor_sets 中注册的服务
+	reflection bool
+	timeout    time.Duration
+	discovery  *discovery.Discovery
+
+	mu       sync.Mutex
+	conns    map[string]*grpc.ClientConn
+	services map[string]protoreflect.ServiceDescriptor // 反射获取的服务，按 target/service 缓存
+}
+
+// NewGRPCExecutor target 为服务名时由 d 解析为节点地址，d 为 nil 时按字面地址连接
+func NewGRPCExecutor(cfg *config.GRPCConfig, d *discovery.Discovery) (*GRPCExecutor, error) {
+	files, err := loadDescriptorSets(cfg.DescriptorSets)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &GRPCExecutor{
+		files:      files,
+		reflection: cfg.Reflection,
+		timeout:    timeout,
+		discovery:  d,
+		conns:      make(map[string]*grpc.ClientConn),
+		services:   make(map[string]protoreflect.ServiceDescriptor),
+	}, nil
+}
+
+// loadDescriptorSets 合并多个 FileDescriptorSet，同名文件只保留第一个
+func loadDescriptorSets(paths []string) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read descriptor set %s failed: %w", path, err)
+		}
+		var fds descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(data, &fds); err != nil {
+			return nil, fmt.Errorf("parse descriptor set %s failed: %w", path, err)
+		}
+		for _, file := range fds.File {
+			if !seen[file.GetName()] {
+				seen[file.GetName()] = true
+				set.File = append(set.File, file)
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("build descriptor sets failed: %w", err)
+	}
+	return files, nil
+}
+
+func (e *GRPCExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var cfg taskdef.TaskConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse grpc config failed")
+	}
+	if cfg.Target == "" || cfg.Service == "" || cfg.Method == "" {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "grpc config incomplete: target=%s, service=%s, method=%s", cfg.Target, cfg.Service, cfg.Method)
+	}
+
+	timeout := e.timeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	md := metadata.New(cfg.Metadata)
+	if key := IdempotencyKey(ctx); key != "" {
+		md.Set(IdempotencyHeader, key)
+	}
+	inputBytes, _ := json.Marshal(input)
+	fullMethod := fmt.Sprintf("/%s/%s", cfg.Service, cfg.Method)
+
+	var endpoint string
+	var output []byte
+	err := e.discovery.Do(ctx, cfg.Target, func(address string) error {
+		conn, err := e.conn(address, cfg.TLS)
+		if err != nil {
+			return err
+		}
+
+		method, err := e.resolveMethod(ctx, conn, cfg.Target, cfg.Service, cfg.Method)
+		if err != nil {
+			return err
+		}
+
+		request := dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal(inputBytes, request); err != nil {
+			return apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeValidationFailed, err, "map input to grpc request failed")
+		}
+
+		response := dynamicpb.NewMessage(method.Output())
+		if err := conn.Invoke(metadata.NewOutgoingContext(ctx, md), fullMethod, request, response); err != nil {
+			return grpcError(err, "grpc call failed")
+		}
+
+		endpoint = address
+		output, err = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(response)
+		if err != nil {
+			return apperrors.Wrap(apperrors.CategoryUnknown, apperrors.CodeUnknown, err, "encode grpc response failed")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("target", cfg.Target).
+		Str("endpoint", endpoint).
+		Str("method", fullMethod).
+		Strs("input_fields", inputFields(input)).
+		Msg("gRPC executor completed")
+
+	return decodeOutput(output), nil
+}
+
+// conn 按 target 和 TLS 配置复用连接
+func (e *GRPCExecutor) conn(target string, tlsCfg *taskdef.TLSConfig) (*grpc.ClientConn, error) {
+	tlsKey, _ := json.Marshal(tlsCfg)
+	key := target + "|" + string(tlsKey)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if conn, ok := e.conns[key]; ok {
+		return conn, nil
+	}
+
+	creds, err := transportCredentials(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "create grpc client failed")
+	}
+	e.conns[key] = conn
+	return conn, nil
+}
+
+func transportCredentials(cfg *taskdef.TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// clientTLSConfig 加载 CA 和双向认证的客户端证书，gRPC 和 HTTP 任务共用
+func clientTLSConfig(cfg *taskdef.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "read tls ca_file failed")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "tls ca_file contains no certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "load tls client certificate failed")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// resolveMethod 优先从 descriptor_sets 查找服务，找不到时通过服务端反射获取
+func (e *GRPCExecutor) resolveMethod(ctx context.Context, conn *grpc.ClientConn, target, service, method string) (protoreflect.MethodDescriptor, error) {
+	var sd protoreflect.ServiceDescriptor
+	if desc, err := e.files.FindDescriptorByName(protoreflect.FullName(service)); err == nil {
+		sd, _ = desc.(protoreflect.ServiceDescriptor)
+	}
+
+	if sd == nil {
+		if !e.reflection {
+			return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "grpc service %s not found in descriptor sets", service)
+		}
+
+		var err error
+		sd, err = e.reflectService(ctx, conn, target, service)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "grpc method %s/%s not found", service, method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "grpc method %s/%s is streaming, only unary methods are supported", service, method)
+	}
+	return md, nil
+}
+
+func (e *GRPCExecutor) reflectService(ctx context.Context, conn *grpc.ClientConn, target, service string) (protoreflect.ServiceDescriptor, error) {
+	key := target + "/" + service
+
+	e.mu.Lock()
+	sd, ok := e.services[key]
+	e.mu.Unlock()
+	if ok {
+		return sd, nil
+	}
+
+	files, err := fetchFiles(ctx, conn, service)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "grpc service not found via reflection")
+	}
+	sd, ok = desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "%s is not a grpc service", service)
+	}
+
+	e.mu.Lock()
+	e.services[key] = sd
+	e.mu.Unlock()
+	return sd, nil
+}
+
+// fetchFiles 通过反射获取定义服务的文件及其全部依赖，
+// 服务端未返回的依赖先从本地已注册的文件（如 well-known types）补齐
+func fetchFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, grpcError(err, "grpc reflection failed")
+	}
+	defer stream.CloseSend()
+
+	fetched := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}
+	for request != nil {
+		if err := stream.Send(request); err != nil {
+			return nil, grpcError(err, "grpc reflection failed")
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, grpcError(err, "grpc reflection failed")
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, grpcError(status.Error(codes.Code(errResp.ErrorCode), errResp.ErrorMessage), "grpc reflection failed")
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var file descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &file); err != nil {
+				return nil, apperrors.Wrap(apperrors.CategoryServer, apperrors.CodeUnknown, err, "parse reflected descriptor failed")
+			}
+			fetched[file.GetName()] = &file
+		}
+
+		request = nil
+		for _, dep := range missingDependencies(fetched) {
+			if local, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				fetched[dep] = protodesc.ToFileDescriptorProto(local)
+				continue
+			}
+			request = &reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			}
+			break
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range fetched {
+		set.File = append(set.File, file)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryServer, apperrors.CodeUnknown, err, "build reflected descriptors failed")
+	}
+	return files, nil
+}
+
+func missingDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, file := range files {
+		for _, dep := range file.GetDependency() {
+			if _, ok := files[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
+
+func (e *GRPCExecutor) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, conn := range e.conns {
+		conn.Close()
+		delete(e.conns, key)
+	}
+	return nil
+}
+
+// grpcError 按状态码分类，错误码为 GRPC_<code>；与 HTTP 429 一样，
+// RESOURCE_EXHAUSTED 和 ABORTED 视为可重试
+func grpcError(err error, message string) *apperrors.Error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return apperrors.Classify(err, apperrors.CategoryConnection, apperrors.CodeConnectionFailed, message)
+	}
+
+	var category apperrors.Category
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		category = apperrors.CategoryTimeout
+	case codes.Unavailable:
+		category = apperrors.CategoryConnection
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange, codes.Unimplemented,
+		codes.ResourceExhausted, codes.Aborted:
+		category = apperrors.CategoryClient
+	case codes.Internal, codes.DataLoss:
+		category = apperrors.CategoryServer
+	default:
+		category = apperrors.CategoryUnknown
+	}
+
+	e := apperrors.Wrap(category, fmt.Sprintf("GRPC_%d", st.Code()), err, message)
+	if st.Code() == codes.ResourceExhausted || st.Code() == codes.Aborted {
+		e.Retryable = true
+	}
+	return e
+}
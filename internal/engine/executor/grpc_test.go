@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func startHealthServer(t *testing.T, withReflection bool) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	server := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("payment", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, hs)
+	if withReflection {
+		reflection.Register(server)
+	}
+
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func writeHealthDescriptorSet(t *testing.T) string {
+	t.Helper()
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal descriptor set failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "health.pb")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write descriptor set failed: %v", err)
+	}
+	return path
+}
+
+func TestGRPCExecutor(t *testing.T) {
+	tests := []struct {
+		name          string
+		reflect       bool
+		descriptorSet bool
+		service       string
+		input         map[string]interface{}
+		want          string
+		wantCode      string
+	}{
+		{
+			name:    "reflection",
+			reflect: true,
+			input:   map[string]interface{}{"service": "payment"},
+			want:    "SERVING",
+		},
+		{
+			name:          "descriptor set",
+			descriptorSet: true,
+			input:         map[string]interface{}{"service": "payment"},
+			want:          "SERVING",
+		},
+		{
+			name:     "status not found",
+			reflect:  true,
+			input:    map[string]interface{}{"service": "unknown"},
+			wantCode: "GRPC_5",
+		},
+		{
+			name:     "unknown input field",
+			reflect:  true,
+			input:    map[string]interface{}{"order_id": "o_001"},
+			wantCode: apperrors.CodeValidationFailed,
+		},
+		{
+			name:     "service not registered",
+			service:  "payment.v1.PaymentService",
+			input:    map[string]interface{}{"service": "payment"},
+			wantCode: apperrors.CodeConfigInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := startHealthServer(t, tt.reflect)
+
+			cfg := config.GRPCConfig{Reflection: tt.reflect}
+			if tt.descriptorSet {
+				cfg.DescriptorSets = []string{writeHealthDescriptorSet(t)}
+			}
+			exec, err := NewGRPCExecutor(&cfg, nil)
+			if err != nil {
+				t.Fatalf("NewGRPCExecutor() error = %v", err)
+			}
+			defer exec.Close()
+
+			service := tt.service
+			if service == "" {
+				service = "grpc.health.v1.Health"
+			}
+			taskCfg := []byte(`{"protocol": "grpc", "target": "` + target + `", "service": "` + service + `", "method": "Check", "metadata": {"x-source": "dist_task"}}`)
+
+			ctx := WithIdempotencyKey(context.Background(), "inst_1:check")
+			output, err := NewRPCExecutor(exec, nil).Execute(ctx, taskCfg, tt.input)
+			if tt.wantCode != "" {
+				var typed *apperrors.Error
+				if !errors.As(err, &typed) || typed.Code != tt.wantCode {
+					t.Fatalf("Execute() error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if output["status"] != tt.want {
+				t.Errorf("status = %v, want %s", output["status"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,547 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dist_task/internal/discovery"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+	"dist_task/pkg/taskdef"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	signatureHeader    = "X-Signature"
+	timestampHeader    = "X-Timestamp"
+	keyIDHeader        = "X-Key-Id"
+)
+
+// HTTPExecutor url 中的主机名可以是服务名，由 discovery 解析为节点地址
+type HTTPExecutor struct {
+	client    *http.Client
+	discovery *discovery.Discovery
+
+	mu      sync.Mutex
+	clients map[string]*http.Client // 按 TLS 配置复用的客户端
+}
+
+func NewHTTPExecutor(d *discovery.Discovery) *HTTPExecutor {
+	return &HTTPExecutor{
+		client:    &http.Client{},
+		discovery: d,
+		clients:   make(map[string]*http.Client),
+	}
+}
+
+// httpRequest 按配置生成的请求体，服务发现换节点重试时复用
+type httpRequest struct {
+	method      string
+	target      *url.URL
+	body        []byte
+	contentType string
+}
+
+func (e *HTTPExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var cfg taskdef.TaskConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse http config failed")
+	}
+
+	if cfg.URL == "" {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "http url is required")
+	}
+
+	checker, err := newResponseChecker(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := buildHTTPRequest(&cfg, input)
+	if err != nil {
+		return nil, err
+	}
+	client, err := e.httpClient(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultHTTPTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var endpoint string
+	var statusCode int
+	var respBody []byte
+	err = e.discovery.Do(ctx, req.target.Host, func(address string) error {
+		u := *req.target
+		u.Host = address
+
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(req.body))
+		if err != nil {
+			return apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "create http request failed")
+		}
+
+		if req.contentType != "" {
+			httpReq.Header.Set("Content-Type", req.contentType)
+		}
+		for k, v := range cfg.Headers {
+			httpReq.Header.Set(k, v)
+		}
+		if key := IdempotencyKey(ctx); key != "" {
+			httpReq.Header.Set(IdempotencyHeader, key)
+		}
+		if err := setAuth(httpReq, cfg.Auth, req.body); err != nil {
+			return err
+		}
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return apperrors.Classify(err, apperrors.CategoryConnection, apperrors.CodeConnectionFailed, "http request failed")
+		}
+		defer resp.Body.Close()
+
+		endpoint, statusCode = address, resp.StatusCode
+		respBody, _ = io.ReadAll(resp.Body)
+		if !checker.statusOK(resp.StatusCode) {
+			return apperrors.HTTPStatus(resp.StatusCode, fmt.Sprintf("http request failed with status %d: %s", resp.StatusCode, string(respBody)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("url", cfg.URL).
+		Str("endpoint", endpoint).
+		Str("method", req.method).
+		Int("status", statusCode).
+		Int("body_size", len(respBody)).
+		Msg("HTTP executor completed")
+
+	return checker.check(statusCode, respBody)
+}
+
+// httpClient 未配置 TLS 时使用共享客户端，否则按 TLS 配置复用
+func (e *HTTPExecutor) httpClient(tlsCfg *taskdef.TLSConfig) (*http.Client, error) {
+	if tlsCfg == nil {
+		return e.client, nil
+	}
+
+	data, _ := json.Marshal(tlsCfg)
+	key := string(data)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if client, ok := e.clients[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := clientTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{Transport: transport}
+	e.clients[key] = client
+	return client, nil
+}
+
+func buildHTTPRequest(cfg *taskdef.TaskConfig, input map[string]interface{}) (*httpRequest, error) {
+	target, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse http url failed")
+	}
+	if len(cfg.Query) > 0 {
+		query := target.Query()
+		for k, v := range cfg.Query {
+			query.Set(k, formatValue(v))
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	req := &httpRequest{method: "POST", target: target}
+	if cfg.Method != "" {
+		req.method = strings.ToUpper(cfg.Method)
+	}
+
+	bodies := 0
+	for _, set := range []bool{cfg.Body != nil, len(cfg.Form) > 0, len(cfg.Multipart) > 0} {
+		if set {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "http body, form and multipart are mutually exclusive")
+	}
+
+	switch {
+	case cfg.Body != nil:
+		req.contentType = "application/json"
+		if s, ok := cfg.Body.(string); ok {
+			req.body = []byte(s)
+		} else {
+			req.body, _ = json.Marshal(cfg.Body)
+		}
+	case len(cfg.Form) > 0:
+		form := url.Values{}
+		for k, v := range cfg.Form {
+			form.Set(k, formatValue(v))
+		}
+		req.contentType = "application/x-www-form-urlencoded"
+		req.body = []byte(form.Encode())
+	case len(cfg.Multipart) > 0:
+		req.body, req.contentType, err = buildMultipart(cfg.Multipart)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		// 未配置请求体时沿用入参中的 body
+		req.contentType = "application/json"
+		if body, _ := input["body"].(string); body != "" {
+			req.body = []byte(body)
+		}
+	}
+	return req, nil
+}
+
+func buildMultipart(fields []taskdef.MultipartField) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range fields {
+		if field.Name == "" {
+			return nil, "", apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "multipart field name is required")
+		}
+
+		content := []byte(field.Value)
+		switch field.Encoding {
+		case "":
+		case "base64":
+			data, err := base64.StdEncoding.DecodeString(field.Value)
+			if err != nil {
+				return nil, "", apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeValidationFailed, err, "decode multipart field "+field.Name+" failed")
+			}
+			content = data
+		default:
+			return nil, "", apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unknown multipart encoding: %s", field.Encoding)
+		}
+
+		fileName := field.FileName
+		header := make(textproto.MIMEHeader)
+		if fileName != "" {
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field.Name, fileName))
+			header.Set("Content-Type", "application/octet-stream")
+		} else {
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, field.Name))
+		}
+		if field.ContentType != "" {
+			header.Set("Content-Type", field.ContentType)
+		}
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "build multipart body failed")
+		}
+		part.Write(content)
+	}
+	writer.Close()
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// setAuth hmac 签名内容为 METHOD\nPATH?QUERY\nTIMESTAMP\nBODY，十六进制编码
+func setAuth(req *http.Request, auth *taskdef.HTTPAuth, body []byte) error {
+	if auth == nil {
+		return nil
+	}
+
+	switch strings.ToLower(auth.Type) {
+	case "basic":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case "hmac":
+		newHash, err := hmacHash(auth.Algorithm)
+		if err != nil {
+			return err
+		}
+		if auth.Secret == "" {
+			return apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "hmac secret is required")
+		}
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(newHash, []byte(auth.Secret))
+		fmt.Fprintf(mac, "%s\n%s\n%s\n", req.Method, req.URL.RequestURI(), timestamp)
+		mac.Write(body)
+
+		header := auth.Header
+		if header == "" {
+			header = signatureHeader
+		}
+		req.Header.Set(timestampHeader, timestamp)
+		req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		if auth.KeyID != "" {
+			req.Header.Set(keyIDHeader, auth.KeyID)
+		}
+	default:
+		return apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unsupported http auth type: %s", auth.Type)
+	}
+	return nil
+}
+
+func hmacHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unsupported hmac algorithm: %s", algorithm)
+}
+
+// responseChecker 校验状态码和响应断言，并按 extract 生成任务输出
+type responseChecker struct {
+	statuses   [][2]int
+	assertions []compiledAssertion
+	extract    map[string]*jsonPath
+}
+
+type compiledAssertion struct {
+	taskdef.HTTPAssertion
+	path    *jsonPath
+	pattern *regexp.Regexp
+}
+
+// newResponseChecker 在发送请求前校验配置，避免请求发出后才发现配置错误
+func newResponseChecker(cfg *taskdef.TaskConfig) (*responseChecker, error) {
+	c := &responseChecker{}
+	if cfg.Success != nil {
+		for _, status := range cfg.Success.Status {
+			r, err := parseStatusRange(status)
+			if err != nil {
+				return nil, err
+			}
+			c.statuses = append(c.statuses, r)
+		}
+
+		for _, assertion := range cfg.Success.Assertions {
+			path, err := parseJSONPath(assertion.Path)
+			if err != nil {
+				return nil, err
+			}
+			compiled := compiledAssertion{HTTPAssertion: assertion, path: path}
+			if compiled.Op == "" {
+				compiled.Op = "eq"
+			}
+			switch compiled.Op {
+			case "eq", "ne", "gt", "gte", "lt", "lte", "exists", "not_exists", "contains", "in":
+			case "matches":
+				pattern, _ := assertion.Value.(string)
+				if compiled.pattern, err = regexp.Compile(pattern); err != nil {
+					return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "invalid assertion pattern")
+				}
+			default:
+				return nil, apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "unsupported assertion op: %s", assertion.Op)
+			}
+			c.assertions = append(c.assertions, compiled)
+		}
+	}
+
+	if len(cfg.Extract) > 0 {
+		c.extract = make(map[string]*jsonPath, len(cfg.Extract))
+		for field, expr := range cfg.Extract {
+			path, err := parseJSONPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			c.extract[field] = path
+		}
+	}
+	return c, nil
+}
+
+// parseStatusRange 支持 "200"、"2xx"、"200-299"
+func parseStatusRange(s string) ([2]int, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	invalid := apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid success status: %s", s)
+
+	if len(s) == 3 && strings.HasSuffix(s, "xx") {
+		class, err := strconv.Atoi(s[:1])
+		if err != nil {
+			return [2]int{}, invalid
+		}
+		return [2]int{class * 100, class*100 + 99}, nil
+	}
+
+	low, high, isRange := strings.Cut(s, "-")
+	from, err := strconv.Atoi(low)
+	if err != nil {
+		return [2]int{}, invalid
+	}
+	to := from
+	if isRange {
+		if to, err = strconv.Atoi(high); err != nil || to < from {
+			return [2]int{}, invalid
+		}
+	}
+	return [2]int{from, to}, nil
+}
+
+func (c *responseChecker) statusOK(status int) bool {
+	if len(c.statuses) == 0 {
+		return status < 400
+	}
+	for _, r := range c.statuses {
+		if status >= r[0] && status <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// check 断言失败时返回 CategoryValidation 错误；未配置 extract 时输出为整个响应体
+func (c *responseChecker) check(status int, body []byte) (map[string]interface{}, error) {
+	var doc interface{}
+	if len(c.assertions) > 0 || len(c.extract) > 0 {
+		json.Unmarshal(body, &doc)
+	}
+
+	for _, assertion := range c.assertions {
+		actual, found := assertion.path.eval(doc)
+		if !assertion.holds(actual, found) {
+			return nil, apperrors.Newf(apperrors.CategoryValidation, apperrors.CodeAssertionFailed,
+				"http response assertion failed: %s %s %v, got %v", assertion.Path, assertion.Op, assertion.Value, actual)
+		}
+	}
+
+	var output map[string]interface{}
+	if c.extract != nil {
+		output = make(map[string]interface{}, len(c.extract)+1)
+		for field, path := range c.extract {
+			if value, found := path.eval(doc); found {
+				output[field] = value
+			}
+		}
+	} else if output = decodeOutput(body); output == nil {
+		output = make(map[string]interface{})
+	}
+	output["status"] = status
+	return output, nil
+}
+
+func (a *compiledAssertion) holds(actual interface{}, found bool) bool {
+	switch a.Op {
+	case "exists":
+		return found
+	case "not_exists":
+		return !found
+	}
+	if !found {
+		return false
+	}
+
+	switch a.Op {
+	case "eq":
+		return jsonEqual(actual, a.Value)
+	case "ne":
+		return !jsonEqual(actual, a.Value)
+	case "gt", "gte", "lt", "lte":
+		x, ok1 := actual.(float64)
+		y, ok2 := toFloat(a.Value)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch a.Op {
+		case "gt":
+			return x > y
+		case "gte":
+			return x >= y
+		case "lt":
+			return x < y
+		}
+		return x <= y
+	case "contains":
+		switch v := actual.(type) {
+		case string:
+			sub, ok := a.Value.(string)
+			return ok && strings.Contains(v, sub)
+		case []interface{}:
+			return containsValue(v, a.Value)
+		}
+		return false
+	case "matches":
+		s, ok := actual.(string)
+		return ok && a.pattern.MatchString(s)
+	case "in":
+		list, ok := a.Value.([]interface{})
+		return ok && containsValue(list, actual)
+	}
+	return false
+}
+
+// jsonEqual 按 JSON 语义比较，数字统一为 float64
+func jsonEqual(x, y interface{}) bool {
+	a, _ := json.Marshal(x)
+	b, _ := json.Marshal(y)
+	return bytes.Equal(a, b)
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if jsonEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatValue 占位符可能解析为数字或布尔值，查询参数和表单统一转为字符串
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
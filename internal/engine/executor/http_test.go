@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	apperrors "dist_task/pkg/errors"
+)
+
+func TestHTTPExecutor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + r.Header.Get(timestampHeader) + "\n"))
+		mac.Write(body)
+
+		switch {
+		case r.Header.Get(signatureHeader) != hex.EncodeToString(mac.Sum(nil)):
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Query().Get("page") != "2":
+			w.WriteHeader(http.StatusBadRequest)
+		case string(body) != `{"order_id":"o-1"}`:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"code":0,"data":{"items":[{"id":"a","qty":1},{"id":"b","qty":3}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	base := `"url":"` + server.URL + `/orders","query":{"page":2},"body":{"order_id":"o-1"},"auth":{"type":"hmac","secret":"s3cret"}`
+	tests := []struct {
+		name     string
+		config   string
+		want     map[string]interface{}
+		category apperrors.Category
+	}{
+		{
+			name:   "extract",
+			config: `{` + base + `,"success":{"status":["200-202"],"assertions":[{"path":"$.code","value":0},{"path":"$.data.items[*].id","op":"contains","value":"b"}]},"extract":{"ids":"$.data.items[*].id","last_qty":"$.data.items[-1].qty"}}`,
+			want:   map[string]interface{}{"ids": []interface{}{"a", "b"}, "last_qty": float64(3), "status": 202},
+		},
+		{
+			name:     "status not in success range",
+			config:   `{` + base + `,"success":{"status":["200"]}}`,
+			category: apperrors.CategoryClient,
+		},
+		{
+			name:     "assertion failed",
+			config:   `{` + base + `,"success":{"assertions":[{"path":"$.data.items[0].qty","op":"gt","value":1}]}}`,
+			category: apperrors.CategoryValidation,
+		},
+		{
+			name:     "bad signature",
+			config:   `{` + base + `,"auth":{"type":"hmac","secret":"wrong"}}`,
+			category: apperrors.CategoryClient,
+		},
+		{
+			name:     "invalid jsonpath",
+			config:   `{` + base + `,"extract":{"id":"data.id"}}`,
+			category: apperrors.CategoryConfig,
+		},
+	}
+
+	e := NewHTTPExecutor(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := e.Execute(context.Background(), []byte(tt.config), nil)
+			if tt.category != 0 {
+				var typed *apperrors.Error
+				if !errors.As(err, &typed) || typed.Category != tt.category {
+					t.Fatalf("Execute() error = %v, want category %s", err, tt.category)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !reflect.DeepEqual(output, tt.want) {
+				t.Errorf("Execute() = %v, want %v", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPRequestBody(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			r.ParseMultipartForm(1 << 20)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	e := NewHTTPExecutor(nil)
+	cfg := `{"url":"` + server.URL + `","form":{"name":"alice","age":30},"auth":{"type":"basic","username":"u","password":"p"}}`
+	if _, err := e.Execute(context.Background(), []byte(cfg), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("Content-Type") != "application/x-www-form-urlencoded" || string(body) != "age=30&name=alice" {
+		t.Errorf("form request: %s %s", got.Header.Get("Content-Type"), body)
+	}
+	if user, pass, ok := got.BasicAuth(); !ok || user != "u" || pass != "p" {
+		t.Errorf("basic auth = %s:%s", user, pass)
+	}
+
+	cfg = `{"url":"` + server.URL + `","multipart":[{"name":"kind","value":"invoice"},{"name":"file","file_name":"a.txt","value":"hello"},{"name":"scan","file_name":"a.pdf","encoding":"base64","value":"JVBERi0=","content_type":"application/pdf"}],"auth":{"type":"bearer","token":"t0k"}}`
+	if _, err := e.Execute(context.Background(), []byte(cfg), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.MultipartForm == nil {
+		t.Fatalf("multipart form not parsed, content type %s", got.Header.Get("Content-Type"))
+	}
+	if got.FormValue("kind") != "invoice" || got.Header.Get("Authorization") != "Bearer t0k" {
+		t.Errorf("multipart kind = %q, auth = %q", got.FormValue("kind"), got.Header.Get("Authorization"))
+	}
+	if files := got.MultipartForm.File["file"]; len(files) != 1 || files[0].Filename != "a.txt" {
+		t.Errorf("multipart files = %v", files)
+	}
+	if files := got.MultipartForm.File["scan"]; len(files) != 1 || files[0].Header.Get("Content-Type") != "application/pdf" {
+		t.Errorf("multipart scan = %v", files)
+	} else {
+		f, _ := files[0].Open()
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != "%PDF-" {
+			t.Errorf("multipart scan content = %q, want decoded base64", data)
+		}
+	}
+
+	for _, field := range []string{
+		`{"name":"scan","file_name":"a.pdf","encoding":"base64","value":"not base64"}`,
+		`{"name":"scan","file_name":"a.pdf","encoding":"hex","value":"00"}`,
+	} {
+		cfg = `{"url":"` + server.URL + `","multipart":[` + field + `]}`
+		if _, err := e.Execute(context.Background(), []byte(cfg), nil); err == nil {
+			t.Errorf("Execute() with multipart %s succeeded", field)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	apperrors "dist_task/pkg/errors"
+)
+
+// jsonPath JSONPath 的常用子集：$、.name、['name']、[n]（负数从末尾计）、[*] 和 .*
+type jsonPath struct {
+	steps []pathStep
+	multi bool // 含通配符时结果为数组
+}
+
+type pathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(expr string) (*jsonPath, error) {
+	invalid := func(reason string) error {
+		return apperrors.Newf(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "invalid jsonpath %q: %s", expr, reason)
+	}
+
+	p := strings.TrimSpace(expr)
+	if !strings.HasPrefix(p, "$") {
+		return nil, invalid("must start with $")
+	}
+	p = p[1:]
+
+	path := &jsonPath{}
+	for len(p) > 0 {
+		var step pathStep
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, invalid("empty field name")
+			}
+			step.name, p = p[:end], p[end:]
+			step.wildcard = step.name == "*"
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, invalid("unclosed bracket")
+			}
+			selector := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			switch {
+			case selector == "*":
+				step.wildcard = true
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				step.name = selector[1 : len(selector)-1]
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, invalid("bad index " + selector)
+				}
+				step.index, step.isIndex = index, true
+			}
+		default:
+			return nil, invalid("unexpected " + string(p[0]))
+		}
+		path.multi = path.multi || step.wildcard
+		path.steps = append(path.steps, step)
+	}
+	return path, nil
+}
+
+// eval 返回匹配的值；含通配符时返回所有匹配组成的数组
+func (p *jsonPath) eval(doc interface{}) (interface{}, bool) {
+	nodes := []interface{}{doc}
+	for _, step := range p.steps {
+		var next []interface{}
+		for _, node := range nodes {
+			next = append(next, step.apply(node)...)
+		}
+		nodes = next
+	}
+
+	if p.multi {
+		if nodes == nil {
+			nodes = []interface{}{}
+		}
+		return nodes, true
+	}
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+func (s pathStep) apply(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				values = append(values, v[k])
+			}
+			return values
+		}
+		if value, ok := v[s.name]; ok && !s.isIndex {
+			return []interface{}{value}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return v
+		}
+		if s.isIndex {
+			i := s.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []interface{}{v[i]}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"context"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+)
+
+// Stager 支持 outbox 模式的执行器：只生成待投递消息，
+// 由引擎在更新任务状态的同一事务中写入 outbox
+type Stager interface {
+	Stage(ctx context.Context, config []byte, input map[string]interface{}) (*model.OutboxMessage, map[string]interface{}, error)
+}
+
+// MQOutboxExecutor outbox 模式下的 MQ 执行器
+type MQOutboxExecutor struct{}
+
+func (e *MQOutboxExecutor) Stage(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (*model.OutboxMessage, map[string]interface{}, error) {
+	cfg, err := parseMQConfig(cfgBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	// relay 普通发送 outbox 中的消息，无法保留事务半消息语义
+	if cfg.Transactional {
+		return nil, nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "transactional mq task is not supported in outbox mode")
+	}
+	message, err := buildMessage(ctx, cfg, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return message, map[string]interface{}{"topic": message.Topic, "message_key": message.MessageKey}, nil
+}
+
+func (e *MQOutboxExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "mq outbox executor must be staged")
+}
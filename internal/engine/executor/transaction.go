@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"dist_task/internal/messaging"
+	"dist_task/internal/model"
+	"dist_task/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// TaskTransactionChecker 根据 dist_task 中的任务状态决定事务消息提交或回滚
+func TaskTransactionChecker(db *gorm.DB) messaging.TransactionChecker {
+	return func(taskID string) messaging.TxState {
+		var task model.DistTask
+		err := db.Select("id", "status").Where("id = ?", taskID).Take(&task).Error
+		if err == gorm.ErrRecordNotFound {
+			return messaging.TxRollback
+		}
+		if err != nil {
+			logger.Error().Err(err).Str("task_id", taskID).Msg("check mq transaction failed")
+			return messaging.TxUnknown
+		}
+		return transactionState(task.Status)
+	}
+}
+
+// transactionState 任务成功则提交消息，失败或取消则回滚，其余状态继续等待回查
+func transactionState(status string) messaging.TxState {
+	switch status {
+	case "success":
+		return messaging.TxCommit
+	case "failed", "cancelled":
+		return messaging.TxRollback
+	default:
+		return messaging.TxUnknown
+	}
+}
@@ -0,0 +1,270 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+const (
+	defaultTransformTimeout  = time.Second
+	defaultTransformMaxSteps = 1000000
+)
+
+// TransformExecutor 在 Starlark 沙箱中计算任务输出。脚本只能访问 input、outputs
+// 以及内置的 json、math 模块，不能读写文件或发起网络请求
+type TransformExecutor struct {
+	timeout  time.Duration
+	maxSteps uint64
+}
+
+func NewTransformExecutor(cfg *config.TransformConfig) *TransformExecutor {
+	e := &TransformExecutor{
+		timeout:  defaultTransformTimeout,
+		maxSteps: defaultTransformMaxSteps,
+	}
+	if cfg.Timeout > 0 {
+		e.timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+	if cfg.MaxSteps > 0 {
+		e.maxSteps = cfg.MaxSteps
+	}
+	return e
+}
+
+// TransformConfig expression 与 script 二选一：expression 为单个表达式，
+// script 为多行脚本，结果赋值给全局变量 output
+type TransformConfig struct {
+	Expression string `json:"expression,omitempty"`
+	Script     string `json:"script,omitempty"`
+	Timeout    int    `json:"timeout,omitempty"`   // 毫秒，不能超过 [transform] timeout
+	MaxSteps   uint64 `json:"max_steps,omitempty"` // 不能超过 [transform] max_steps
+}
+
+var transformFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+func (e *TransformExecutor) Execute(ctx context.Context, cfgBytes []byte, input map[string]interface{}) (map[string]interface{}, error) {
+	var cfg TransformConfig
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse transform config failed")
+	}
+	if (cfg.Expression == "") == (cfg.Script == "") {
+		return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "transform requires exactly one of expression and script")
+	}
+
+	timeout, maxSteps := e.timeout, e.maxSteps
+	if cfg.Timeout > 0 && time.Duration(cfg.Timeout)*time.Millisecond < timeout {
+		timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+	if cfg.MaxSteps > 0 && cfg.MaxSteps < maxSteps {
+		maxSteps = cfg.MaxSteps
+	}
+
+	globals, err := transformGlobals(input, Outputs(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &starlark.Thread{
+		Name: "transform:" + TaskID(ctx),
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.Debug().Str("task_id", TaskID(ctx)).Msg(msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(fmt.Sprintf("transform exceeded %s", timeout))
+		case <-done:
+		}
+	}()
+
+	var result starlark.Value
+	if cfg.Expression != "" {
+		result, err = starlark.EvalOptions(transformFileOptions, thread, "expression", cfg.Expression, globals)
+	} else {
+		var vars starlark.StringDict
+		vars, err = starlark.ExecFileOptions(transformFileOptions, thread, "script", cfg.Script, globals)
+		if err == nil {
+			var ok bool
+			if result, ok = vars["output"]; !ok {
+				return nil, apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "transform script must assign output")
+			}
+		}
+	}
+	if err != nil {
+		return nil, transformError(err)
+	}
+
+	value, err := fromStarlark(result)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeTransformFailed, err, "convert transform output failed")
+	}
+
+	logger.Info().
+		Str("task_id", TaskID(ctx)).
+		Uint64("steps", thread.ExecutionSteps()).
+		Msg("Transform executor completed")
+
+	// 结果不是 dict 时放在 result 字段中
+	if output, ok := value.(map[string]interface{}); ok {
+		return output, nil
+	}
+	return map[string]interface{}{"result": value}, nil
+}
+
+// transformError 语法错误说明流程定义有误；运行时错误（含超时、超出指令数）与数据有关，都不自动重试
+func transformError(err error) *apperrors.Error {
+	var syntaxErr syntax.Error
+	var resolveErrs resolve.ErrorList
+	if errors.As(err, &syntaxErr) || errors.As(err, &resolveErrs) {
+		return apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "compile transform failed")
+	}
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return apperrors.Newf(apperrors.CategoryValidation, apperrors.CodeTransformFailed, "transform failed: %s", evalErr.Backtrace())
+	}
+	return apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeTransformFailed, err, "transform failed")
+}
+
+func transformGlobals(input, outputs map[string]interface{}) (starlark.StringDict, error) {
+	in, err := toStarlark(input)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeTransformFailed, err, "convert input failed")
+	}
+	out, err := toStarlark(outputs)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeTransformFailed, err, "convert outputs failed")
+	}
+	return starlark.StringDict{
+		"input":   in,
+		"outputs": out,
+		"json":    starlarkjson.Module,
+		"math":    starlarkmath.Module,
+	}, nil
+}
+
+// toStarlark 转换 JSON 解码得到的值，整数值的 float64 转为 int
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return starlark.MakeInt64(int64(val)), nil
+		}
+		return starlark.Float(val), nil
+	case []interface{}:
+		items := make([]starlark.Value, len(val))
+		for i, item := range val {
+			converted, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return starlark.NewList(items), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(val))
+		for _, k := range keys {
+			converted, err := toStarlark(val[k])
+			if err != nil {
+				return nil, err
+			}
+			dict.SetKey(starlark.String(k), converted)
+		}
+		return dict, nil
+	}
+
+	// 其他类型（如结构体）先经过 JSON 转换
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return toStarlark(decoded)
+}
+
+func fromStarlark(v starlark.Value) (interface{}, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		if n, ok := val.Int64(); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("integer %s out of range", val)
+	case starlark.Float:
+		return float64(val), nil
+	case starlark.Indexable: // list、tuple
+		items := make([]interface{}, val.Len())
+		for i := range items {
+			item, err := fromStarlark(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case *starlark.Dict:
+		result := make(map[string]interface{}, val.Len())
+		for _, item := range val.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key must be string, got %s", item[0].Type())
+			}
+			converted, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			result[string(key)] = converted
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %s", v.Type())
+}
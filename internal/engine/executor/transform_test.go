@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+)
+
+func TestTransformExecutor(t *testing.T) {
+	input := map[string]interface{}{"amount": 12.34, "currency": "CNY"}
+	outputs := map[string]interface{}{
+		"query": map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"sku": "A1", "qty": 2.0},
+			map[string]interface{}{"sku": "B2", "qty": 0.0},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		want     map[string]interface{}
+		category apperrors.Category
+	}{
+		{
+			name:   "expression",
+			config: `{"expression": "{'cents': int(math.round(input['amount'] * 100)), 'currency': input['currency']}"}`,
+			want:   map[string]interface{}{"cents": int64(1234), "currency": "CNY"},
+		},
+		{
+			name:   "non dict result",
+			config: `{"expression": "len(outputs['query']['items'])"}`,
+			want:   map[string]interface{}{"result": int64(2)},
+		},
+		{
+			name: "script",
+			config: `{"script": "skus = []\nfor item in outputs['query']['items']:\n    if item['qty'] > 0:\n        skus.append(item['sku'])\n` +
+				`output = {'skus': skus, 'payload': json.decode(json.encode({'n': len(skus)}))}"}`,
+			want: map[string]interface{}{"skus": []interface{}{"A1"}, "payload": map[string]interface{}{"n": int64(1)}},
+		},
+		{
+			name:     "script without output",
+			config:   `{"script": "x = 1"}`,
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "syntax error",
+			config:   `{"expression": "input["}`,
+			category: apperrors.CategoryConfig,
+		},
+		{
+			name:     "runtime error",
+			config:   `{"expression": "input['missing']"}`,
+			category: apperrors.CategoryValidation,
+		},
+		{
+			name:     "step limit",
+			config:   `{"script": "n = 0\nwhile True:\n    n += 1\noutput = n", "max_steps": 10000}`,
+			category: apperrors.CategoryValidation,
+		},
+		{
+			name:     "no load",
+			config:   `{"script": "load('os.star', 'system')\noutput = 1"}`,
+			category: apperrors.CategoryValidation,
+		},
+	}
+
+	e := NewTransformExecutor(&config.TransformConfig{Timeout: 500})
+	ctx := WithOutputs(context.Background(), outputs)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := e.Execute(ctx, []byte(tt.config), input)
+			if tt.category != 0 {
+				var typed *apperrors.Error
+				if !errors.As(err, &typed) || typed.Category != tt.category {
+					t.Fatalf("Execute() error = %v, want category %s", err, tt.category)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !reflect.DeepEqual(output, tt.want) {
+				t.Errorf("Execute() = %v, want %v", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformTimeout(t *testing.T) {
+	e := NewTransformExecutor(&config.TransformConfig{Timeout: 50, MaxSteps: 1 << 40})
+	_, err := e.Execute(context.Background(), []byte(`{"script": "while True:\n    pass\noutput = 1"}`), nil)
+	var typed *apperrors.Error
+	if !errors.As(err, &typed) || typed.Code != apperrors.CodeTransformFailed {
+		t.Fatalf("Execute() error = %v, want transform failed", err)
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"fmt"
+
+	"dist_task/internal/model"
+	"dist_task/pkg/taskdef"
+)
+
+func (d *FlowDefinition) Validate() error {
+	tasks := make(map[string]*FlowTask, len(d.Tasks))
+	for i := range d.Tasks {
+		t := &d.Tasks[i]
+		if t.ID == "" {
+			return fmt.Errorf("flow %s: task id is required", d.Name)
+		}
+		if _, ok := tasks[t.ID]; ok {
+			return fmt.Errorf("flow %s: duplicate task id %s", d.Name, t.ID)
+		}
+		tasks[t.ID] = t
+	}
+
+	for _, t := range d.Tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := tasks[dep]; !ok {
+				return fmt.Errorf("flow %s: task %s depends on unknown task %s", d.Name, t.ID, dep)
+			}
+		}
+	}
+
+	// 0: 未访问，1: 访问中，2: 已完成
+	visited := make(map[string]int, len(tasks))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch visited[id] {
+		case 1:
+			return fmt.Errorf("flow %s: dependency cycle detected at task %s", d.Name, id)
+		case 2:
+			return nil
+		}
+		visited[id] = 1
+		for _, dep := range tasks[id].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visited[id] = 2
+		return nil
+	}
+
+	for _, t := range d.Tasks {
+		if err := visit(t.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TaskTypes 返回流程（含补偿任务）用到的执行器类型，未注册的任务名原样返回
+func (d *FlowDefinition) TaskTypes() []string {
+	seen := make(map[string]bool)
+	var types []string
+	add := func(taskName string) {
+		taskType := taskName
+		if def, _ := taskdef.GetTaskDefinition(taskName); def != nil {
+			taskType = def.Type
+		}
+		if !seen[taskType] {
+			seen[taskType] = true
+			types = append(types, taskType)
+		}
+	}
+
+	for _, t := range d.Tasks {
+		add(t.TaskName)
+		if t.Compensate != nil {
+			add(t.Compensate.TaskName)
+		}
+	}
+	return types
+}
+
+type flowState struct {
+	ready   []*FlowTask
+	failed  *model.DistTask
+	waiting bool
+	done    bool
+}
+
+// nextTasks 根据已持久化的任务记录计算下一批可执行的任务
+func (d *FlowDefinition) nextTasks(records map[string]*model.DistTask) flowState {
+	state := flowState{done: true}
+
+	for i := range d.Tasks {
+		t := &d.Tasks[i]
+
+		// pending 为重试时重置的记录，与未执行的任务同样处理
+		if record, ok := records[t.ID]; ok && record.Status != "pending" {
+			switch record.Status {
+			case "success":
+				continue
+			case "failed":
+				state.failed = record
+				state.done = false
+				return state
+			default:
+				state.done = false
+				if record.Status == "waiting" {
+					state.waiting = true
+				}
+				continue
+			}
+		}
+
+		state.done = false
+		if dependenciesMet(t, records) {
+			state.ready = append(state.ready, t)
+		}
+	}
+
+	return state
+}
+
+func (d *FlowDefinition) task(id string) *FlowTask {
+	for i := range d.Tasks {
+		if d.Tasks[i].ID == id {
+			return &d.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// downstream 返回 taskID 及所有直接、间接依赖它的任务，按定义顺序排列
+func (d *FlowDefinition) downstream(taskID string) []string {
+	affected := map[string]bool{taskID: true}
+	// 任务定义已通过 Validate 校验无环，反复扫描直到不再扩展即可
+	for changed := true; changed; {
+		changed = false
+		for _, t := range d.Tasks {
+			if affected[t.ID] {
+				continue
+			}
+			for _, dep := range t.DependsOn {
+				if affected[dep] {
+					affected[t.ID] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var ids []string
+	for _, t := range d.Tasks {
+		if affected[t.ID] {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+func dependenciesMet(t *FlowTask, records map[string]*model.DistTask) bool {
+	for _, dep := range t.DependsOn {
+		record, ok := records[dep]
+		if !ok || record.Status != "success" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"testing"
+
+	"dist_task/internal/model"
+)
+
+func TestFlowDefinition_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []FlowTask
+		wantErr bool
+	}{
+		{"valid", []FlowTask{
+			{ID: "freeze"},
+			{ID: "approve", DependsOn: []string{"freeze"}},
+			{ID: "transfer", DependsOn: []string{"approve"}},
+		}, false},
+		{"duplicate id", []FlowTask{{ID: "a"}, {ID: "a"}}, true},
+		{"unknown dependency", []FlowTask{{ID: "a", DependsOn: []string{"b"}}}, true},
+		{"cycle", []FlowTask{
+			{ID: "a", DependsOn: []string{"b"}},
+			{ID: "b", DependsOn: []string{"a"}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := &FlowDefinition{Name: "test", Tasks: tt.tasks}
+			if err := def.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlowDefinition_NextTasks(t *testing.T) {
+	def := &FlowDefinition{Tasks: []FlowTask{
+		{ID: "freeze"},
+		{ID: "approve", DependsOn: []string{"freeze"}},
+		{ID: "transfer", DependsOn: []string{"approve"}},
+	}}
+
+	tests := []struct {
+		name        string
+		records     map[string]*model.DistTask
+		wantReady   []string
+		wantWaiting bool
+		wantDone    bool
+		wantFailed  bool
+	}{
+		{"fresh", map[string]*model.DistTask{}, []string{"freeze"}, false, false, false},
+		{"waiting", map[string]*model.DistTask{
+			"freeze":  {Status: "success"},
+			"approve": {Status: "waiting"},
+		}, nil, true, false, false},
+		{"approved", map[string]*model.DistTask{
+			"freeze":  {Status: "success"},
+			"approve": {Status: "success"},
+		}, []string{"transfer"}, false, false, false},
+		{"rejected", map[string]*model.DistTask{
+			"freeze":  {Status: "success"},
+			"approve": {Status: "failed"},
+		}, nil, false, false, true},
+		{"reset for retry", map[string]*model.DistTask{
+			"freeze":  {Status: "success"},
+			"approve": {Status: "pending"},
+		}, []string{"approve"}, false, false, false},
+		{"done", map[string]*model.DistTask{
+			"freeze":   {Status: "success"},
+			"approve":  {Status: "success"},
+			"transfer": {Status: "success"},
+		}, nil, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := def.nextTasks(tt.records)
+
+			var ready []string
+			for _, task := range state.ready {
+				ready = append(ready, task.ID)
+			}
+			if len(ready) != len(tt.wantReady) {
+				t.Fatalf("nextTasks() ready = %v, expected %v", ready, tt.wantReady)
+			}
+			for i := range ready {
+				if ready[i] != tt.wantReady[i] {
+					t.Errorf("nextTasks() ready = %v, expected %v", ready, tt.wantReady)
+				}
+			}
+			if state.waiting != tt.wantWaiting {
+				t.Errorf("nextTasks() waiting = %v, expected %v", state.waiting, tt.wantWaiting)
+			}
+			if state.done != tt.wantDone {
+				t.Errorf("nextTasks() done = %v, expected %v", state.done, tt.wantDone)
+			}
+			if (state.failed != nil) != tt.wantFailed {
+				t.Errorf("nextTasks() failed = %v, expected %v", state.failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestFlowDefinition_Downstream(t *testing.T) {
+	def := &FlowDefinition{Tasks: []FlowTask{
+		{ID: "freeze"},
+		{ID: "notify"},
+		{ID: "approve", DependsOn: []string{"freeze"}},
+		{ID: "transfer", DependsOn: []string{"approve", "notify"}},
+	}}
+
+	tests := []struct {
+		name   string
+		taskID string
+		want   []string
+	}{
+		{"root", "freeze", []string{"freeze", "approve", "transfer"}},
+		{"branch", "notify", []string{"notify", "transfer"}},
+		{"leaf", "transfer", []string{"transfer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := def.downstream(tt.taskID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("downstream() = %v, expected %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("downstream() = %v, expected %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
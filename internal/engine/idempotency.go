@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"fmt"
+
+	"dist_task/internal/model"
+	"dist_task/pkg/taskdef"
+)
+
+// idempotencyKey 按任务定义中的格式生成幂等键，除 params / input 外还可引用：
+//
+//	${instance_id}  实例 ID
+//	${task_id}      Flow 中的任务 ID
+//	${tenant_id}    租户 ID
+//
+// 敏感入参以脱敏值参与解析，避免明文出现在任务记录中
+func idempotencyKey(taskDef *taskdef.TaskDefinition, instance *model.TaskGroupInstance, taskID string, input, params map[string]interface{}) string {
+	format := taskDef.IdempotencyKey
+	if format == "" {
+		format = taskdef.DefaultIdempotencyKey
+	}
+
+	scope := newScope(taskdef.Mask(taskDef.InputFields, input), params, nil)
+	scope["instance_id"] = instance.ID
+	scope["task_id"] = taskID
+	scope["tenant_id"] = instance.TenantID
+
+	key := resolveString(format, scope)
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"dist_task/internal/model"
+)
+
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// 占位符作用域：
+//
+//	${input.xxx}          当前任务校验后的入参
+//	${params.xxx}         启动事务时传入的全局参数
+//	${outputs.task.xxx}   上游任务的输出
+func newScope(input, params, outputs map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"input":   input,
+		"params":  params,
+		"outputs": outputs,
+	}
+}
+
+// collectOutputs 汇总已成功任务的输出，key 为 Flow 中的任务 ID
+func collectOutputs(records map[string]*model.DistTask) map[string]interface{} {
+	outputs := make(map[string]interface{}, len(records))
+	for key, record := range records {
+		if record.Status != "success" || record.OutputData == "" {
+			continue
+		}
+		var output interface{}
+		if err := json.Unmarshal([]byte(record.OutputData), &output); err == nil {
+			outputs[key] = output
+		}
+	}
+	return outputs
+}
+
+func lookupPath(scope map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = scope
+	for _, part := range strings.Split(strings.TrimSpace(path), ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// resolveString 解析字符串中的占位符；整个字符串恰为一个占位符时保留原始类型
+func resolveString(s string, scope map[string]interface{}) interface{} {
+	if m := placeholderPattern.FindStringSubmatch(s); m != nil && m[0] == s {
+		if value, ok := lookupPath(scope, m[1]); ok {
+			return value
+		}
+		return s
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		value, ok := lookupPath(scope, match[2:len(match)-1])
+		if !ok {
+			return match
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		data, _ := json.Marshal(value)
+		return string(data)
+	})
+}
+
+// resolveValue 递归解析 map / slice / string 中的占位符
+func resolveValue(v interface{}, scope map[string]interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return resolveString(val, scope)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			result[k] = resolveValue(item, scope)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = resolveValue(item, scope)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func resolveConfig(config []byte, scope map[string]interface{}) ([]byte, error) {
+	if len(config) == 0 {
+		return config, nil
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return nil, fmt.Errorf("parse config failed: %w", err)
+	}
+
+	return json.Marshal(resolveValue(raw, scope))
+}
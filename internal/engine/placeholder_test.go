@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"dist_task/internal/model"
+	"dist_task/pkg/taskdef"
+)
+
+func TestResolveValue(t *testing.T) {
+	scope := newScope(
+		map[string]interface{}{"order_id": "o_001"},
+		map[string]interface{}{"deduct": map[string]interface{}{"amount": 100.0}},
+		map[string]interface{}{"approve": map[string]interface{}{"payload": map[string]interface{}{"confirm_at": "2024-01-31 10:00:00"}}},
+	)
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected interface{}
+	}{
+		{"input", "${input.order_id}", "o_001"},
+		{"keep type", "${params.deduct.amount}", 100.0},
+		{"outputs", "${outputs.approve.payload.confirm_at}", "2024-01-31 10:00:00"},
+		{"embedded", "order-${input.order_id}-${params.deduct.amount}", "order-o_001-100"},
+		{"missing", "${outputs.unknown.field}", "${outputs.unknown.field}"},
+		{"nested", map[string]interface{}{
+			"where": map[string]interface{}{"order_id": "${input.order_id}"},
+			"list":  []interface{}{"${params.deduct.amount}", 1.0},
+		}, map[string]interface{}{
+			"where": map[string]interface{}{"order_id": "o_001"},
+			"list":  []interface{}{100.0, 1.0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := resolveValue(tt.input, scope)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("resolveValue() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDelayConfig_FireAt(t *testing.T) {
+	start := parseTimeOrFatal(t, "2024-01-31 10:00:00")
+
+	tests := []struct {
+		name     string
+		cfg      DelayConfig
+		expected string
+		wantErr  bool
+	}{
+		{"duration", DelayConfig{Duration: "24h"}, "2024-02-01 10:00:00", false},
+		{"until", DelayConfig{Until: "2024-02-02 08:30:00"}, "2024-02-02 08:30:00", false},
+		{"until wins", DelayConfig{Duration: "1h", Until: "2024-02-02 08:30:00"}, "2024-02-02 08:30:00", false},
+		{"invalid duration", DelayConfig{Duration: "tomorrow"}, "", true},
+		{"empty", DelayConfig{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.cfg.fireAt(start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fireAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if expected := parseTimeOrFatal(t, tt.expected); !result.Equal(expected) {
+				t.Errorf("fireAt() = %v, expected %v", result, expected)
+			}
+		})
+	}
+}
+
+func parseTimeOrFatal(t *testing.T, value string) time.Time {
+	t.Helper()
+	result, err := parseFireTime(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	instance := &model.TaskGroupInstance{ID: "inst_1", TenantID: "t1"}
+	fields := []taskdef.Field{
+		{Name: "order_id", Type: "string"},
+		{Name: "card_no", Type: "string", Sensitive: true},
+	}
+	input := map[string]interface{}{"order_id": "o_001", "card_no": "6222000011112222"}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{"default", "", "inst_1:deduct"},
+		{"input and tenant", "${tenant_id}:${input.order_id}:${task_id}", "t1:o_001:deduct"},
+		{"sensitive masked", "${input.card_no}", taskdef.MaskedValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := &taskdef.TaskDefinition{InputFields: fields, IdempotencyKey: tt.format}
+			if got := idempotencyKey(def, instance, "deduct", input, nil); got != tt.expected {
+				t.Errorf("idempotencyKey() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
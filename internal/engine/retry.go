@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+// Retry 从失败处继续执行已失败的实例：成功任务沿用已持久化的输出，只重跑失败和未执行的任务。
+// fromTask 非空时回退到该任务，它及所有下游任务都会重新执行
+func (e *Engine) Retry(instanceID, fromTask, operator string) error {
+	instance, flow, _, err := e.loadInstance(instanceID)
+	if err != nil {
+		return err
+	}
+	if instance.Status != "failed" {
+		return fmt.Errorf("%w: instance is %s", apperrors.ErrInvalidStatus, instance.Status)
+	}
+
+	var flowDefinition FlowDefinition
+	if err := json.Unmarshal([]byte(flow.Definition), &flowDefinition); err != nil {
+		return fmt.Errorf("parse flow definition failed: %w", err)
+	}
+
+	var keys []string
+	if fromTask != "" {
+		if flowDefinition.task(fromTask) == nil {
+			return fmt.Errorf("%w: unknown task %s", apperrors.ErrInvalidArgument, fromTask)
+		}
+		keys = flowDefinition.downstream(fromTask)
+	} else {
+		records, err := e.loadTaskRecords(instanceID)
+		if err != nil {
+			return err
+		}
+		for key, record := range records {
+			switch record.Status {
+			case "failed", "cancelled", "running":
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	if len(keys) > 0 {
+		if _, err := e.taskRepo.ResetForRetry(instanceID, keys); err != nil {
+			return err
+		}
+	}
+
+	ok, err := e.instanceRepo.UpdateStatusIf(instanceID, []string{"failed"}, map[string]interface{}{
+		"status":       "running",
+		"completed_at": nil,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: instance is no longer failed", apperrors.ErrInvalidStatus)
+	}
+
+	message := fmt.Sprintf("instance retried by %s", operator)
+	if fromTask != "" {
+		message = fmt.Sprintf("instance retried from %s by %s", fromTask, operator)
+	}
+	e.logInstance(instanceID, "retry", operator, message)
+	logger.Info().Str("instance_id", instanceID).Str("from_task", fromTask).Msg("instance retried")
+
+	return e.wake(instanceID)
+}
+
+// RetryTask 由重试调度器调用，在原任务记录上重跑单个失败任务，成功后继续推进实例
+func (e *Engine) RetryTask(ctx context.Context, instance *model.TaskGroupInstance, taskKey string) error {
+	_, flow, params, err := e.loadInstance(instance.ID)
+	if err != nil {
+		return err
+	}
+
+	var flowDefinition FlowDefinition
+	if err := json.Unmarshal([]byte(flow.Definition), &flowDefinition); err != nil {
+		return fmt.Errorf("parse flow definition failed: %w", err)
+	}
+
+	task := flowDefinition.task(taskKey)
+	if task == nil {
+		return fmt.Errorf("%w: unknown task %s", apperrors.ErrInvalidArgument, taskKey)
+	}
+
+	records, err := e.loadTaskRecords(instance.ID)
+	if err != nil {
+		return err
+	}
+	record, ok := records[taskKey]
+	if !ok {
+		return apperrors.ErrTaskNotFound
+	}
+	switch record.Status {
+	case "success":
+		// 已被手动重试等方式处理
+		return nil
+	case "failed":
+	default:
+		return fmt.Errorf("%w: task is %s", apperrors.ErrInvalidStatus, record.Status)
+	}
+
+	if err := e.executeTask(ctx, instance, task, params, collectOutputs(records), false); err != nil {
+		return err
+	}
+
+	ok, err = e.instanceRepo.UpdateStatusIf(instance.ID, []string{"failed"}, map[string]interface{}{
+		"status":       "running",
+		"completed_at": nil,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	return e.wake(instance.ID)
+}
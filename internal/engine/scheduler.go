@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
+	"dist_task/internal/config"
 	"dist_task/internal/engine/executor"
 	"dist_task/internal/model"
+	"dist_task/internal/ratelimit"
 	"dist_task/internal/repository"
+	apperrors "dist_task/pkg/errors"
 	"dist_task/pkg/logger"
+	"dist_task/pkg/secret"
 	"dist_task/pkg/taskdef"
 )
 
@@ -22,6 +25,12 @@ type FlowTask struct {
 	DependsOn   []string        `json:"depends_on"`
 	Config      json.RawMessage `json:"config"`
 	Retry       *RetryConfig    `json:"retry,omitempty"`
+	Compensate  *CompensateTask `json:"compensate,omitempty"`
+}
+
+type CompensateTask struct {
+	TaskName string          `json:"task_name"`
+	Config   json.RawMessage `json:"config"`
 }
 
 type RetryConfig struct {
@@ -36,27 +45,73 @@ type FlowDefinition struct {
 	Tasks       []FlowTask `json:"tasks"`
 }
 
+type WaitConfig struct {
+	Timeout   int    `json:"timeout,omitempty"`    // 等待超时（秒），0 表示一直等待
+	OnTimeout string `json:"on_timeout,omitempty"` // 超时后的默认决策：approve / reject
+}
+
+const (
+	SignalApprove = "approve"
+	SignalReject  = "reject"
+)
+
+type Signal struct {
+	Decision string                 `json:"decision"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	Operator string                 `json:"operator,omitempty"`
+}
+
+type execution struct {
+	wakeup     bool
+	paused     bool
+	cancelled  bool
+	compensate bool
+	operator   string
+	cancel     context.CancelFunc
+}
+
 type Engine struct {
+	flowRepo        *repository.FlowRepository
 	instanceRepo    *repository.InstanceRepository
 	taskRepo        *repository.TaskRepository
 	exceptionRepo   *repository.ExceptionRepository
 	logRepo         *repository.LogRepository
 	executorFactory *executor.ExecutorFactory
+	cipher          *secret.Cipher
+	limiter         *ratelimit.Limiter
+	workerTaskRepo  *repository.WorkerTaskRepository
+	workerConfig    *config.WorkerConfig
+
+	mu          sync.Mutex
+	executions  map[string]*execution
+	workerReady chan struct{} // 派发 worker 任务时关闭并替换，唤醒本节点上的长轮询
 }
 
 func NewEngine(
+	flowRepo *repository.FlowRepository,
 	instanceRepo *repository.InstanceRepository,
 	taskRepo *repository.TaskRepository,
 	exceptionRepo *repository.ExceptionRepository,
 	logRepo *repository.LogRepository,
 	executorFactory *executor.ExecutorFactory,
+	cipher *secret.Cipher,
+	limiter *ratelimit.Limiter,
+	workerTaskRepo *repository.WorkerTaskRepository,
+	workerConfig *config.WorkerConfig,
 ) *Engine {
 	return &Engine{
+		flowRepo:        flowRepo,
 		instanceRepo:    instanceRepo,
 		taskRepo:        taskRepo,
 		exceptionRepo:   exceptionRepo,
 		logRepo:         logRepo,
 		executorFactory: executorFactory,
+		cipher:          cipher,
+		limiter:         limiter,
+		workerTaskRepo:  workerTaskRepo,
+		workerConfig:    workerConfig,
+		executions:      make(map[string]*execution),
+		workerReady:     make(chan struct{}),
 	}
 }
 
@@ -66,45 +121,302 @@ func (e *Engine) Execute(ctx context.Context, instance *model.TaskGroupInstance,
 		return fmt.Errorf("parse flow definition failed: %w", err)
 	}
 
+	if err := flowDefinition.Validate(); err != nil {
+		instance.Status = "failed"
+		e.instanceRepo.Update(instance)
+		return err
+	}
+
+	if instance.Status == "paused" || instance.Status == "cancelled" {
+		logger.Info().Str("instance_id", instance.ID).Str("status", instance.Status).Msg("instance not runnable, skip execute")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exec, ok := e.register(instance.ID, cancel)
+	if !ok {
+		// 已有执行中的循环，由其负责推进
+		logger.Debug().Str("instance_id", instance.ID).Msg("instance already running, wakeup requested")
+		return nil
+	}
+
 	instance.Status = "running"
 	if err := e.instanceRepo.Update(instance); err != nil {
+		e.unregister(instance.ID)
 		return err
 	}
 
-	taskMap := make(map[string]*FlowTask)
-	for i := range flowDefinition.Tasks {
-		taskMap[flowDefinition.Tasks[i].ID] = &flowDefinition.Tasks[i]
+	return e.run(ctx, exec, instance, &flowDefinition, globalParams)
+}
+
+// wake 在等待中的任务被唤醒后继续推进实例
+func (e *Engine) wake(instanceID string) error {
+	e.mu.Lock()
+	if exec, ok := e.executions[instanceID]; ok {
+		exec.wakeup = true
+		e.mu.Unlock()
+		return nil
+	}
+	e.mu.Unlock()
+
+	instance, flow, params, err := e.loadInstance(instanceID)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := e.Execute(context.Background(), instance, flow, params); err != nil {
+			logger.Error().Err(err).Str("instance_id", instanceID).Msg("resume failed")
+		}
+	}()
+
+	return nil
+}
+
+func (e *Engine) loadInstance(instanceID string) (*model.TaskGroupInstance, *model.TaskGroupFlow, map[string]interface{}, error) {
+	instance, err := e.instanceRepo.GetByID(instanceID)
+	if err != nil {
+		return nil, nil, nil, apperrors.ErrInstanceNotFound
+	}
+
+	flow, err := e.flowRepo.GetByID(instance.FlowID)
+	if err != nil {
+		return nil, nil, nil, apperrors.ErrFlowNotFound
+	}
+
+	params := make(map[string]interface{})
+	if instance.Params != "" {
+		if err := json.Unmarshal([]byte(instance.Params), &params); err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: %v", apperrors.ErrParseParamsFailed, err)
+		}
+	}
+
+	return instance, flow, params, nil
+}
+
+func (e *Engine) register(instanceID string, cancel context.CancelFunc) (*execution, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if exec, ok := e.executions[instanceID]; ok {
+		exec.wakeup = true
+		return nil, false
 	}
 
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(flowDefinition.Tasks))
+	exec := &execution{cancel: cancel}
+	e.executions[instanceID] = exec
+	return exec, true
+}
+
+func (e *Engine) unregister(instanceID string) {
+	e.mu.Lock()
+	delete(e.executions, instanceID)
+	e.mu.Unlock()
+}
 
-	for i := range flowDefinition.Tasks {
-		wg.Add(1)
-		go func(t FlowTask) {
-			defer wg.Done()
-			if err := e.executeTask(ctx, instance.ID, &t, taskMap, globalParams); err != nil {
-				errCh <- err
+// interrupted 返回执行期间收到的取消/暂停请求
+func (e *Engine) interrupted(exec *execution) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case exec.cancelled:
+		return "cancelled", true
+	case exec.paused:
+		return "paused", true
+	default:
+		return "", false
+	}
+}
+
+// finish 更新实例终态并注销执行；若等待期间收到唤醒请求则返回 false，由调用方继续推进
+func (e *Engine) finish(exec *execution, instance *model.TaskGroupInstance, status string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case status == "success":
+	case exec.cancelled:
+		status = "cancelled"
+	case exec.paused:
+		status = "paused"
+	case status == "waiting" && exec.wakeup:
+		exec.wakeup = false
+		return false
+	}
+	delete(e.executions, instance.ID)
+
+	instance.Status = status
+	if status == "success" || status == "failed" || status == "cancelled" {
+		now := time.Now()
+		instance.CompletedAt = &now
+	}
+	if err := e.instanceRepo.Update(instance); err != nil {
+		logger.Error().Err(err).Str("instance_id", instance.ID).Str("status", status).Msg("update instance status failed")
+	}
+	return true
+}
+
+func (e *Engine) run(ctx context.Context, exec *execution, instance *model.TaskGroupInstance, flowDefinition *FlowDefinition, globalParams map[string]interface{}) error {
+	for {
+		if status, ok := e.interrupted(exec); ok {
+			e.stop(exec, instance, flowDefinition, globalParams, status)
+			return nil
+		}
+
+		records, err := e.loadTaskRecords(instance.ID)
+		if err != nil {
+			e.finish(exec, instance, "failed")
+			return err
+		}
+
+		state := flowDefinition.nextTasks(records)
+
+		if state.failed != nil {
+			e.finish(exec, instance, "failed")
+			return fmt.Errorf("task %s failed: %s", state.failed.TaskKey, state.failed.ErrorMessage)
+		}
+
+		if len(state.ready) == 0 {
+			switch {
+			case state.done:
+				e.finish(exec, instance, "success")
+				return nil
+			case state.waiting:
+				if e.finish(exec, instance, "waiting") {
+					logger.Info().Str("instance_id", instance.ID).Msg("instance waiting for signal")
+					return nil
+				}
+				continue
+			default:
+				e.finish(exec, instance, "failed")
+				return fmt.Errorf("flow %s has no runnable task", flowDefinition.Name)
 			}
-		}(flowDefinition.Tasks[i])
+		}
+
+		outputs := collectOutputs(records)
+
+		var wg sync.WaitGroup
+		errCh := make(chan error, len(state.ready))
+
+		for _, t := range state.ready {
+			wg.Add(1)
+			go func(t *FlowTask) {
+				defer wg.Done()
+				if err := e.executeTask(ctx, instance, t, globalParams, outputs, true); err != nil {
+					errCh <- err
+				}
+			}(t)
+		}
+
+		wg.Wait()
+		close(errCh)
+
+		if status, ok := e.interrupted(exec); ok {
+			e.stop(exec, instance, flowDefinition, globalParams, status)
+			return nil
+		}
+
+		for err := range errCh {
+			e.finish(exec, instance, "failed")
+			return err
+		}
 	}
+}
 
-	wg.Wait()
-	close(errCh)
+// stop 在取消/暂停请求生效后结束执行循环，需要时触发补偿
+func (e *Engine) stop(exec *execution, instance *model.TaskGroupInstance, flowDefinition *FlowDefinition, globalParams map[string]interface{}, status string) {
+	e.finish(exec, instance, status)
+	logger.Info().Str("instance_id", instance.ID).Str("status", status).Msg("instance stopped")
 
-	for err := range errCh {
-		instance.Status = "failed"
-		e.instanceRepo.Update(instance)
+	if status == "cancelled" && exec.compensate {
+		e.compensate(instance, flowDefinition, globalParams, exec.operator)
+	}
+}
+
+func (e *Engine) loadTaskRecords(groupID string) (map[string]*model.DistTask, error) {
+	tasks, err := e.taskRepo.ListByGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]*model.DistTask, len(tasks))
+	for i := range tasks {
+		if tasks[i].TaskKey == "" {
+			continue
+		}
+		records[tasks[i].TaskKey] = &tasks[i]
+	}
+	return records, nil
+}
+
+func taskRecordID(groupID, taskKey string) string {
+	return fmt.Sprintf("%s_%s", groupID, taskKey)
+}
+
+// saveTaskStart 首次执行时创建任务记录，重跑时复用原记录并累加重试次数
+func (e *Engine) saveTaskStart(taskRecord *model.DistTask) error {
+	existing, err := e.taskRepo.GetByID(taskRecord.ID)
+	if err != nil {
+		return e.taskRepo.Create(taskRecord)
+	}
+
+	taskRecord.CreatedAt = existing.CreatedAt
+	// 限流挂起的任务尚未真正执行过，恢复后的首次执行不计为重试
+	if existing.StartedAt == nil {
+		taskRecord.RetryCount = existing.RetryCount
+		return e.taskRepo.Update(taskRecord)
+	}
+
+	taskRecord.RetryCount = existing.RetryCount + 1
+	if err := e.taskRepo.Update(taskRecord); err != nil {
 		return err
 	}
 
-	instance.Status = "success"
-	now := time.Now()
-	instance.CompletedAt = &now
-	return e.instanceRepo.Update(instance)
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "retry",
+		Message: fmt.Sprintf("retrying task %s, attempt %d", taskRecord.TaskKey, taskRecord.RetryCount),
+	})
+	return nil
+}
+
+// throttle 将任务置为 waiting 并设置 resume_at，等待令牌补充后重新调度
+func (e *Engine) throttle(taskRecord *model.DistTask, task *FlowTask, wait time.Duration) error {
+	resumeAt := time.Now().Add(wait)
+	existing, err := e.taskRepo.GetByID(taskRecord.ID)
+	if err != nil {
+		taskRecord.Status = "waiting"
+		taskRecord.StartedAt = nil
+		taskRecord.ResumeAt = &resumeAt
+		err = e.taskRepo.Create(taskRecord)
+	} else {
+		existing.Status = "waiting"
+		existing.ResumeAt = &resumeAt
+		err = e.taskRepo.Update(existing)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "throttle",
+		Message: fmt.Sprintf("task %s rate limited, resume at %s", task.TaskName, resumeAt.Format(time.RFC3339)),
+	})
+
+	logger.Info().Str("task_id", taskRecord.ID).Dur("wait", wait).Msg("task throttled")
+	return nil
 }
 
-func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask, taskMap map[string]*FlowTask, globalParams map[string]interface{}) error {
+// executeTask 执行单个任务；raiseException 为 false 时失败不再生成异常记录（由自动重试复用原异常）
+func (e *Engine) executeTask(ctx context.Context, instance *model.TaskGroupInstance, task *FlowTask, globalParams, outputs map[string]interface{}, raiseException bool) error {
+	groupID := instance.ID
+
 	taskDef, err := taskdef.GetTaskDefinition(task.TaskName)
 	if err != nil {
 		return err
@@ -116,8 +428,10 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 
 	now := time.Now()
 	taskRecord := &model.DistTask{
-		ID:        fmt.Sprintf("%s_%s", groupID, task.ID),
+		ID:        taskRecordID(groupID, task.ID),
+		TenantID:  instance.TenantID,
 		GroupID:   groupID,
+		TaskKey:   task.ID,
 		Name:      task.Description,
 		Type:      taskDef.Type,
 		Status:    "running",
@@ -126,7 +440,27 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 		Config:    string(task.Config),
 	}
 
-	if err := e.taskRepo.Create(taskRecord); err != nil {
+	switch taskDef.Type {
+	case "wait":
+		return e.startWait(taskRecord, task)
+	case "delay":
+		return e.startDelay(taskRecord, task, globalParams, outputs)
+	}
+
+	prepared, err := e.prepareTask(task, taskDef, globalParams, outputs)
+	if err == nil {
+		// 超出限流时挂起任务，不占用执行协程，到期后由定时调度器唤醒
+		target := executor.BreakerKey(taskDef.Type, prepared.config)
+		wait, limitErr := e.limiter.Acquire(ctx, task.TaskName, taskDef.RateLimit, target, instance.FlowID)
+		if limitErr != nil && ctx.Err() == nil {
+			logger.Warn().Err(limitErr).Str("task_id", taskRecord.ID).Msg("rate limit check failed, task not throttled")
+		}
+		if wait > 0 {
+			return e.throttle(taskRecord, task, wait)
+		}
+	}
+
+	if err := e.saveTaskStart(taskRecord); err != nil {
 		return err
 	}
 
@@ -139,7 +473,6 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 
 	logger.Info().Str("task_id", taskRecord.ID).Str("task_name", task.TaskName).Msg("task started")
 
-	taskParams, err := e.extractTaskParams(task.TaskName, globalParams)
 	if err != nil {
 		taskRecord.Status = "failed"
 		taskRecord.ErrorMessage = err.Error()
@@ -155,8 +488,24 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 		return err
 	}
 
-	taskConfig, _ := json.Marshal(taskDef.Config)
-	mergedConfig := e.mergeConfig(taskConfig, task.Config)
+	// 记录实际下发的入参和配置，便于排查失败原因
+	inputJSON, _ := json.Marshal(prepared.storedInput)
+	taskRecord.InputData = string(inputJSON)
+	taskRecord.Config = string(prepared.storedConfig)
+	taskRecord.IdempotencyKey = idempotencyKey(taskDef, instance, task.ID, prepared.input, globalParams)
+	e.taskRepo.Update(taskRecord)
+
+	// worker 任务交给外部进程执行，派发后挂起，由 worker 上报结果
+	if taskDef.Type == "worker" {
+		if err := e.dispatchWorker(taskRecord, prepared, taskDef); err != nil {
+			return e.failTask(instance, task, taskRecord, err, raiseException)
+		}
+		return nil
+	}
+
+	ctx = executor.WithIdempotencyKey(ctx, taskRecord.IdempotencyKey)
+	ctx = executor.WithTaskID(ctx, taskRecord.ID)
+	ctx = executor.WithOutputs(ctx, outputs)
 
 	taskExecutor, err := e.executorFactory.Create(taskDef.Type)
 	if err != nil {
@@ -166,54 +515,55 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 		return err
 	}
 
-	if err := taskExecutor.Execute(ctx, mergedConfig, taskParams); err != nil {
-		taskRecord.Status = "failed"
+	var output map[string]interface{}
+	var message *model.OutboxMessage
+	if stager, ok := taskExecutor.(executor.Stager); ok {
+		message, output, err = stager.Stage(ctx, prepared.config, prepared.input)
+	} else {
+		output, err = taskExecutor.Execute(ctx, prepared.config, prepared.input)
+	}
+	if err != nil && ctx.Err() == context.Canceled {
+		completedAt := time.Now()
+		taskRecord.Status = "cancelled"
 		taskRecord.ErrorMessage = err.Error()
+		taskRecord.CompletedAt = &completedAt
 		e.taskRepo.Update(taskRecord)
 
-		retryStrategy := "manual"
-		maxAttempts := 3
-		interval := 60
-
-		if task.Retry != nil {
-			retryStrategy = task.Retry.Strategy
-			if task.Retry.MaxAttempts > 0 {
-				maxAttempts = task.Retry.MaxAttempts
-			}
-			if task.Retry.Interval > 0 {
-				interval = task.Retry.Interval
-			}
-		}
-
-		nextAt := time.Now().Add(time.Duration(interval) * time.Second)
-		e.exceptionRepo.Create(&model.ExceptionRecord{
-			GroupID:       groupID,
-			GroupName:     task.Description,
-			TaskID:        taskRecord.ID,
-			TaskName:      task.TaskName,
-			ErrorType:     1,
-			ErrorMessage:  err.Error(),
-			RetryStrategy: retryStrategy,
-			RetryMax:      maxAttempts,
-			RetryInterval: interval,
-			RetryNextAt:   &nextAt,
-			OccurredAt:    time.Now(),
-		})
-
 		e.logRepo.Create(&model.ExecutionLog{
 			TaskID:  taskRecord.ID,
 			GroupID: groupID,
-			Action:  "failed",
+			Action:  "cancel",
 			Message: err.Error(),
 		})
 
 		return err
 	}
+	if err != nil {
+		return e.failTask(instance, task, taskRecord, err, raiseException)
+	}
 
 	completedAt := time.Now()
 	taskRecord.Status = "success"
 	taskRecord.CompletedAt = &completedAt
-	e.taskRepo.Update(taskRecord)
+	if output != nil {
+		outputJSON, _ := json.Marshal(output)
+		taskRecord.OutputData = string(outputJSON)
+	}
+	if message != nil {
+		// outbox 模式：消息与任务状态同一事务写入，由 relay 投递
+		message.TenantID = instance.TenantID
+		message.TaskID = taskRecord.ID
+		message.GroupID = groupID
+		if err := e.taskRepo.CompleteWithOutbox(taskRecord, message); err != nil {
+			taskRecord.Status = "failed"
+			taskRecord.ErrorMessage = err.Error()
+			taskRecord.CompletedAt = nil
+			e.taskRepo.Update(taskRecord)
+			return err
+		}
+	} else {
+		e.taskRepo.Update(taskRecord)
+	}
 
 	e.logRepo.Create(&model.ExecutionLog{
 		TaskID:  taskRecord.ID,
@@ -227,20 +577,126 @@ func (e *Engine) executeTask(ctx context.Context, groupID string, task *FlowTask
 	return nil
 }
 
-func (e *Engine) extractTaskParams(taskName string, globalParams map[string]interface{}) (map[string]interface{}, error) {
-	validator := taskdef.NewValidator()
+// failTask 记录任务失败，raiseException 为 true 时按重试配置生成异常记录
+func (e *Engine) failTask(instance *model.TaskGroupInstance, task *FlowTask, taskRecord *model.DistTask, err error, raiseException bool) error {
+	taskRecord.Status = "failed"
+	taskRecord.ErrorMessage = err.Error()
+	e.taskRepo.Update(taskRecord)
 
-	taskDef, err := taskdef.GetTaskDefinition(taskName)
+	retryStrategy := "manual"
+	maxAttempts := 3
+	interval := 60
+
+	if task.Retry != nil {
+		retryStrategy = task.Retry.Strategy
+		if task.Retry.MaxAttempts > 0 {
+			maxAttempts = task.Retry.MaxAttempts
+		}
+		if task.Retry.Interval > 0 {
+			interval = task.Retry.Interval
+		}
+	}
+
+	// 不可重试的错误（如 4xx、配置错误）自动重试无意义，转为人工处理
+	classified := apperrors.Classify(err, apperrors.CategoryUnknown, apperrors.CodeUnknown, "")
+	if retryStrategy == "auto" && !classified.Retryable {
+		retryStrategy = "manual"
+	}
+	// worker 任务每次失败都会生成新的异常记录，按已执行次数限制自动重试
+	if retryStrategy == "auto" && taskRecord.Type == "worker" && taskRecord.RetryCount >= maxAttempts {
+		retryStrategy = "manual"
+	}
+
+	if raiseException {
+		nextAt := time.Now().Add(time.Duration(interval) * time.Second)
+		// 熔断中的下游至少等到冷却结束再重试
+		if retryAt := time.Now().Add(classified.RetryAfter); retryAt.After(nextAt) {
+			nextAt = retryAt
+		}
+		e.exceptionRepo.Create(&model.ExceptionRecord{
+			TenantID:      instance.TenantID,
+			GroupID:       taskRecord.GroupID,
+			GroupName:     task.Description,
+			TaskID:        taskRecord.ID,
+			TaskName:      task.TaskName,
+			ErrorType:     int(classified.Category),
+			ErrorCode:     classified.Code,
+			ErrorMessage:  err.Error(),
+			RetryStrategy: retryStrategy,
+			RetryMax:      maxAttempts,
+			RetryInterval: interval,
+			RetryNextAt:   &nextAt,
+			OccurredAt:    time.Now(),
+		})
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "failed",
+		Message: err.Error(),
+	})
+
+	return err
+}
+
+type preparedTask struct {
+	input        map[string]interface{} // 明文入参和配置，仅交给执行器
+	config       []byte
+	storedInput  map[string]interface{} // 敏感字段已加密或脱敏，用于持久化
+	storedConfig []byte
+}
+
+// prepareTask 提取并校验任务入参，合并任务配置，并解析其中的占位符
+func (e *Engine) prepareTask(task *FlowTask, taskDef *taskdef.TaskDefinition, globalParams, outputs map[string]interface{}) (*preparedTask, error) {
+	input, err := e.extractTaskParams(task.TaskName, globalParams, outputs)
 	if err != nil {
 		return nil, err
 	}
 
-	if taskDef == nil {
-		return globalParams, nil
+	// 未声明入参的任务以全部全局参数为入参，持久化时使用保持密文（或已脱敏）的全局参数
+	storedInput := e.storedParams(globalParams)
+	if len(taskDef.InputFields) > 0 {
+		if storedInput, err = e.sealInput(taskDef.InputFields, input); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(taskDef.InputFields) == 0 {
-		return globalParams, nil
+	taskConfig, _ := json.Marshal(taskDef.Config)
+	mergedConfig := e.mergeConfig(taskConfig, task.Config)
+
+	storedConfig, err := resolveConfig(mergedConfig, newScope(storedInput, e.storedParams(globalParams), outputs))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := resolveConfig(mergedConfig, newScope(input, globalParams, outputs))
+	if err != nil {
+		return nil, err
+	}
+	config, err = e.revealConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preparedTask{
+		input:        input,
+		config:       config,
+		storedInput:  storedInput,
+		storedConfig: storedConfig,
+	}, nil
+}
+
+func (e *Engine) extractTaskParams(taskName string, globalParams, outputs map[string]interface{}) (map[string]interface{}, error) {
+	validator := taskdef.NewValidator()
+
+	taskDef, err := taskdef.GetTaskDefinition(taskName)
+	if err != nil {
+		return nil, err
+	}
+
+	if taskDef == nil || len(taskDef.InputFields) == 0 {
+		return e.revealParams(globalParams)
 	}
 
 	taskParams, ok := globalParams[taskName].(map[string]interface{})
@@ -248,11 +704,17 @@ func (e *Engine) extractTaskParams(taskName string, globalParams map[string]inte
 		taskParams = make(map[string]interface{})
 	}
 
-	validatedParams, err := validator.Validate(taskDef.InputFields, taskParams)
+	// 入参中可引用全局参数和上游输出
+	resolved, err := e.revealParams(resolveValue(taskParams, newScope(nil, globalParams, outputs)).(map[string]interface{}))
 	if err != nil {
 		return nil, err
 	}
 
+	validatedParams, err := validator.Validate(taskDef.InputFields, resolved)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeValidationFailed, err, "")
+	}
+
 	return validatedParams, nil
 }
 
@@ -278,91 +740,3 @@ func (e *Engine) mergeConfig(baseConfig []byte, taskConfig json.RawMessage) []by
 	result, _ := json.Marshal(base)
 	return result
 }
-
-func resolvePlaceholder(config string, params map[string]interface{}) string {
-	for key, value := range params {
-		placeholder := fmt.Sprintf("${input.%s}", key)
-		config = strings.ReplaceAll(config, placeholder, fmt.Sprintf("%v", value))
-	}
-	return config
-}
-
-func (e *Engine) RetryTask(ctx context.Context, instance *model.TaskGroupInstance, flow *model.TaskGroupFlow, taskName string, taskConfig string) error {
-	taskDef, err := taskdef.GetTaskDefinition(taskName)
-	if err != nil {
-		return err
-	}
-
-	if taskDef == nil {
-		return fmt.Errorf("task definition not found: %s", taskName)
-	}
-
-	taskID := fmt.Sprintf("%s_retry_%d", instance.ID, time.Now().UnixNano())
-	now := time.Now()
-
-	taskRecord := &model.DistTask{
-		ID:         taskID,
-		GroupID:    instance.ID,
-		Name:       taskDef.Name,
-		Type:       taskDef.Type,
-		Status:     "running",
-		MaxRetry:   3,
-		RetryCount: 1,
-		StartedAt:  &now,
-		Config:     taskConfig,
-	}
-
-	if err := e.taskRepo.Create(taskRecord); err != nil {
-		return err
-	}
-
-	e.logRepo.Create(&model.ExecutionLog{
-		TaskID:  taskRecord.ID,
-		GroupID: instance.ID,
-		Action:  "retry",
-		Message: fmt.Sprintf("retrying task %s", taskName),
-	})
-
-	logger.Info().Str("task_id", taskRecord.ID).Str("task_name", taskName).Msg("task retry started")
-
-	taskExecutor, err := e.executorFactory.Create(taskDef.Type)
-	if err != nil {
-		taskRecord.Status = "failed"
-		taskRecord.ErrorMessage = err.Error()
-		e.taskRepo.Update(taskRecord)
-		return err
-	}
-
-	taskConfigBytes, _ := json.Marshal(taskDef.Config)
-
-	if err := taskExecutor.Execute(ctx, taskConfigBytes, map[string]interface{}{}); err != nil {
-		taskRecord.Status = "failed"
-		taskRecord.ErrorMessage = err.Error()
-		e.taskRepo.Update(taskRecord)
-
-		e.logRepo.Create(&model.ExecutionLog{
-			TaskID:  taskRecord.ID,
-			GroupID: instance.ID,
-			Action:  "failed",
-			Message: err.Error(),
-		})
-
-		return err
-	}
-
-	completedAt := time.Now()
-	taskRecord.Status = "success"
-	taskRecord.CompletedAt = &completedAt
-	e.taskRepo.Update(taskRecord)
-
-	e.logRepo.Create(&model.ExecutionLog{
-		TaskID:  taskRecord.ID,
-		GroupID: instance.ID,
-		Action:  "success",
-		Message: "retry completed",
-	})
-
-	logger.Info().Str("task_id", taskRecord.ID).Str("task_name", taskName).Msg("task retry completed")
-
-	return nil
-}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"dist_task/pkg/secret"
+	"dist_task/pkg/taskdef"
+)
+
+// 敏感字段在引擎内部及持久化时始终保持密文，只在交给执行器前解密。
+// 服务启动时要求声明了敏感字段的部署配置密钥；未配置密钥时（不含敏感字段）任务记录仍按 schema 脱敏
+
+// SealParams 加密启动参数中各任务 schema 标记为 sensitive 的字段
+func (e *Engine) SealParams(params map[string]interface{}) (map[string]interface{}, error) {
+	if e.cipher == nil {
+		return params, nil
+	}
+
+	sealed := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		sealed[key] = value
+
+		taskParams, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		taskDef, _ := taskdef.GetTaskDefinition(key)
+		if taskDef == nil {
+			continue
+		}
+
+		encrypted, err := e.sealFields(taskDef.InputFields, taskParams)
+		if err != nil {
+			return nil, err
+		}
+		sealed[key] = encrypted
+	}
+	return sealed, nil
+}
+
+// storedParams 返回解析持久化配置时使用的全局参数：配置了密钥时敏感字段已是密文，否则按 schema 脱敏
+func (e *Engine) storedParams(params map[string]interface{}) map[string]interface{} {
+	if e.cipher != nil {
+		return params
+	}
+
+	masked := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		masked[key] = value
+
+		taskParams, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if taskDef, _ := taskdef.GetTaskDefinition(key); taskDef != nil {
+			masked[key] = taskdef.Mask(taskDef.InputFields, taskParams)
+		}
+	}
+	return masked
+}
+
+// sealInput 返回可持久化的任务入参：配置了密钥时加密敏感字段，否则脱敏
+func (e *Engine) sealInput(fields []taskdef.Field, params map[string]interface{}) (map[string]interface{}, error) {
+	if e.cipher == nil {
+		return taskdef.Mask(fields, params), nil
+	}
+	return e.sealFields(fields, params)
+}
+
+func (e *Engine) sealFields(fields []taskdef.Field, params map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		result[k] = v
+	}
+
+	for _, field := range fields {
+		value, ok := result[field.Name]
+		if !ok || !field.Sensitive {
+			continue
+		}
+		if s, ok := value.(string); ok && secret.IsToken(s) {
+			continue
+		}
+
+		// 加密 JSON 编码后的值，解密时可还原原始类型
+		data, _ := json.Marshal(value)
+		token, err := e.cipher.Encrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt field %s failed: %w", field.Name, err)
+		}
+		result[field.Name] = token
+	}
+	return result, nil
+}
+
+// reveal 递归解密 map / slice / string 中的密文；整个字符串恰为密文时还原原始类型
+func (e *Engine) reveal(v interface{}) (interface{}, error) {
+	if e.cipher == nil {
+		return v, nil
+	}
+
+	switch val := v.(type) {
+	case string:
+		if secret.IsToken(val) {
+			return e.decryptValue(val)
+		}
+		var revealErr error
+		result := secret.ReplaceTokens(val, func(token string) string {
+			value, err := e.decryptValue(token)
+			if err != nil {
+				revealErr = err
+				return token
+			}
+			if s, ok := value.(string); ok {
+				return s
+			}
+			data, _ := json.Marshal(value)
+			return string(data)
+		})
+		return result, revealErr
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			revealed, err := e.reveal(item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = revealed
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			revealed, err := e.reveal(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = revealed
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *Engine) revealParams(params map[string]interface{}) (map[string]interface{}, error) {
+	revealed, err := e.reveal(params)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := revealed.(map[string]interface{})
+	return result, nil
+}
+
+func (e *Engine) revealConfig(config []byte) ([]byte, error) {
+	if e.cipher == nil || len(config) == 0 {
+		return config, nil
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return nil, fmt.Errorf("parse config failed: %w", err)
+	}
+
+	revealed, err := e.reveal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(revealed)
+}
+
+func (e *Engine) decryptValue(token string) (interface{}, error) {
+	data, err := e.cipher.Decrypt(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decode secret value failed: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"dist_task/pkg/taskdef"
+)
+
+func registerTaskDefinition(t *testing.T, name string, def taskdef.TaskDefinition) {
+	t.Helper()
+	taskdef.TaskDefinitions[name] = def
+	t.Cleanup(func() { delete(taskdef.TaskDefinitions, name) })
+}
+
+func TestPrepareTask_MasksStoredConfig(t *testing.T) {
+	registerTaskDefinition(t, "login", taskdef.TaskDefinition{
+		Type: "http",
+		InputFields: []taskdef.Field{
+			{Name: "user", Type: "string", Required: true},
+			{Name: "password", Type: "string", Required: true, Sensitive: true},
+		},
+	})
+
+	e := &Engine{}
+	task := &FlowTask{
+		ID:       "login",
+		TaskName: "login",
+		Config:   json.RawMessage(`{"url":"http://auth/login","body":{"user":"${input.user}","password":"${input.password}","raw":"${params.login.password}"}}`),
+	}
+	taskDef, _ := taskdef.GetTaskDefinition("login")
+	globalParams := map[string]interface{}{
+		"login": map[string]interface{}{"user": "alice", "password": "s3cret"},
+	}
+
+	prepared, err := e.prepareTask(task, taskDef, globalParams, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("prepareTask() error = %v", err)
+	}
+
+	var stored, config struct {
+		Body map[string]string `json:"body"`
+	}
+	json.Unmarshal(prepared.storedConfig, &stored)
+	json.Unmarshal(prepared.config, &config)
+
+	want := map[string]string{"user": "alice", "password": taskdef.MaskedValue, "raw": taskdef.MaskedValue}
+	for k, v := range want {
+		if stored.Body[k] != v {
+			t.Errorf("stored body[%s] = %q, want %q", k, stored.Body[k], v)
+		}
+	}
+	// 交给执行器的配置保持明文
+	if config.Body["password"] != "s3cret" || config.Body["raw"] != "s3cret" {
+		t.Errorf("executor config body = %v", config.Body)
+	}
+	if prepared.storedInput["password"] != taskdef.MaskedValue {
+		t.Errorf("stored input = %v", prepared.storedInput)
+	}
+}
+
+func TestPrepareTask_MasksStoredInputWithoutDeclaredFields(t *testing.T) {
+	registerTaskDefinition(t, "login", taskdef.TaskDefinition{
+		Type:        "http",
+		InputFields: []taskdef.Field{{Name: "password", Type: "string", Sensitive: true}},
+	})
+	registerTaskDefinition(t, "audit", taskdef.TaskDefinition{Type: "http"})
+
+	e := &Engine{}
+	task := &FlowTask{ID: "audit", TaskName: "audit", Config: json.RawMessage(`{"url":"http://audit","body":{"password":"${input.login.password}"}}`)}
+	taskDef, _ := taskdef.GetTaskDefinition("audit")
+	globalParams := map[string]interface{}{
+		"login": map[string]interface{}{"password": "s3cret"},
+	}
+
+	prepared, err := e.prepareTask(task, taskDef, globalParams, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("prepareTask() error = %v", err)
+	}
+
+	// 未声明入参时以全局参数为入参，持久化的入参同样按各任务 schema 脱敏
+	login, _ := prepared.storedInput["login"].(map[string]interface{})
+	if login["password"] != taskdef.MaskedValue {
+		t.Errorf("stored input = %v", prepared.storedInput)
+	}
+	var stored struct {
+		Body map[string]string `json:"body"`
+	}
+	json.Unmarshal(prepared.storedConfig, &stored)
+	if stored.Body["password"] != taskdef.MaskedValue {
+		t.Errorf("stored body = %v", stored.Body)
+	}
+	if login, _ := prepared.input["login"].(map[string]interface{}); login["password"] != "s3cret" {
+		t.Errorf("executor input = %v", prepared.input)
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+func (e *Engine) startWait(taskRecord *model.DistTask, task *FlowTask) error {
+	var cfg WaitConfig
+	if len(task.Config) > 0 {
+		if err := json.Unmarshal(task.Config, &cfg); err != nil {
+			return fmt.Errorf("parse wait config failed: %w", err)
+		}
+	}
+
+	if cfg.OnTimeout != "" && cfg.OnTimeout != SignalApprove && cfg.OnTimeout != SignalReject {
+		return fmt.Errorf("unsupported wait on_timeout: %s", cfg.OnTimeout)
+	}
+
+	taskRecord.Status = "waiting"
+	if cfg.Timeout > 0 {
+		resumeAt := taskRecord.StartedAt.Add(time.Duration(cfg.Timeout) * time.Second)
+		taskRecord.ResumeAt = &resumeAt
+	}
+
+	if err := e.saveTaskStart(taskRecord); err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "wait",
+		Message: fmt.Sprintf("task %s waiting for signal", task.ID),
+	})
+
+	logger.Info().Str("task_id", taskRecord.ID).Str("task_key", task.ID).Msg("task waiting for signal")
+
+	return nil
+}
+
+// Signal 对等待中的任务做出决策并继续推进实例
+func (e *Engine) Signal(instanceID, taskKey string, signal *Signal) error {
+	if signal.Decision != SignalApprove && signal.Decision != SignalReject {
+		return fmt.Errorf("%w: unsupported decision %s", apperrors.ErrInvalidArgument, signal.Decision)
+	}
+
+	taskRecord, err := e.taskRepo.GetByID(taskRecordID(instanceID, taskKey))
+	if err != nil {
+		return apperrors.ErrTaskNotFound
+	}
+
+	if taskRecord.Type != "wait" {
+		return fmt.Errorf("%w: task %s is not a wait task", apperrors.ErrInvalidArgument, taskKey)
+	}
+
+	return e.resolveWait(taskRecord, signal, "signal")
+}
+
+// expireWait 处理已超时的等待任务，按 on_timeout 配置给出默认决策
+func (e *Engine) expireWait(taskRecord *model.DistTask) error {
+	var cfg WaitConfig
+	if taskRecord.Config != "" {
+		json.Unmarshal([]byte(taskRecord.Config), &cfg)
+	}
+
+	decision := SignalReject
+	if cfg.OnTimeout == SignalApprove {
+		decision = SignalApprove
+	}
+
+	return e.resolveWait(taskRecord, &Signal{Decision: decision, Operator: "system"}, "timeout")
+}
+
+func (e *Engine) resolveWait(taskRecord *model.DistTask, signal *Signal, action string) error {
+	ok, err := e.taskRepo.CompareAndSwapStatus(taskRecord.ID, "waiting", "running")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrTaskNotWaiting
+	}
+
+	output, _ := json.Marshal(signal)
+	now := time.Now()
+	taskRecord.OutputData = string(output)
+	taskRecord.ResumeAt = nil
+	taskRecord.CompletedAt = &now
+
+	if signal.Decision == SignalApprove {
+		taskRecord.Status = "success"
+	} else {
+		taskRecord.Status = "failed"
+		taskRecord.ErrorMessage = fmt.Sprintf("rejected by %s", signal.Operator)
+	}
+
+	if err := e.taskRepo.Update(taskRecord); err != nil {
+		return err
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"decision": signal.Decision,
+		"operator": signal.Operator,
+	})
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  action,
+		Message: fmt.Sprintf("task %s resolved with %s", taskRecord.TaskKey, signal.Decision),
+		Details: string(details),
+	})
+
+	logger.Info().
+		Str("task_id", taskRecord.ID).
+		Str("decision", signal.Decision).
+		Str("operator", signal.Operator).
+		Msg("wait task resolved")
+
+	return e.wake(taskRecord.GroupID)
+}
@@ -0,0 +1,402 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+	"dist_task/pkg/taskdef"
+)
+
+const (
+	defaultWorkerStartToClose = time.Hour
+	defaultWorkerPollTimeout  = 30 * time.Second
+	// 其他节点派发的任务无法通过 workerReady 通知，长轮询期间定期重新查询
+	workerPollInterval = time.Second
+)
+
+// WorkerConfig worker 任务的配置，任务由注册了 task_type 的外部 worker 进程领取执行
+type WorkerConfig struct {
+	TaskType               string      `json:"task_type"`
+	Input                  interface{} `json:"input,omitempty"`                     // 交给 worker 的入参，支持占位符，默认为任务声明的入参
+	ScheduleToStartTimeout int         `json:"schedule_to_start_timeout,omitempty"` // 秒，派发后未在此时间内被领取则超时，0 表示不限
+	StartToCloseTimeout    int         `json:"start_to_close_timeout,omitempty"`    // 秒，领取后必须完成的时限，默认 [worker] start_to_close_timeout
+	HeartbeatTimeout       int         `json:"heartbeat_timeout,omitempty"`         // 秒，超过此时间未收到心跳视为 worker 失联，0 表示不要求心跳
+}
+
+// LeasedTask worker 领取到的任务，上报心跳和结果时需携带 lease_token
+type LeasedTask struct {
+	TaskID           string      `json:"task_id"`
+	LeaseToken       string      `json:"lease_token"`
+	TaskType         string      `json:"task_type"`
+	InstanceID       string      `json:"instance_id"`
+	TaskKey          string      `json:"task_key"`
+	Attempt          int         `json:"attempt"`
+	Input            interface{} `json:"input"`
+	IdempotencyKey   string      `json:"idempotency_key"`
+	HeartbeatTimeout int         `json:"heartbeat_timeout,omitempty"`
+	Deadline         time.Time   `json:"deadline"`
+}
+
+// WorkerFailure worker 上报的失败，默认可以按流程的重试配置自动重试
+type WorkerFailure struct {
+	Message      string `json:"message"`
+	Code         string `json:"code,omitempty"`
+	NonRetryable bool   `json:"non_retryable,omitempty"`
+}
+
+// dispatchWorker 将任务放入 worker 队列并挂起。入参取自持久化配置，敏感字段保持密文，领取时再解密；
+// 未配置 input 时只下发任务声明的入参，未声明入参时为空对象
+func (e *Engine) dispatchWorker(taskRecord *model.DistTask, prepared *preparedTask, taskDef *taskdef.TaskDefinition) error {
+	var cfg WorkerConfig
+	if err := json.Unmarshal(prepared.config, &cfg); err != nil {
+		return apperrors.Wrap(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, err, "parse worker config failed")
+	}
+	if cfg.TaskType == "" {
+		return apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "worker task requires task_type")
+	}
+
+	var stored WorkerConfig
+	json.Unmarshal(prepared.storedConfig, &stored)
+	var input interface{} = map[string]interface{}{}
+	switch {
+	case stored.Input != nil:
+		input = stored.Input
+	case len(taskDef.InputFields) > 0:
+		input = prepared.storedInput
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return apperrors.Wrap(apperrors.CategoryValidation, apperrors.CodeValidationFailed, err, "encode worker input failed")
+	}
+
+	startToClose := defaultWorkerStartToClose
+	if e.workerConfig != nil && e.workerConfig.StartToCloseTimeout > 0 {
+		startToClose = time.Duration(e.workerConfig.StartToCloseTimeout) * time.Second
+	}
+	if cfg.StartToCloseTimeout > 0 {
+		startToClose = time.Duration(cfg.StartToCloseTimeout) * time.Second
+	}
+
+	now := time.Now()
+	taskRecord.Status = "waiting"
+	taskRecord.ResumeAt = nil
+	if cfg.ScheduleToStartTimeout > 0 {
+		resumeAt := now.Add(time.Duration(cfg.ScheduleToStartTimeout) * time.Second)
+		taskRecord.ResumeAt = &resumeAt
+	}
+	// 先挂起任务再入队，避免 worker 领取时任务仍是 running
+	if err := e.taskRepo.Update(taskRecord); err != nil {
+		return err
+	}
+
+	err = e.workerTaskRepo.Enqueue(&model.WorkerTask{
+		ID:               taskRecord.ID,
+		TenantID:         taskRecord.TenantID,
+		GroupID:          taskRecord.GroupID,
+		TaskKey:          taskRecord.TaskKey,
+		TaskType:         cfg.TaskType,
+		Payload:          string(payload),
+		Status:           "queued",
+		Attempt:          taskRecord.RetryCount + 1,
+		HeartbeatTimeout: cfg.HeartbeatTimeout,
+		StartToClose:     int(startToClose / time.Second),
+		CreatedAt:        now,
+	})
+	if err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "dispatch",
+		Message: fmt.Sprintf("task %s dispatched to worker queue %s", taskRecord.TaskKey, cfg.TaskType),
+	})
+
+	logger.Info().Str("task_id", taskRecord.ID).Str("task_type", cfg.TaskType).Msg("task dispatched to worker")
+
+	e.notifyWorkers()
+	return nil
+}
+
+func (e *Engine) notifyWorkers() {
+	e.mu.Lock()
+	close(e.workerReady)
+	e.workerReady = make(chan struct{})
+	e.mu.Unlock()
+}
+
+func (e *Engine) workerReadyCh() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.workerReady
+}
+
+// PollWorkerTask 长轮询领取任务，wait 内没有可领取的任务时返回 nil
+func (e *Engine) PollWorkerTask(ctx context.Context, tenantID, workerID string, taskTypes []string, wait time.Duration) (*LeasedTask, error) {
+	maxWait := defaultWorkerPollTimeout
+	if e.workerConfig != nil && e.workerConfig.PollTimeout > 0 {
+		maxWait = time.Duration(e.workerConfig.PollTimeout) * time.Second
+	}
+	if wait <= 0 || wait > maxWait {
+		wait = maxWait
+	}
+	deadline := time.Now().Add(wait)
+
+	for {
+		// 先取通知 channel 再查询，避免错过查询期间派发的任务
+		ready := e.workerReadyCh()
+		task, err := e.LeaseWorkerTask(tenantID, workerID, taskTypes)
+		if err != nil || task != nil {
+			return task, err
+		}
+
+		remain := time.Until(deadline)
+		if remain <= 0 {
+			return nil, nil
+		}
+		if remain > workerPollInterval {
+			remain = workerPollInterval
+		}
+
+		timer := time.NewTimer(remain)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil
+		case <-ready:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// LeaseWorkerTask 领取一个任务，没有可领取的任务时返回 nil
+func (e *Engine) LeaseWorkerTask(tenantID, workerID string, taskTypes []string) (*LeasedTask, error) {
+	repo := e.workerTaskRepo.ForTenant(tenantID)
+	for {
+		now := time.Now()
+		wt, err := repo.Lease(taskTypes, workerID, newLeaseToken(), now)
+		if err != nil || wt == nil {
+			return nil, err
+		}
+
+		// 任务已被取消或重置时丢弃队列记录，继续领取下一个
+		ok, err := e.taskRepo.ExtendWaiting(wt.ID, workerExpiry(wt, now))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			e.workerTaskRepo.Delete(wt.ID)
+			continue
+		}
+
+		record, err := e.taskRepo.GetByID(wt.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		var input interface{}
+		json.Unmarshal([]byte(wt.Payload), &input)
+		if input, err = e.reveal(input); err != nil {
+			return nil, err
+		}
+
+		e.logRepo.Create(&model.ExecutionLog{
+			TaskID:  wt.ID,
+			GroupID: wt.GroupID,
+			Action:  "lease",
+			Message: fmt.Sprintf("task %s leased by worker %s, attempt %d", wt.TaskKey, workerID, wt.Attempt),
+		})
+
+		logger.Info().Str("task_id", wt.ID).Str("worker_id", workerID).Msg("worker task leased")
+
+		return &LeasedTask{
+			TaskID:           wt.ID,
+			LeaseToken:       wt.LeaseToken,
+			TaskType:         wt.TaskType,
+			InstanceID:       wt.GroupID,
+			TaskKey:          wt.TaskKey,
+			Attempt:          wt.Attempt,
+			Input:            input,
+			IdempotencyKey:   record.IdempotencyKey,
+			HeartbeatTimeout: wt.HeartbeatTimeout,
+			Deadline:         *wt.DeadlineAt,
+		}, nil
+	}
+}
+
+// HeartbeatWorkerTask 延长租约；任务已被取消时返回 true，worker 应停止执行
+func (e *Engine) HeartbeatWorkerTask(tenantID, taskID, token string, details json.RawMessage) (bool, error) {
+	wt, err := e.leasedWorkerTask(tenantID, taskID, token)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	ok, err := e.taskRepo.ExtendWaiting(taskID, workerExpiry(wt, now))
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		e.workerTaskRepo.Delete(taskID)
+		return true, nil
+	}
+
+	return false, e.workerTaskRepo.Heartbeat(taskID, token, string(details), now)
+}
+
+// CompleteWorkerTask 记录 worker 的执行结果并继续推进实例
+func (e *Engine) CompleteWorkerTask(tenantID, taskID, token string, output map[string]interface{}) error {
+	if _, err := e.leasedWorkerTask(tenantID, taskID, token); err != nil {
+		return err
+	}
+	taskRecord, err := e.claimWorkerTask(taskID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	taskRecord.Status = "success"
+	taskRecord.ResumeAt = nil
+	taskRecord.CompletedAt = &now
+	if output != nil {
+		outputJSON, _ := json.Marshal(output)
+		taskRecord.OutputData = string(outputJSON)
+	}
+	if err := e.taskRepo.Update(taskRecord); err != nil {
+		return err
+	}
+
+	e.logRepo.Create(&model.ExecutionLog{
+		TaskID:  taskRecord.ID,
+		GroupID: taskRecord.GroupID,
+		Action:  "success",
+		Message: "task completed by worker",
+	})
+
+	logger.Info().Str("task_id", taskRecord.ID).Msg("worker task completed")
+
+	return e.wake(taskRecord.GroupID)
+}
+
+// FailWorkerTask 记录 worker 上报的失败，按流程的重试配置生成异常记录
+func (e *Engine) FailWorkerTask(tenantID, taskID, token string, failure *WorkerFailure) error {
+	if _, err := e.leasedWorkerTask(tenantID, taskID, token); err != nil {
+		return err
+	}
+	taskRecord, err := e.claimWorkerTask(taskID)
+	if err != nil {
+		return err
+	}
+
+	code := failure.Code
+	if code == "" {
+		code = apperrors.CodeWorkerFailed
+	}
+	typed := apperrors.New(apperrors.CategoryUnknown, code, failure.Message)
+	typed.Retryable = !failure.NonRetryable
+
+	return e.failWorkerTask(taskRecord, typed)
+}
+
+// expireWorkerTask 处理超时的 worker 任务：未及时领取、心跳超时或执行超时
+func (e *Engine) expireWorkerTask(taskRecord *model.DistTask) error {
+	// 心跳可能在扫描后延长了租约
+	current, err := e.taskRepo.GetByID(taskRecord.ID)
+	if err != nil {
+		return apperrors.ErrTaskNotFound
+	}
+	if current.ResumeAt == nil || current.ResumeAt.After(time.Now()) {
+		return apperrors.ErrTaskNotWaiting
+	}
+
+	reason := "schedule_to_start"
+	if wt, err := e.workerTaskRepo.GetByID(taskRecord.ID); err == nil && wt.Status == "leased" {
+		reason = "heartbeat"
+		if wt.DeadlineAt != nil && !wt.DeadlineAt.After(time.Now()) {
+			reason = "start_to_close"
+		}
+	}
+
+	claimed, err := e.claimWorkerTask(taskRecord.ID)
+	if err != nil {
+		return err
+	}
+
+	return e.failWorkerTask(claimed, apperrors.Newf(apperrors.CategoryTimeout, apperrors.CodeTimeout, "worker task %s timeout", reason))
+}
+
+func (e *Engine) failWorkerTask(taskRecord *model.DistTask, err error) error {
+	instance, flowDefinition, _, loadErr := e.loadInstance(taskRecord.GroupID)
+	if loadErr != nil {
+		return loadErr
+	}
+	var definition FlowDefinition
+	if loadErr := json.Unmarshal([]byte(flowDefinition.Definition), &definition); loadErr != nil {
+		return fmt.Errorf("parse flow definition failed: %w", loadErr)
+	}
+	task := definition.task(taskRecord.TaskKey)
+	if task == nil {
+		return fmt.Errorf("%w: unknown task %s", apperrors.ErrInvalidArgument, taskRecord.TaskKey)
+	}
+
+	taskRecord.ResumeAt = nil
+	e.failTask(instance, task, taskRecord, err, true)
+
+	logger.Warn().Err(err).Str("task_id", taskRecord.ID).Msg("worker task failed")
+
+	return e.wake(taskRecord.GroupID)
+}
+
+// leasedWorkerTask 校验租约，任务被重新派发或已结束后旧的 lease_token 失效
+func (e *Engine) leasedWorkerTask(tenantID, taskID, token string) (*model.WorkerTask, error) {
+	wt, err := e.workerTaskRepo.ForTenant(tenantID).GetByID(taskID)
+	if err != nil {
+		return nil, apperrors.ErrTaskNotFound
+	}
+	if wt.Status != "leased" || wt.LeaseToken != token {
+		return nil, apperrors.ErrLeaseLost
+	}
+	return wt, nil
+}
+
+// claimWorkerTask 将等待中的任务置为 running 并移出队列，与超时、取消互斥
+func (e *Engine) claimWorkerTask(taskID string) (*model.DistTask, error) {
+	ok, err := e.taskRepo.CompareAndSwapStatus(taskID, "waiting", "running")
+	if err != nil {
+		return nil, err
+	}
+	e.workerTaskRepo.Delete(taskID)
+	if !ok {
+		return nil, apperrors.ErrTaskNotWaiting
+	}
+	return e.taskRepo.GetByID(taskID)
+}
+
+// workerExpiry 租约到期时间：要求心跳时为下一次心跳的截止时间，但不超过执行时限
+func workerExpiry(wt *model.WorkerTask, now time.Time) time.Time {
+	deadline := now.Add(time.Duration(wt.StartToClose) * time.Second)
+	if wt.DeadlineAt != nil {
+		deadline = *wt.DeadlineAt
+	}
+	if wt.HeartbeatTimeout > 0 {
+		if next := now.Add(time.Duration(wt.HeartbeatTimeout) * time.Second); next.Before(deadline) {
+			return next
+		}
+	}
+	return deadline
+}
+
+func newLeaseToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,347 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"dist_task/internal/model"
+	apperrors "dist_task/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+func TestWorkerExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deadline := now.Add(10 * time.Minute)
+
+	tests := []struct {
+		name string
+		task model.WorkerTask
+		want time.Time
+	}{
+		{
+			name: "no heartbeat",
+			task: model.WorkerTask{StartToClose: 600, DeadlineAt: &deadline},
+			want: deadline,
+		},
+		{
+			name: "heartbeat before deadline",
+			task: model.WorkerTask{StartToClose: 600, HeartbeatTimeout: 30, DeadlineAt: &deadline},
+			want: now.Add(30 * time.Second),
+		},
+		{
+			name: "heartbeat capped by deadline",
+			task: model.WorkerTask{StartToClose: 600, HeartbeatTimeout: 900, DeadlineAt: &deadline},
+			want: deadline,
+		},
+		{
+			name: "deadline not set",
+			task: model.WorkerTask{StartToClose: 60},
+			want: now.Add(time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workerExpiry(&tt.task, now); !got.Equal(tt.want) {
+				t.Errorf("workerExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func workerTask(id, taskType string, config map[string]interface{}) FlowTask {
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	config["task_type"] = taskType
+	raw, _ := json.Marshal(config)
+	return FlowTask{ID: id, TaskName: "worker", Config: raw}
+}
+
+// startWorkerInstance 执行实例直到 worker 任务入队
+func startWorkerInstance(t *testing.T, e *Engine, conn *gorm.DB, tasks []FlowTask) {
+	t.Helper()
+	instance, flow := createInstance(t, conn, "inst", "pending", tasks)
+	if err := e.Execute(context.Background(), instance, flow, map[string]interface{}{"sku": "A1"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+}
+
+func leaseTask(t *testing.T, e *Engine, taskType string) *LeasedTask {
+	t.Helper()
+	leased, err := e.PollWorkerTask(context.Background(), "default", "w1", []string{taskType}, 2*time.Second)
+	if err != nil || leased == nil {
+		t.Fatalf("PollWorkerTask() = %v, %v", leased, err)
+	}
+	return leased
+}
+
+func TestWorker_LeaseAndComplete(t *testing.T) {
+	e, conn := newTestEngine(t)
+	startWorkerInstance(t, e, conn, []FlowTask{
+		workerTask("reserve", "inventory", map[string]interface{}{"input": map[string]interface{}{"sku": "${params.sku}"}}),
+		{ID: "ship", TaskName: "worker", DependsOn: []string{"reserve"}, Config: json.RawMessage(`{"task_type":"shipping"}`)},
+	})
+
+	if task := getTask(t, conn, "inst_reserve"); task.Status != "waiting" {
+		t.Fatalf("task status = %s, want waiting", task.Status)
+	}
+
+	// 只领取注册的任务类型
+	if leased, err := e.LeaseWorkerTask("default", "w1", []string{"shipping"}); err != nil || leased != nil {
+		t.Fatalf("LeaseWorkerTask(shipping) = %v, %v", leased, err)
+	}
+	// 其他租户看不到该任务
+	if leased, err := e.LeaseWorkerTask("other", "w1", []string{"inventory"}); err != nil || leased != nil {
+		t.Fatalf("LeaseWorkerTask(other tenant) = %v, %v", leased, err)
+	}
+
+	leased := leaseTask(t, e, "inventory")
+	if leased.TaskID != "inst_reserve" || leased.InstanceID != "inst" || leased.Attempt != 1 || leased.LeaseToken == "" {
+		t.Errorf("leased = %+v", leased)
+	}
+	if input, _ := leased.Input.(map[string]interface{}); input["sku"] != "A1" {
+		t.Errorf("input = %v, want sku A1", leased.Input)
+	}
+
+	// 已领取的任务不会被再次领取
+	if again, err := e.LeaseWorkerTask("default", "w2", []string{"inventory"}); err != nil || again != nil {
+		t.Fatalf("LeaseWorkerTask() after lease = %v, %v", again, err)
+	}
+
+	if err := e.CompleteWorkerTask("default", leased.TaskID, "stale", nil); !errors.Is(err, apperrors.ErrLeaseLost) {
+		t.Errorf("CompleteWorkerTask() with wrong token error = %v, want ErrLeaseLost", err)
+	}
+	if err := e.CompleteWorkerTask("default", leased.TaskID, leased.LeaseToken, map[string]interface{}{"reserved": true}); err != nil {
+		t.Fatalf("CompleteWorkerTask() error = %v", err)
+	}
+	if task := getTask(t, conn, "inst_reserve"); task.Status != "success" || task.OutputData != `{"reserved":true}` {
+		t.Errorf("task = %s, output = %s", task.Status, task.OutputData)
+	}
+	// 完成后旧租约失效
+	if err := e.CompleteWorkerTask("default", leased.TaskID, leased.LeaseToken, nil); err == nil {
+		t.Error("CompleteWorkerTask() twice succeeded")
+	}
+
+	// 实例继续推进到下游 worker 任务，未配置 input 且未声明入参时下发空对象
+	ship := leaseTask(t, e, "shipping")
+	if input, ok := ship.Input.(map[string]interface{}); !ok || len(input) != 0 {
+		t.Errorf("input = %v, want empty object", ship.Input)
+	}
+	if err := e.CompleteWorkerTask("default", ship.TaskID, ship.LeaseToken, nil); err != nil {
+		t.Fatalf("CompleteWorkerTask() error = %v", err)
+	}
+	waitFor(t, "instance success", func() bool {
+		return getInstance(t, conn, "inst").Status == "success"
+	})
+}
+
+func TestWorker_Heartbeat(t *testing.T) {
+	e, conn := newTestEngine(t)
+	startWorkerInstance(t, e, conn, []FlowTask{
+		workerTask("reserve", "inventory", map[string]interface{}{"heartbeat_timeout": 30, "start_to_close_timeout": 600}),
+	})
+
+	before := time.Now()
+	leased := leaseTask(t, e, "inventory")
+	first := getTask(t, conn, "inst_reserve").ResumeAt
+	if first == nil || first.Before(before.Add(30*time.Second)) || first.After(time.Now().Add(30*time.Second)) {
+		t.Fatalf("resume_at after lease = %v, want now+30s", first)
+	}
+	if !leased.Deadline.After(before.Add(599 * time.Second)) {
+		t.Errorf("deadline = %v, want now+600s", leased.Deadline)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	cancelled, err := e.HeartbeatWorkerTask("default", leased.TaskID, leased.LeaseToken, json.RawMessage(`{"progress":50}`))
+	if err != nil || cancelled {
+		t.Fatalf("HeartbeatWorkerTask() = %v, %v", cancelled, err)
+	}
+	if extended := getTask(t, conn, "inst_reserve").ResumeAt; extended == nil || !extended.After(*first) {
+		t.Errorf("resume_at after heartbeat = %v, want after %v", extended, first)
+	}
+	var wt model.WorkerTask
+	conn.First(&wt, "id = ?", leased.TaskID)
+	if wt.HeartbeatDetails != `{"progress":50}` {
+		t.Errorf("heartbeat_details = %s", wt.HeartbeatDetails)
+	}
+
+	if _, err := e.HeartbeatWorkerTask("default", leased.TaskID, "stale", nil); !errors.Is(err, apperrors.ErrLeaseLost) {
+		t.Errorf("HeartbeatWorkerTask() with wrong token error = %v, want ErrLeaseLost", err)
+	}
+
+	// 实例取消后心跳通知 worker 停止执行
+	if err := e.Cancel("inst", "alice", false); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	cancelled, err = e.HeartbeatWorkerTask("default", leased.TaskID, leased.LeaseToken, nil)
+	if err != nil || !cancelled {
+		t.Errorf("HeartbeatWorkerTask() after cancel = %v, %v, want cancel requested", cancelled, err)
+	}
+}
+
+func TestWorker_Fail(t *testing.T) {
+	tests := []struct {
+		name         string
+		retry        *RetryConfig
+		failure      WorkerFailure
+		wantCode     string
+		wantStrategy string
+	}{
+		{
+			name:         "default code",
+			failure:      WorkerFailure{Message: "out of stock"},
+			wantCode:     apperrors.CodeWorkerFailed,
+			wantStrategy: "manual",
+		},
+		{
+			name:         "non retryable",
+			retry:        &RetryConfig{Strategy: "auto", MaxAttempts: 3, Interval: 60},
+			failure:      WorkerFailure{Message: "invalid sku", Code: "INVALID_SKU", NonRetryable: true},
+			wantCode:     "INVALID_SKU",
+			wantStrategy: "manual",
+		},
+		{
+			name:         "retryable",
+			retry:        &RetryConfig{Strategy: "auto", MaxAttempts: 3, Interval: 60},
+			failure:      WorkerFailure{Message: "db busy"},
+			wantCode:     apperrors.CodeWorkerFailed,
+			wantStrategy: "auto",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, conn := newTestEngine(t)
+			task := workerTask("reserve", "inventory", nil)
+			task.Retry = tt.retry
+			startWorkerInstance(t, e, conn, []FlowTask{task})
+
+			leased := leaseTask(t, e, "inventory")
+			if err := e.FailWorkerTask("default", leased.TaskID, leased.LeaseToken, &tt.failure); err != nil {
+				t.Fatalf("FailWorkerTask() error = %v", err)
+			}
+
+			if task := getTask(t, conn, "inst_reserve"); task.Status != "failed" || task.ErrorMessage != tt.failure.Message {
+				t.Errorf("task = %s, error = %q", task.Status, task.ErrorMessage)
+			}
+			var ex model.ExceptionRecord
+			if err := conn.First(&ex, "task_id = ?", "inst_reserve").Error; err != nil {
+				t.Fatalf("exception not created: %v", err)
+			}
+			if ex.ErrorCode != tt.wantCode || ex.RetryStrategy != tt.wantStrategy {
+				t.Errorf("exception code/strategy = %s/%s, want %s/%s", ex.ErrorCode, ex.RetryStrategy, tt.wantCode, tt.wantStrategy)
+			}
+			waitFor(t, "instance failed", func() bool {
+				return getInstance(t, conn, "inst").Status == "failed"
+			})
+
+			var queued int64
+			conn.Model(&model.WorkerTask{}).Count(&queued)
+			if queued != 0 {
+				t.Errorf("%d worker tasks left in queue", queued)
+			}
+		})
+	}
+}
+
+func TestWorker_Timeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		lease   bool
+		past    bool // 执行时限已过
+		wantMsg string
+	}{
+		{name: "schedule to start", config: map[string]interface{}{"schedule_to_start_timeout": 60}, wantMsg: "worker task schedule_to_start timeout"},
+		{name: "heartbeat", config: map[string]interface{}{"heartbeat_timeout": 30}, lease: true, wantMsg: "worker task heartbeat timeout"},
+		{name: "start to close", config: map[string]interface{}{"start_to_close_timeout": 60}, lease: true, past: true, wantMsg: "worker task start_to_close timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, conn := newTestEngine(t)
+			startWorkerInstance(t, e, conn, []FlowTask{workerTask("reserve", "inventory", tt.config)})
+
+			var leased *LeasedTask
+			if tt.lease {
+				leased = leaseTask(t, e, "inventory")
+			}
+
+			// 未到期时不处理
+			if err := e.Wake(getTask(t, conn, "inst_reserve")); !errors.Is(err, apperrors.ErrTaskNotWaiting) {
+				t.Fatalf("Wake() before expiry error = %v, want ErrTaskNotWaiting", err)
+			}
+
+			expired := time.Now().Add(-time.Second)
+			conn.Model(&model.DistTask{}).Where("id = ?", "inst_reserve").Update("resume_at", expired)
+			if tt.past {
+				conn.Model(&model.WorkerTask{}).Where("id = ?", "inst_reserve").Update("deadline_at", expired)
+			}
+			if err := e.Wake(getTask(t, conn, "inst_reserve")); err != nil {
+				t.Fatalf("Wake() error = %v", err)
+			}
+
+			task := getTask(t, conn, "inst_reserve")
+			if task.Status != "failed" || task.ErrorMessage != tt.wantMsg {
+				t.Errorf("task = %s, error = %q, want failed %q", task.Status, task.ErrorMessage, tt.wantMsg)
+			}
+			var ex model.ExceptionRecord
+			conn.First(&ex, "task_id = ?", "inst_reserve")
+			if ex.ErrorCode != apperrors.CodeTimeout {
+				t.Errorf("exception code = %s, want %s", ex.ErrorCode, apperrors.CodeTimeout)
+			}
+			waitFor(t, "instance failed", func() bool {
+				return getInstance(t, conn, "inst").Status == "failed"
+			})
+
+			// 超时后 worker 再上报结果被拒绝
+			if leased != nil {
+				if err := e.CompleteWorkerTask("default", leased.TaskID, leased.LeaseToken, nil); err == nil {
+					t.Error("CompleteWorkerTask() after timeout succeeded")
+				}
+			}
+		})
+	}
+}
+
+// 重新派发后旧租约失效，只有新的 lease_token 可以上报
+func TestWorker_StaleLeaseAfterRetry(t *testing.T) {
+	e, conn := newTestEngine(t)
+	startWorkerInstance(t, e, conn, []FlowTask{workerTask("reserve", "inventory", map[string]interface{}{"heartbeat_timeout": 30})})
+
+	stale := leaseTask(t, e, "inventory")
+	expired := time.Now().Add(-time.Second)
+	conn.Model(&model.DistTask{}).Where("id = ?", "inst_reserve").Update("resume_at", expired)
+	if err := e.Wake(getTask(t, conn, "inst_reserve")); err != nil {
+		t.Fatalf("Wake() error = %v", err)
+	}
+	waitFor(t, "instance failed", func() bool {
+		return getInstance(t, conn, "inst").Status == "failed"
+	})
+
+	if err := e.RetryTask(context.Background(), getInstance(t, conn, "inst"), "reserve"); err != nil {
+		t.Fatalf("RetryTask() error = %v", err)
+	}
+	fresh := leaseTask(t, e, "inventory")
+	if fresh.LeaseToken == stale.LeaseToken || fresh.Attempt != 2 {
+		t.Errorf("fresh lease = %+v", fresh)
+	}
+
+	if _, err := e.HeartbeatWorkerTask("default", stale.TaskID, stale.LeaseToken, nil); !errors.Is(err, apperrors.ErrLeaseLost) {
+		t.Errorf("HeartbeatWorkerTask() with stale token error = %v, want ErrLeaseLost", err)
+	}
+	if err := e.CompleteWorkerTask("default", stale.TaskID, stale.LeaseToken, nil); !errors.Is(err, apperrors.ErrLeaseLost) {
+		t.Errorf("CompleteWorkerTask() with stale token error = %v, want ErrLeaseLost", err)
+	}
+	if err := e.CompleteWorkerTask("default", fresh.TaskID, fresh.LeaseToken, nil); err != nil {
+		t.Fatalf("CompleteWorkerTask() error = %v", err)
+	}
+	waitFor(t, "instance success", func() bool {
+		return getInstance(t, conn, "inst").Status == "success"
+	})
+}
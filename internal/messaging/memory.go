@@ -0,0 +1,59 @@
+package messaging
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// MemoryBroker 内存 broker，用于测试和本地开发
+type MemoryBroker struct {
+	mu   sync.Mutex
+	sent []Message
+	err  error
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Send(ctx context.Context, msg *Message) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return "", b.err
+	}
+	b.sent = append(b.sent, *msg)
+	return "mem-" + strconv.Itoa(len(b.sent)), nil
+}
+
+// SendInTransaction 内存 broker 没有回查，消息直接可见
+func (b *MemoryBroker) SendInTransaction(ctx context.Context, msg *Message, taskID string) (string, error) {
+	return b.Send(ctx, msg)
+}
+
+// Fail 设置后 Send 返回该错误，传 nil 恢复，用于模拟 broker 故障
+func (b *MemoryBroker) Fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+// Messages 返回已发送的消息，topic 为空时返回全部
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []Message
+	for _, msg := range b.sent {
+		if topic == "" || msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
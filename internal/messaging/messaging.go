@@ -0,0 +1,135 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"dist_task/internal/config"
+)
+
+const (
+	BrokerRocketMQ = "rocketmq"
+	BrokerKafka    = "kafka"
+	BrokerNATS     = "nats"
+	BrokerMemory   = "memory"
+)
+
+// Message 与具体 broker 无关的消息，各实现按自身能力映射字段
+type Message struct {
+	Topic       string
+	Tags        string
+	Keys        []string
+	Properties  map[string]string
+	Body        []byte
+	ShardingKey string     // 分区 / 顺序键
+	DelayLevel  int        // 仅 RocketMQ 支持
+	DeliverAt   *time.Time // 定时投递
+}
+
+// Broker 消息发送方，返回 broker 生成的消息 ID
+type Broker interface {
+	Send(ctx context.Context, msg *Message) (string, error)
+	Close() error
+}
+
+// TxState 本地事务状态，事务消息回查时使用
+type TxState int
+
+const (
+	TxUnknown TxState = iota
+	TxCommit
+	TxRollback
+)
+
+// TransactionChecker 根据任务记录 ID 判断本地事务状态
+type TransactionChecker func(taskID string) TxState
+
+// TransactionalBroker 支持事务半消息的 broker
+type TransactionalBroker interface {
+	SendInTransaction(ctx context.Context, msg *Message, taskID string) (string, error)
+}
+
+// Registry 按名称管理已配置的 broker
+type Registry struct {
+	defaultBroker string
+	brokers       map[string]Broker
+}
+
+func NewRegistry(defaultBroker string) *Registry {
+	return &Registry{defaultBroker: defaultBroker, brokers: make(map[string]Broker)}
+}
+
+// NewRegistryFromConfig 按配置创建 broker，未配置连接地址的 broker 不会注册
+func NewRegistryFromConfig(cfg *config.Config, checker TransactionChecker) (*Registry, error) {
+	defaultBroker := cfg.Messaging.DefaultBroker
+	if defaultBroker == "" {
+		defaultBroker = BrokerRocketMQ
+	}
+	r := NewRegistry(defaultBroker)
+
+	if cfg.RocketMQ.NameServer != "" {
+		b, err := NewRocketMQBroker(&cfg.RocketMQ, checker)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(BrokerRocketMQ, b)
+	}
+	if len(cfg.Kafka.Brokers) > 0 {
+		b, err := NewKafkaBroker(&cfg.Kafka)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.Register(BrokerKafka, b)
+	}
+	if cfg.NATS.URL != "" {
+		b, err := NewNATSBroker(&cfg.NATS)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		r.Register(BrokerNATS, b)
+	}
+	if cfg.Messaging.Memory {
+		r.Register(BrokerMemory, NewMemoryBroker())
+	}
+
+	if _, ok := r.brokers[defaultBroker]; !ok {
+		r.Close()
+		return nil, fmt.Errorf("default broker %s is not configured", defaultBroker)
+	}
+	return r, nil
+}
+
+func (r *Registry) Register(name string, broker Broker) {
+	r.brokers[name] = broker
+}
+
+// Get 返回指定 broker，name 为空时返回默认 broker
+func (r *Registry) Get(name string) (Broker, error) {
+	if name == "" {
+		name = r.defaultBroker
+	}
+	broker, ok := r.brokers[name]
+	if !ok {
+		return nil, fmt.Errorf("broker %s is not configured", name)
+	}
+	return broker, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.brokers))
+	for name := range r.brokers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) Close() {
+	for _, broker := range r.brokers {
+		broker.Close()
+	}
+}
@@ -0,0 +1,197 @@
+package messaging
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestRegistry_Get(t *testing.T) {
+	r := NewRegistry(BrokerMemory)
+	r.Register(BrokerMemory, NewMemoryBroker())
+
+	if _, err := r.Get(""); err != nil {
+		t.Errorf("Get(\"\") error = %v, want default broker", err)
+	}
+	if _, err := r.Get(BrokerKafka); err == nil {
+		t.Errorf("Get(kafka) error = nil, want not configured")
+	}
+}
+
+func TestToPrimitive(t *testing.T) {
+	deliverAt := time.UnixMilli(1706695200000)
+	msg := toPrimitive(&Message{
+		Topic:       "payment.completed",
+		Tags:        "paid",
+		Keys:        []string{"inst_1:notify", "o_001"},
+		Properties:  map[string]string{"source": "dist_task"},
+		ShardingKey: "u_001",
+		DeliverAt:   &deliverAt,
+	})
+
+	expected := map[string]string{
+		primitive.PropertyTags:        "paid",
+		primitive.PropertyKeys:        "inst_1:notify o_001",
+		primitive.PropertyShardingKey: "u_001",
+		deliverTimeProperty:           "1706695200000",
+		"source":                      "dist_task",
+	}
+	for k, v := range expected {
+		if got := msg.GetProperty(k); got != v {
+			t.Errorf("property %s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestTransactionListener(t *testing.T) {
+	states := map[string]TxState{"done": TxCommit, "failed": TxRollback, "running": TxUnknown}
+	l := &transactionListener{checker: func(taskID string) TxState { return states[taskID] }}
+
+	tests := []struct {
+		taskID string
+		want   primitive.LocalTransactionState
+	}{
+		{"done", primitive.CommitMessageState},
+		{"failed", primitive.RollbackMessageState},
+		{"running", primitive.UnknowState},
+		{"", primitive.RollbackMessageState},
+	}
+
+	for _, tt := range tests {
+		msg := &primitive.MessageExt{}
+		if tt.taskID != "" {
+			msg.WithProperty(TaskIDProperty, tt.taskID)
+		}
+		if got := l.ExecuteLocalTransaction(&msg.Message); got != tt.want {
+			t.Errorf("ExecuteLocalTransaction(%q) = %v, want %v", tt.taskID, got, tt.want)
+		}
+		if got := l.CheckLocalTransaction(msg); got != tt.want {
+			t.Errorf("CheckLocalTransaction(%q) = %v, want %v", tt.taskID, got, tt.want)
+		}
+	}
+}
+
+func TestKafkaBroker_Send(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, "payment.completed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+
+	// 默认 acks=all 并启用幂等生产，发送前会申请生产者 ID
+	var mu sync.Mutex
+	var initProducerID int
+	var acks []int16
+	cluster.ControlKey(int16(kmsg.InitProducerID), func(kmsg.Request) (kmsg.Response, error, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		initProducerID++
+		return nil, nil, false
+	})
+	cluster.ControlKey(int16(kmsg.Produce), func(req kmsg.Request) (kmsg.Response, error, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		acks = append(acks, req.(*kmsg.ProduceRequest).Acks)
+		return nil, nil, false
+	})
+
+	broker, err := NewKafkaBroker(&config.KafkaConfig{Brokers: cluster.ListenAddrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 相同分区键的消息落在同一分区且按顺序写入
+	var ids []string
+	for _, orderID := range []string{"o_001", "o_002"} {
+		msgID, err := broker.Send(ctx, &Message{
+			Topic:       "payment.completed",
+			Keys:        []string{"inst_1:" + orderID},
+			ShardingKey: "u_001",
+			Body:        []byte(`{"order_id": "` + orderID + `"}`),
+		})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		ids = append(ids, msgID)
+	}
+	partition := strings.TrimSuffix(ids[0], "@0")
+	if partition == ids[0] || ids[1] != partition+"@1" {
+		t.Errorf("Send() ids = %v, want same partition with offsets 0 and 1", ids)
+	}
+
+	mu.Lock()
+	if initProducerID == 0 {
+		t.Errorf("producer did not init a producer id, idempotence disabled")
+	}
+	for _, a := range acks {
+		if a != -1 {
+			t.Errorf("produce acks = %d, want -1 (all)", a)
+		}
+	}
+	mu.Unlock()
+
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.ConsumeTopics("payment.completed"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for len(records) < 2 && ctx.Err() == nil {
+		records = append(records, consumer.PollFetches(ctx).Records()...)
+	}
+	if len(records) != 2 {
+		t.Fatalf("consumed %d records, want 2", len(records))
+	}
+	record := records[0]
+	if string(record.Key) != "u_001" || string(record.Value) != `{"order_id": "o_001"}` {
+		t.Errorf("record key = %s, value = %s", record.Key, record.Value)
+	}
+	if len(record.Headers) != 1 || record.Headers[0].Key != "keys" || string(record.Headers[0].Value) != "inst_1:o_001" {
+		t.Errorf("headers = %+v", record.Headers)
+	}
+
+	if _, err := broker.Send(ctx, &Message{Topic: "t", DelayLevel: 3}); err == nil {
+		t.Errorf("Send() with delay error = nil, want unsupported")
+	}
+}
+
+func TestKafkaOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.KafkaConfig
+		wantErr bool
+	}{
+		{name: "defaults", cfg: config.KafkaConfig{Brokers: []string{"localhost:9092"}}},
+		{name: "leader acks", cfg: config.KafkaConfig{Acks: "leader"}},
+		{name: "scram", cfg: config.KafkaConfig{SASLMechanism: "scram-sha-512", Username: "u", Password: "p", TLS: true}},
+		{name: "unknown acks", cfg: config.KafkaConfig{Acks: "2"}, wantErr: true},
+		{name: "unknown sasl", cfg: config.KafkaConfig{SASLMechanism: "GSSAPI"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := kafkaOptions(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("kafkaOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSBroker 以 Topic 为 subject 发布消息；开启 JetStream 时第一个消息 Key 作为 Nats-Msg-Id 去重
+type NATSBroker struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+func NewNATSBroker(cfg *config.NATSConfig) (*NATSBroker, error) {
+	opts := []nats.Option{nats.Name("dist_task")}
+	if cfg.Token != "" {
+		opts = append(opts, nats.Token(cfg.Token))
+	}
+	if cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, nats.Timeout(time.Duration(cfg.Timeout)*time.Second))
+	}
+
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect nats failed: %w", err)
+	}
+
+	b := &NATSBroker{conn: conn}
+	if cfg.JetStream {
+		js, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("create jetstream context failed: %w", err)
+		}
+		b.js = js
+	}
+	return b, nil
+}
+
+func (b *NATSBroker) Send(ctx context.Context, msg *Message) (string, error) {
+	if msg.DelayLevel > 0 || msg.DeliverAt != nil {
+		return "", apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "nats does not support delayed messages")
+	}
+
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Body
+	for k, v := range msg.Properties {
+		m.Header.Set(k, v)
+	}
+	if msg.Tags != "" {
+		m.Header.Set("Tags", msg.Tags)
+	}
+	if len(msg.Keys) > 0 {
+		m.Header.Set("Keys", strings.Join(msg.Keys, " "))
+	}
+	if msg.ShardingKey != "" {
+		m.Header.Set("Sharding-Key", msg.ShardingKey)
+	}
+
+	if b.js == nil {
+		if err := b.conn.PublishMsg(m); err != nil {
+			return "", apperrors.Classify(err, apperrors.CategoryBroker, apperrors.CodeBrokerError, "publish nats message failed")
+		}
+		if err := b.conn.FlushWithContext(ctx); err != nil {
+			return "", apperrors.Classify(err, apperrors.CategoryBroker, apperrors.CodeBrokerError, "flush nats connection failed")
+		}
+		return "", nil
+	}
+
+	if len(msg.Keys) > 0 {
+		m.Header.Set(nats.MsgIdHdr, msg.Keys[0])
+	}
+	ack, err := b.js.PublishMsg(m, nats.Context(ctx))
+	if err != nil {
+		return "", apperrors.Classify(err, apperrors.CategoryBroker, apperrors.CodeBrokerError, "publish jetstream message failed")
+	}
+	return ack.Stream + ":" + strconv.FormatUint(ack.Sequence, 10), nil
+}
+
+func (b *NATSBroker) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,155 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"dist_task/internal/config"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+
+	"github.com/apache/rocketmq-client-go/v2"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/apache/rocketmq-client-go/v2/producer"
+)
+
+// TaskIDProperty 事务消息中携带任务记录 ID 的属性名
+const TaskIDProperty = "dist_task_id"
+
+// RocketMQ 5.x 定时消息属性
+const deliverTimeProperty = "TIMER_DELIVER_MS"
+
+type RocketMQBroker struct {
+	producer   rocketmq.Producer
+	txProducer rocketmq.TransactionProducer // 未配置 transaction_group 时为 nil
+}
+
+func NewRocketMQBroker(cfg *config.RocketMQConfig, checker TransactionChecker) (*RocketMQBroker, error) {
+	p, err := rocketmq.NewProducer(
+		producer.WithNsResolver(primitive.NewPassthroughResolver([]string{cfg.NameServer})),
+		producer.WithRetry(2),
+		producer.WithQueueSelector(producer.NewHashQueueSelector()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create mq producer failed: %w", err)
+	}
+
+	if err := p.Start(); err != nil {
+		return nil, fmt.Errorf("start mq producer failed: %w", err)
+	}
+
+	b := &RocketMQBroker{producer: p}
+
+	if cfg.TransactionGroup != "" {
+		tp, err := rocketmq.NewTransactionProducer(
+			&transactionListener{checker: checker},
+			producer.WithNsResolver(primitive.NewPassthroughResolver([]string{cfg.NameServer})),
+			producer.WithGroupName(cfg.TransactionGroup),
+			producer.WithRetry(2),
+			producer.WithQueueSelector(producer.NewHashQueueSelector()),
+		)
+		if err != nil {
+			p.Shutdown()
+			return nil, fmt.Errorf("create mq transaction producer failed: %w", err)
+		}
+		if err := tp.Start(); err != nil {
+			p.Shutdown()
+			return nil, fmt.Errorf("start mq transaction producer failed: %w", err)
+		}
+		b.txProducer = tp
+	}
+
+	return b, nil
+}
+
+func (b *RocketMQBroker) Send(ctx context.Context, msg *Message) (string, error) {
+	result, err := b.producer.SendSync(ctx, toPrimitive(msg))
+	if err != nil {
+		return "", apperrors.Classify(err, apperrors.CategoryBroker, apperrors.CodeBrokerError, "send mq message failed")
+	}
+	return result.MsgID, nil
+}
+
+// SendInTransaction 发送事务半消息。本地事务即引擎提交任务状态，
+// 发送时状态未知，由 broker 回查 dist_task 决定提交或回滚
+func (b *RocketMQBroker) SendInTransaction(ctx context.Context, msg *Message, taskID string) (string, error) {
+	if b.txProducer == nil {
+		return "", apperrors.New(apperrors.CategoryConfig, apperrors.CodeConfigInvalid, "rocketmq transaction_group is not configured")
+	}
+
+	m := toPrimitive(msg)
+	m.WithProperty(TaskIDProperty, taskID)
+
+	result, err := b.txProducer.SendMessageInTransaction(ctx, m)
+	if err != nil {
+		return "", apperrors.Classify(err, apperrors.CategoryBroker, apperrors.CodeBrokerError, "send mq transaction message failed")
+	}
+	if result.Status != primitive.SendOK {
+		return "", apperrors.Newf(apperrors.CategoryBroker, apperrors.CodeBrokerError, "send mq transaction message failed with status %d", result.Status)
+	}
+	return result.MsgID, nil
+}
+
+func (b *RocketMQBroker) Close() error {
+	if b.txProducer != nil {
+		b.txProducer.Shutdown()
+	}
+	return b.producer.Shutdown()
+}
+
+func toPrimitive(msg *Message) *primitive.Message {
+	m := primitive.NewMessage(msg.Topic, msg.Body)
+	if len(msg.Properties) > 0 {
+		m.WithProperties(msg.Properties)
+	}
+	if msg.Tags != "" {
+		m.WithTag(msg.Tags)
+	}
+	if len(msg.Keys) > 0 {
+		m.WithKeys(msg.Keys)
+	}
+	if msg.ShardingKey != "" {
+		m.WithShardingKey(msg.ShardingKey)
+	}
+	if msg.DelayLevel > 0 {
+		m.WithDelayTimeLevel(msg.DelayLevel)
+	}
+	if msg.DeliverAt != nil {
+		m.WithProperty(deliverTimeProperty, strconv.FormatInt(msg.DeliverAt.UnixMilli(), 10))
+	}
+	return m
+}
+
+type transactionListener struct {
+	checker TransactionChecker
+}
+
+// ExecuteLocalTransaction 半消息发送成功时任务状态通常尚未提交，返回未知等待回查；
+// 本地状态已确定（如重发已结束任务的消息）时直接提交或回滚
+func (l *transactionListener) ExecuteLocalTransaction(msg *primitive.Message) primitive.LocalTransactionState {
+	return l.localState(msg)
+}
+
+func (l *transactionListener) CheckLocalTransaction(msg *primitive.MessageExt) primitive.LocalTransactionState {
+	return l.localState(&msg.Message)
+}
+
+func (l *transactionListener) localState(msg *primitive.Message) primitive.LocalTransactionState {
+	taskID := msg.GetProperty(TaskIDProperty)
+	if taskID == "" || l.checker == nil {
+		return primitive.RollbackMessageState
+	}
+
+	state := l.checker(taskID)
+	logger.Info().Str("task_id", taskID).Int("state", int(state)).Msg("mq transaction checked")
+
+	switch state {
+	case TxCommit:
+		return primitive.CommitMessageState
+	case TxRollback:
+		return primitive.RollbackMessageState
+	default:
+		return primitive.UnknowState
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 type TaskGroupFlow struct {
 	ID          string    `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	TenantID    string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
 	Name        string    `json:"name" gorm:"type:varchar(255);not null"`
 	Description string    `json:"description" gorm:"type:text"`
 	FlowType    string    `json:"flow_type" gorm:"type:varchar(50);not null"`
@@ -24,8 +25,10 @@ func (TaskGroupFlow) TableName() string {
 
 type TaskGroupInstance struct {
 	ID          string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	TenantID    string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
 	FlowID      string     `json:"flow_id" gorm:"type:varchar(64);not null"`
 	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:pending"`
+	Params      string     `json:"params" gorm:"type:json"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	CompletedAt *time.Time `json:"completed_at"`
@@ -36,20 +39,25 @@ func (TaskGroupInstance) TableName() string {
 }
 
 type DistTask struct {
-	ID           string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
-	GroupID      string     `json:"group_id" gorm:"type:varchar(64);not null"`
-	Name         string     `json:"name" gorm:"type:varchar(255);not null"`
-	Type         string     `json:"type" gorm:"type:varchar(20);not null"`
-	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:pending"`
-	MaxRetry     int        `json:"max_retry" gorm:"default:3"`
-	RetryCount   int        `json:"retry_count" gorm:"default:0"`
-	Config       string     `json:"config" gorm:"type:json"`
-	InputData    string     `json:"input_data" gorm:"type:json"`
-	OutputData   string     `json:"output_data" gorm:"type:json"`
-	ErrorMessage string     `json:"error_message" gorm:"type:text"`
-	ErrorStack   string     `json:"error_stack" gorm:"type:text"`
-	StartedAt    *time.Time `json:"started_at"`
-	CompletedAt  *time.Time `json:"completed_at"`
+	ID             string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	TenantID       string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
+	GroupID        string     `json:"group_id" gorm:"type:varchar(64);not null"`
+	TaskKey        string     `json:"task_key" gorm:"type:varchar(64);not null"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"type:varchar(255)"`
+	Name           string     `json:"name" gorm:"type:varchar(255);not null"`
+	Type           string     `json:"type" gorm:"type:varchar(20);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:pending"`
+	MaxRetry       int        `json:"max_retry" gorm:"default:3"`
+	RetryCount     int        `json:"retry_count" gorm:"default:0"`
+	Config         string     `json:"config" gorm:"type:json"`
+	InputData      string     `json:"input_data" gorm:"type:json"`
+	OutputData     string     `json:"output_data" gorm:"type:json"`
+	ErrorMessage   string     `json:"error_message" gorm:"type:text"`
+	ErrorStack     string     `json:"error_stack" gorm:"type:text"`
+	ResumeAt       *time.Time `json:"resume_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	StartedAt      *time.Time `json:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
 }
 
 func (DistTask) TableName() string {
@@ -58,6 +66,7 @@ func (DistTask) TableName() string {
 
 type ExceptionRecord struct {
 	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID      string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
 	GroupID       string     `json:"group_id" gorm:"type:varchar(64);not null"`
 	GroupName     string     `json:"group_name" gorm:"type:varchar(255);not null"`
 	TaskID        string     `json:"task_id" gorm:"type:varchar(64);not null"`
@@ -75,6 +84,7 @@ type ExceptionRecord struct {
 	HandledBy     string     `json:"handled_by" gorm:"type:varchar(100)"`
 	HandledAt     *time.Time `json:"handled_at"`
 	HandledRemark string     `json:"handled_remark" gorm:"type:text"`
+	Assignee      string     `json:"assignee" gorm:"type:varchar(100)"`
 	OccurredAt    time.Time  `json:"occurred_at"`
 }
 
@@ -95,3 +105,125 @@ type ExecutionLog struct {
 func (ExecutionLog) TableName() string {
 	return "execution_log"
 }
+
+type AuditLog struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID    string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
+	Actor       string    `json:"actor" gorm:"type:varchar(100);not null"`
+	Action      string    `json:"action" gorm:"type:varchar(50);not null"`
+	TargetType  string    `json:"target_type" gorm:"type:varchar(20);not null"` // flow / instance / exception / bulk_job
+	TargetID    string    `json:"target_id" gorm:"type:varchar(64);not null"`
+	FlowID      string    `json:"flow_id" gorm:"type:varchar(64)"`
+	InstanceID  string    `json:"instance_id" gorm:"type:varchar(64)"`
+	ClientIP    string    `json:"client_ip" gorm:"type:varchar(64)"`
+	BeforeState string    `json:"before_state" gorm:"type:json"`
+	AfterState  string    `json:"after_state" gorm:"type:json"`
+	Remark      string    `json:"remark" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+type ExceptionBulkJob struct {
+	ID           string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	TenantID     string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
+	Action       string     `json:"action" gorm:"type:varchar(20);not null"`
+	Filter       string     `json:"filter" gorm:"type:json"`
+	Params       string     `json:"params" gorm:"type:json"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:pending"`
+	Total        int64      `json:"total"`
+	Processed    int64      `json:"processed"`
+	Succeeded    int64      `json:"succeeded"`
+	Failed       int64      `json:"failed"`
+	LastID       int64      `json:"-"`
+	ErrorMessage string     `json:"error_message" gorm:"type:text"`
+	CreatedBy    string     `json:"created_by" gorm:"type:varchar(100)"`
+	ClientIP     string     `json:"client_ip" gorm:"type:varchar(64)"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+}
+
+func (ExceptionBulkJob) TableName() string {
+	return "exception_bulk_job"
+}
+
+// OutboxMessage 待投递的 MQ 消息，与任务状态在同一事务中写入
+type OutboxMessage struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantID      string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
+	TaskID        string     `json:"task_id" gorm:"type:varchar(64);not null"`
+	GroupID       string     `json:"group_id" gorm:"type:varchar(64);not null"`
+	Broker        string     `json:"broker" gorm:"type:varchar(32)"` // 为空时使用默认 broker
+	Topic         string     `json:"topic" gorm:"type:varchar(255);not null"`
+	Tags          string     `json:"tags" gorm:"type:varchar(255)"`
+	MessageKey    string     `json:"message_key" gorm:"type:varchar(512)"` // 多个 Key 以空格分隔
+	Properties    string     `json:"properties" gorm:"type:json"`
+	ShardingKey   string     `json:"sharding_key" gorm:"type:varchar(255)"`
+	DelayLevel    int        `json:"delay_level" gorm:"default:0"`
+	DeliverAt     *time.Time `json:"deliver_at"`
+	Body          string     `json:"body" gorm:"type:mediumtext"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:pending"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	MsgID         string     `json:"msg_id" gorm:"type:varchar(128)"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "mq_outbox"
+}
+
+// RateLimitBucket 分布式限流的令牌桶
+type RateLimitBucket struct {
+	BucketKey  string    `json:"bucket_key" gorm:"primaryKey;type:varchar(255)"`
+	Tokens     float64   `json:"tokens"`
+	RefilledAt time.Time `json:"refilled_at" gorm:"type:datetime(3)"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_bucket"
+}
+
+// Worker 已注册的外部 worker 进程
+type Worker struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:varchar(128)"` // 由 worker 指定，按租户区分
+	TenantID   string    `json:"tenant_id" gorm:"primaryKey;type:varchar(64);not null;default:default"`
+	Identity   string    `json:"identity" gorm:"type:varchar(255)"` // 主机名、进程号等，便于排查
+	TaskTypes  string    `json:"task_types" gorm:"type:json"`       // 处理的任务类型列表
+	LastSeenAt time.Time `json:"last_seen_at"`                      // 最近一次注册或领取任务的时间
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Worker) TableName() string {
+	return "worker"
+}
+
+// WorkerTask 等待外部 worker 领取或执行中的 worker 任务，ID 与 dist_task 相同，任务结束后删除
+type WorkerTask struct {
+	ID               string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	TenantID         string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:default"`
+	GroupID          string     `json:"group_id" gorm:"type:varchar(64);not null"`
+	TaskKey          string     `json:"task_key" gorm:"type:varchar(64);not null"`
+	TaskType         string     `json:"task_type" gorm:"type:varchar(128);not null"`
+	Payload          string     `json:"payload" gorm:"type:mediumtext"` // 未解密的入参，领取时解密
+	Status           string     `json:"status" gorm:"type:varchar(20);not null;default:queued"`
+	WorkerID         string     `json:"worker_id" gorm:"type:varchar(128)"`
+	LeaseToken       string     `json:"-" gorm:"type:varchar(64)"`
+	Attempt          int        `json:"attempt" gorm:"default:0"`
+	HeartbeatTimeout int        `json:"heartbeat_timeout" gorm:"default:0"`
+	StartToClose     int        `json:"start_to_close_timeout" gorm:"column:start_to_close_timeout;default:0"`
+	HeartbeatDetails string     `json:"heartbeat_details" gorm:"type:json"`
+	DeadlineAt       *time.Time `json:"deadline_at"`
+	LeasedAt         *time.Time `json:"leased_at"`
+	HeartbeatAt      *time.Time `json:"heartbeat_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func (WorkerTask) TableName() string {
+	return "worker_task"
+}
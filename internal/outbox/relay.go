@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/internal/model"
+	"dist_task/pkg/logger"
+)
+
+// Producer 投递 outbox 消息，返回 broker 生成的消息 ID
+type Producer interface {
+	Send(ctx context.Context, message *model.OutboxMessage) (string, error)
+}
+
+// Store outbox 消息存储，由 repository.OutboxRepository 实现
+type Store interface {
+	Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkSent(id int64, msgID string) error
+	MarkRetry(id int64, nextAttemptAt *time.Time, lastError string) error
+}
+
+// Relay 定时领取待投递消息并发送，失败按次数线性退避，超过上限标记为 failed。
+// 投递成功但标记前进程退出时消息会被再次发送，下游应按消息 Key（幂等键）去重
+type Relay struct {
+	store         Store
+	producer      Producer
+	interval      time.Duration
+	batchSize     int
+	maxAttempts   int
+	retryInterval time.Duration
+	sendTimeout   time.Duration
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewRelay(store Store, producer Producer, cfg *config.OutboxConfig) *Relay {
+	r := &Relay{
+		store:         store,
+		producer:      producer,
+		interval:      time.Duration(cfg.PollInterval) * time.Second,
+		batchSize:     cfg.BatchSize,
+		maxAttempts:   cfg.MaxAttempts,
+		retryInterval: time.Duration(cfg.RetryInterval) * time.Second,
+		sendTimeout:   10 * time.Second,
+		stopCh:        make(chan struct{}),
+	}
+	if r.interval <= 0 {
+		r.interval = time.Second
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = 100
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = 10
+	}
+	if r.retryInterval <= 0 {
+		r.retryInterval = 5 * time.Second
+	}
+	return r
+}
+
+func (r *Relay) Start() {
+	r.wg.Add(1)
+	go r.run()
+	log.Printf("Outbox relay started with interval: %v", r.interval)
+}
+
+func (r *Relay) Stop() {
+	close(r.stopCh)
+	r.wg.Wait()
+	log.Println("Outbox relay stopped")
+}
+
+func (r *Relay) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.Flush()
+		}
+	}
+}
+
+// Flush 投递一批到期消息，返回成功发送的数量
+func (r *Relay) Flush() int {
+	// 领取后的租约需覆盖整批发送耗时
+	lease := r.sendTimeout*time.Duration(r.batchSize) + r.interval
+	messages, err := r.store.Claim(r.batchSize, lease)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to claim outbox messages")
+		return 0
+	}
+
+	sent := 0
+	for i := range messages {
+		if r.send(&messages[i]) {
+			sent++
+		}
+	}
+	return sent
+}
+
+func (r *Relay) send(message *model.OutboxMessage) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), r.sendTimeout)
+	defer cancel()
+
+	msgID, err := r.producer.Send(ctx, message)
+	if err == nil {
+		if err := r.store.MarkSent(message.ID, msgID); err != nil {
+			logger.Error().Err(err).Int64("outbox_id", message.ID).Msg("Failed to mark outbox message sent")
+		}
+		return true
+	}
+
+	attempts := message.Attempts + 1
+	var nextAttemptAt *time.Time
+	if attempts < r.maxAttempts {
+		next := time.Now().Add(r.retryInterval * time.Duration(attempts))
+		nextAttemptAt = &next
+	}
+
+	logger.Warn().Err(err).
+		Int64("outbox_id", message.ID).
+		Str("task_id", message.TaskID).
+		Int("attempts", attempts).
+		Bool("exhausted", nextAttemptAt == nil).
+		Msg("Outbox message send failed")
+
+	if err := r.store.MarkRetry(message.ID, nextAttemptAt, err.Error()); err != nil {
+		logger.Error().Err(err).Int64("outbox_id", message.ID).Msg("Failed to update outbox message")
+	}
+	return false
+}
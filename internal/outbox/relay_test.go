@@ -0,0 +1,97 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/internal/engine/executor"
+	"dist_task/internal/messaging"
+	"dist_task/internal/model"
+)
+
+type memoryStore struct {
+	messages map[int64]*model.OutboxMessage
+}
+
+func newMemoryStore(messages ...model.OutboxMessage) *memoryStore {
+	s := &memoryStore{messages: make(map[int64]*model.OutboxMessage)}
+	for i := range messages {
+		messages[i].Status = "pending"
+		s.messages[messages[i].ID] = &messages[i]
+	}
+	return s
+}
+
+func (s *memoryStore) Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var claimed []model.OutboxMessage
+	now := time.Now()
+	for _, m := range s.messages {
+		if m.Status != "pending" || (m.NextAttemptAt != nil && m.NextAttemptAt.After(now)) || len(claimed) >= limit {
+			continue
+		}
+		next := now.Add(lease)
+		m.NextAttemptAt = &next
+		claimed = append(claimed, *m)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkSent(id int64, msgID string) error {
+	m := s.messages[id]
+	m.Status = "sent"
+	m.MsgID = msgID
+	m.Attempts++
+	return nil
+}
+
+func (s *memoryStore) MarkRetry(id int64, nextAttemptAt *time.Time, lastError string) error {
+	m := s.messages[id]
+	m.Attempts++
+	m.LastError = lastError
+	if nextAttemptAt == nil {
+		m.Status = "failed"
+	} else {
+		m.NextAttemptAt = nextAttemptAt
+	}
+	return nil
+}
+
+func TestRelay_Flush(t *testing.T) {
+	tests := []struct {
+		name         string
+		producerErr  error
+		attempts     int
+		wantStatus   string
+		wantSent     int
+		wantAttempts int
+	}{
+		{name: "sent", wantStatus: "sent", wantSent: 1, wantAttempts: 1},
+		{name: "broker down", producerErr: errors.New("broker unavailable"), wantStatus: "pending", wantAttempts: 1},
+		{name: "attempts exhausted", producerErr: errors.New("broker unavailable"), attempts: 2, wantStatus: "failed", wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore(model.OutboxMessage{ID: 1, TaskID: "inst_1_notify", Topic: "payment.completed", Attempts: tt.attempts})
+			broker := messaging.NewMemoryBroker()
+			broker.Fail(tt.producerErr)
+			brokers := messaging.NewRegistry(messaging.BrokerMemory)
+			brokers.Register(messaging.BrokerMemory, broker)
+			relay := NewRelay(store, executor.NewMQExecutor(brokers), &config.OutboxConfig{MaxAttempts: 3})
+
+			if got := relay.Flush(); got != tt.wantSent {
+				t.Errorf("Flush() = %d, want %d", got, tt.wantSent)
+			}
+
+			m := store.messages[1]
+			if m.Status != tt.wantStatus || m.Attempts != tt.wantAttempts {
+				t.Errorf("message status = %s attempts = %d, want %s %d", m.Status, m.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if got := len(broker.Messages("payment.completed")); got != tt.wantSent {
+				t.Errorf("broker received %d messages, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/pkg/taskdef"
+
+	"golang.org/x/time/rate"
+)
+
+// Store 令牌桶存储，Take 在令牌不足时不扣减，返回需要等待的时长
+type Store interface {
+	Take(key string, rate float64, burst int) (time.Duration, error)
+}
+
+// Limiter 按任务定义、下游目标和事务流限制出站调用频率，为 nil 时不做限制
+type Limiter struct {
+	store      Store
+	inlineWait time.Duration
+	tasks      map[string]config.RateLimitRule
+	targets    map[string]config.RateLimitRule
+	flows      map[string]config.RateLimitRule
+}
+
+func NewLimiter(cfg *config.RateLimitConfig, store Store) *Limiter {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Limiter{
+		store:      store,
+		inlineWait: time.Duration(cfg.InlineWait) * time.Millisecond,
+		tasks:      cfg.Tasks,
+		targets:    cfg.Targets,
+		flows:      cfg.Flows,
+	}
+}
+
+type bucket struct {
+	key  string
+	rule config.RateLimitRule
+}
+
+// Acquire 依次从任务、目标、事务流的令牌桶取令牌。等待时长不超过 inline_wait 时原地等待，
+// 否则返回需要等待的时长，由调用方挂起任务后重试；已取得的令牌不退还
+func (l *Limiter) Acquire(ctx context.Context, taskName string, taskRule *taskdef.RateLimit, target, flowID string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	for _, b := range l.buckets(taskName, taskRule, target, flowID) {
+		for {
+			wait, err := l.store.Take(b.key, b.rule.Rate, burst(b.rule))
+			if err != nil {
+				return 0, err
+			}
+			if wait == 0 {
+				break
+			}
+			if wait > l.inlineWait {
+				return wait, nil
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return 0, ctx.Err()
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (l *Limiter) buckets(taskName string, taskRule *taskdef.RateLimit, target, flowID string) []bucket {
+	var buckets []bucket
+	// 配置文件中的任务限流覆盖任务定义
+	if rule, ok := l.tasks[taskName]; ok {
+		buckets = append(buckets, bucket{"task:" + taskName, rule})
+	} else if taskRule != nil {
+		buckets = append(buckets, bucket{"task:" + taskName, config.RateLimitRule{Rate: taskRule.Rate, Burst: taskRule.Burst}})
+	}
+	if rule, ok := l.targets[target]; ok && target != "" {
+		buckets = append(buckets, bucket{"target:" + target, rule})
+	}
+	if rule, ok := l.flows[flowID]; ok {
+		buckets = append(buckets, bucket{"flow:" + flowID, rule})
+	}
+
+	valid := buckets[:0]
+	for _, b := range buckets {
+		if b.rule.Rate > 0 {
+			valid = append(valid, b)
+		}
+	}
+	return valid
+}
+
+func burst(rule config.RateLimitRule) int {
+	if rule.Burst > 0 {
+		return rule.Burst
+	}
+	return int(math.Max(1, math.Ceil(rule.Rate)))
+}
+
+// MemoryStore 单进程内的令牌桶
+type MemoryStore struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{limiters: make(map[string]*rate.Limiter)}
+}
+
+func (s *MemoryStore) Take(key string, r float64, burst int) (time.Duration, error) {
+	s.mu.Lock()
+	limiter, ok := s.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(r), burst)
+		s.limiters[key] = limiter
+	}
+	s.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return delay, nil
+	}
+	return 0, nil
+}
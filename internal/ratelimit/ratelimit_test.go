@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dist_task/internal/config"
+	"dist_task/pkg/taskdef"
+)
+
+func TestLimiterAcquire(t *testing.T) {
+	l := NewLimiter(&config.RateLimitConfig{
+		Tasks:   map[string]config.RateLimitRule{"notify": {Rate: 0.1, Burst: 1}},
+		Targets: map[string]config.RateLimitRule{"http:api.partner.com": {Rate: 0.1, Burst: 2}},
+	}, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		taskName string
+		taskRule *taskdef.RateLimit
+		target   string
+		throttle bool
+	}{
+		{"first call within burst", "notify", nil, "http:api.partner.com", false},
+		{"task bucket empty", "notify", nil, "http:api.partner.com", true},
+		{"config overrides definition", "notify", &taskdef.RateLimit{Rate: 100}, "", true},
+		{"target bucket shared by tasks", "report", nil, "http:api.partner.com", false},
+		{"target bucket empty", "report", nil, "http:api.partner.com", true},
+		{"definition rule", "audit", &taskdef.RateLimit{Rate: 0.1}, "", false},
+		{"definition rule exhausted", "audit", &taskdef.RateLimit{Rate: 0.1}, "", true},
+		{"no rule", "report", nil, "http:other.com", false},
+	}
+
+	for _, tt := range tests {
+		wait, err := l.Acquire(ctx, tt.taskName, tt.taskRule, tt.target, "flow_001")
+		if err != nil {
+			t.Fatalf("%s: Acquire() error = %v", tt.name, err)
+		}
+		if (wait > 0) != tt.throttle {
+			t.Errorf("%s: wait = %v, throttle = %v", tt.name, wait, tt.throttle)
+		}
+	}
+}
+
+func TestLimiterInlineWait(t *testing.T) {
+	l := NewLimiter(&config.RateLimitConfig{
+		InlineWait: 1000,
+		Flows:      map[string]config.RateLimitRule{"flow_001": {Rate: 20, Burst: 1}},
+	}, nil)
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if wait, err := l.Acquire(context.Background(), "notify", nil, "", "flow_001"); wait != 0 || err != nil {
+			t.Fatalf("Acquire() = %v, %v", wait, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("second call returned after %v, want inline wait", elapsed)
+	}
+
+	var nilLimiter *Limiter
+	if wait, _ := nilLimiter.Acquire(context.Background(), "notify", nil, "", "flow_001"); wait != 0 {
+		t.Errorf("nil limiter wait = %v", wait)
+	}
+}
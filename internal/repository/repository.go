@@ -3,10 +3,12 @@ package repository
 import (
 	"dist_task/internal/model"
 	"dist_task/pkg/logger"
+	"math"
 	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	gormlogger "gorm.io/gorm/logger"
 )
 
@@ -29,15 +31,41 @@ func GetDB() *gorm.DB {
 	return db
 }
 
-type FlowRepository struct{}
+// SetDB 替换全局连接，供测试使用
+func SetDB(conn *gorm.DB) {
+	db = conn
+}
+
+// 仓储默认不限定租户，供引擎和后台调度使用；接口层通过 ForTenant 获取限定租户的副本
+func scoped(tenantID, column string) *gorm.DB {
+	if tenantID == "" {
+		return db
+	}
+	return db.Where(column+" = ?", tenantID)
+}
+
+type FlowRepository struct {
+	tenantID string
+}
+
+func (r *FlowRepository) ForTenant(tenantID string) *FlowRepository {
+	return &FlowRepository{tenantID: tenantID}
+}
+
+func (r *FlowRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
 
 func (r *FlowRepository) Create(flow *model.TaskGroupFlow) error {
+	if r.tenantID != "" {
+		flow.TenantID = r.tenantID
+	}
 	return db.Create(flow).Error
 }
 
 func (r *FlowRepository) GetByID(id string) (*model.TaskGroupFlow, error) {
 	var flow model.TaskGroupFlow
-	if err := db.First(&flow, "id = ?", id).Error; err != nil {
+	if err := r.query().First(&flow, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &flow, nil
@@ -47,30 +75,43 @@ func (r *FlowRepository) List(offset, limit int) ([]model.TaskGroupFlow, int64)
 	var flows []model.TaskGroupFlow
 	var total int64
 
-	db.Model(&model.TaskGroupFlow{}).Count(&total)
+	r.query().Model(&model.TaskGroupFlow{}).Count(&total)
 
-	db.Offset(offset).Limit(limit).Find(&flows)
+	r.query().Offset(offset).Limit(limit).Find(&flows)
 
 	return flows, total
 }
 
 func (r *FlowRepository) Update(flow *model.TaskGroupFlow) error {
-	return db.Save(flow).Error
+	return r.query().Select("*").Updates(flow).Error
 }
 
 func (r *FlowRepository) Delete(id string) error {
-	return db.Delete(&model.TaskGroupFlow{}, "id = ?", id).Error
+	return r.query().Delete(&model.TaskGroupFlow{}, "id = ?", id).Error
 }
 
-type InstanceRepository struct{}
+type InstanceRepository struct {
+	tenantID string
+}
+
+func (r *InstanceRepository) ForTenant(tenantID string) *InstanceRepository {
+	return &InstanceRepository{tenantID: tenantID}
+}
+
+func (r *InstanceRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
 
 func (r *InstanceRepository) Create(instance *model.TaskGroupInstance) error {
+	if r.tenantID != "" {
+		instance.TenantID = r.tenantID
+	}
 	return db.Create(instance).Error
 }
 
 func (r *InstanceRepository) GetByID(id string) (*model.TaskGroupInstance, error) {
 	var instance model.TaskGroupInstance
-	if err := db.First(&instance, "id = ?", id).Error; err != nil {
+	if err := r.query().First(&instance, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &instance, nil
@@ -80,18 +121,45 @@ func (r *InstanceRepository) List(offset, limit int) ([]model.TaskGroupInstance,
 	var instances []model.TaskGroupInstance
 	var total int64
 
-	db.Model(&model.TaskGroupInstance{}).Count(&total)
+	r.query().Model(&model.TaskGroupInstance{}).Count(&total)
 
-	db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&instances)
+	r.query().Offset(offset).Limit(limit).Order("created_at DESC").Find(&instances)
 
 	return instances, total
 }
 
+// CountActive 统计未结束的实例数，用于租户并发配额
+func (r *InstanceRepository) CountActive() (int64, error) {
+	var total int64
+	err := r.query().Model(&model.TaskGroupInstance{}).
+		Where("status IN ?", []string{"pending", "running", "waiting"}).
+		Count(&total).Error
+	return total, err
+}
+
 func (r *InstanceRepository) Update(instance *model.TaskGroupInstance) error {
-	return db.Save(instance).Error
+	return r.query().Select("*").Updates(instance).Error
+}
+
+func (r *InstanceRepository) UpdateStatusIf(id string, from []string, updates map[string]interface{}) (bool, error) {
+	result := r.query().Model(&model.TaskGroupInstance{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
-type TaskRepository struct{}
+type TaskRepository struct {
+	tenantID string
+}
+
+func (r *TaskRepository) ForTenant(tenantID string) *TaskRepository {
+	return &TaskRepository{tenantID: tenantID}
+}
+
+func (r *TaskRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "dist_task.tenant_id")
+}
 
 func (r *TaskRepository) Create(task *model.DistTask) error {
 	return db.Create(task).Error
@@ -99,7 +167,7 @@ func (r *TaskRepository) Create(task *model.DistTask) error {
 
 func (r *TaskRepository) GetByID(id string) (*model.DistTask, error) {
 	var task model.DistTask
-	if err := db.First(&task, "id = ?", id).Error; err != nil {
+	if err := r.query().First(&task, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &task, nil
@@ -107,45 +175,196 @@ func (r *TaskRepository) GetByID(id string) (*model.DistTask, error) {
 
 func (r *TaskRepository) ListByGroupID(groupID string) ([]model.DistTask, error) {
 	var tasks []model.DistTask
-	if err := db.Where("group_id = ?", groupID).Order("created_at ASC").Find(&tasks).Error; err != nil {
+	if err := r.query().Where("group_id = ?", groupID).Order("created_at ASC").Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
 func (r *TaskRepository) Update(task *model.DistTask) error {
-	return db.Save(task).Error
+	return r.query().Select("*").Updates(task).Error
+}
+
+// CompleteWithOutbox 在同一事务中更新任务状态并写入待投递消息
+func (r *TaskRepository) CompleteWithOutbox(task *model.DistTask, message *model.OutboxMessage) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(task).Error; err != nil {
+			return err
+		}
+		return tx.Create(message).Error
+	})
+}
+
+func (r *TaskRepository) CompareAndSwapStatus(id, from, to string) (bool, error) {
+	result := r.query().Model(&model.DistTask{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ExtendWaiting 更新等待中任务的 resume_at，任务已不在等待时返回 false
+func (r *TaskRepository) ExtendWaiting(id string, resumeAt time.Time) (bool, error) {
+	result := r.query().Model(&model.DistTask{}).Where("id = ? AND status = ?", id, "waiting").Update("resume_at", resumeAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *TaskRepository) CancelPending(groupID string) (int64, error) {
+	result := r.query().Model(&model.DistTask{}).
+		Where("group_id = ? AND status IN ?", groupID, []string{"pending", "waiting"}).
+		Updates(map[string]interface{}{"status": "cancelled", "resume_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+// ResetForRetry 将指定任务重置为 pending，供重试时重新调度
+func (r *TaskRepository) ResetForRetry(groupID string, taskKeys []string) (int64, error) {
+	result := r.query().Model(&model.DistTask{}).
+		Where("group_id = ? AND task_key IN ?", groupID, taskKeys).
+		Updates(map[string]interface{}{"status": "pending", "resume_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+func (r *TaskRepository) ListWaiting(flowID, taskKey string, offset, limit int) ([]model.DistTask, int64) {
+	var tasks []model.DistTask
+	var total int64
+
+	query := r.query().Model(&model.DistTask{}).Where("dist_task.status = ?", "waiting")
+	if taskKey != "" {
+		query = query.Where("dist_task.task_key = ?", taskKey)
+	}
+	if flowID != "" {
+		query = query.Joins("JOIN task_group_instance ON task_group_instance.id = dist_task.group_id").
+			Where("task_group_instance.flow_id = ?", flowID)
+	}
+
+	query.Count(&total)
+
+	query.Select("dist_task.*").Offset(offset).Limit(limit).Order("dist_task.created_at ASC").Find(&tasks)
+
+	return tasks, total
 }
 
-type ExceptionRepository struct{}
+func (r *TaskRepository) ListDueWaiting(now time.Time) ([]model.DistTask, error) {
+	var tasks []model.DistTask
+	err := db.Where("status = ? AND resume_at IS NOT NULL AND resume_at <= ?", "waiting", now).
+		Order("resume_at ASC").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+type ExceptionRepository struct {
+	tenantID string
+}
+
+func (r *ExceptionRepository) ForTenant(tenantID string) *ExceptionRepository {
+	return &ExceptionRepository{tenantID: tenantID}
+}
+
+func (r *ExceptionRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "exception_record.tenant_id")
+}
 
 func (r *ExceptionRepository) Create(exception *model.ExceptionRecord) error {
 	return db.Create(exception).Error
 }
 
-func (r *ExceptionRepository) List(offset, limit int, handled *bool) ([]model.ExceptionRecord, int64) {
+type ExceptionFilter struct {
+	Handled       *bool      `json:"handled,omitempty"`
+	FlowID        string     `json:"flow_id,omitempty"`
+	GroupID       string     `json:"group_id,omitempty"`
+	TaskName      string     `json:"task_name,omitempty"`
+	ErrorType     *int       `json:"error_type,omitempty"`
+	ErrorCode     string     `json:"error_code,omitempty"`
+	RetryStrategy string     `json:"retry_strategy,omitempty"`
+	Assignee      string     `json:"assignee,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+	Query         string     `json:"q,omitempty"` // 错误信息全文检索
+}
+
+func (r *ExceptionRepository) filtered(filter ExceptionFilter) *gorm.DB {
+	query := r.query().Model(&model.ExceptionRecord{})
+	if filter.Handled != nil {
+		query = query.Where("exception_record.handled = ?", *filter.Handled)
+	}
+	if filter.FlowID != "" {
+		query = query.Joins("JOIN task_group_instance ON task_group_instance.id = exception_record.group_id").
+			Where("task_group_instance.flow_id = ?", filter.FlowID)
+	}
+	if filter.GroupID != "" {
+		query = query.Where("exception_record.group_id = ?", filter.GroupID)
+	}
+	if filter.TaskName != "" {
+		query = query.Where("exception_record.task_name = ?", filter.TaskName)
+	}
+	if filter.ErrorType != nil {
+		query = query.Where("exception_record.error_type = ?", *filter.ErrorType)
+	}
+	if filter.ErrorCode != "" {
+		query = query.Where("exception_record.error_code = ?", filter.ErrorCode)
+	}
+	if filter.RetryStrategy != "" {
+		query = query.Where("exception_record.retry_strategy = ?", filter.RetryStrategy)
+	}
+	if filter.Assignee != "" {
+		query = query.Where("exception_record.assignee = ?", filter.Assignee)
+	}
+	if filter.Since != nil {
+		query = query.Where("exception_record.occurred_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("exception_record.occurred_at < ?", *filter.Until)
+	}
+	if filter.Query != "" {
+		query = query.Where("MATCH(exception_record.error_message) AGAINST (? IN BOOLEAN MODE)", filter.Query)
+	}
+	return query
+}
+
+func (r *ExceptionRepository) List(filter ExceptionFilter, offset, limit int) ([]model.ExceptionRecord, int64) {
 	var exceptions []model.ExceptionRecord
 	var total int64
 
-	query := db.Model(&model.ExceptionRecord{})
-	if handled != nil {
-		query = query.Where("handled = ?", *handled)
-	}
+	query := r.filtered(filter)
 
 	query.Count(&total)
 
-	query.Offset(offset).Limit(limit).Order("occurred_at DESC").Find(&exceptions)
+	query.Select("exception_record.*").Offset(offset).Limit(limit).Order("exception_record.occurred_at DESC").Find(&exceptions)
 
 	return exceptions, total
 }
 
+func (r *ExceptionRepository) Count(filter ExceptionFilter) (int64, error) {
+	var total int64
+	err := r.filtered(filter).Count(&total).Error
+	return total, err
+}
+
+// ListAfter 按 ID 游标分页，批量处理时记录的匹配范围变化不会导致漏处理
+func (r *ExceptionRepository) ListAfter(filter ExceptionFilter, afterID int64, limit int) ([]model.ExceptionRecord, error) {
+	var exceptions []model.ExceptionRecord
+	err := r.filtered(filter).
+		Select("exception_record.*").
+		Where("exception_record.id > ?", afterID).
+		Order("exception_record.id ASC").
+		Limit(limit).
+		Find(&exceptions).Error
+	return exceptions, err
+}
+
 func (r *ExceptionRepository) Update(exception *model.ExceptionRecord) error {
-	return db.Save(exception).Error
+	return r.query().Select("*").Updates(exception).Error
 }
 
 func (r *ExceptionRepository) GetByID(id string) (*model.ExceptionRecord, error) {
 	var exception model.ExceptionRecord
-	if err := db.First(&exception, "id = ?", id).Error; err != nil {
+	if err := r.query().First(&exception, "exception_record.id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &exception, nil
@@ -171,6 +390,11 @@ func (r *ExceptionRepository) MarkRetryComplete(id string) error {
 	return db.Exec("UPDATE exception_record SET retry_times = retry_max WHERE id = ?", id).Error
 }
 
+// Postpone 推迟下次重试时间，不增加重试次数
+func (r *ExceptionRepository) Postpone(id string, nextAt time.Time) error {
+	return db.Exec("UPDATE exception_record SET retry_next_at = ? WHERE id = ?", nextAt, id).Error
+}
+
 type LogRepository struct{}
 
 func (r *LogRepository) Create(log *model.ExecutionLog) error {
@@ -192,3 +416,307 @@ func (r *LogRepository) ListByGroupID(groupID string) ([]model.ExecutionLog, err
 	}
 	return logs, nil
 }
+
+// AuditRepository 审计日志只追加，不提供修改和删除
+type AuditRepository struct {
+	tenantID string
+}
+
+func (r *AuditRepository) ForTenant(tenantID string) *AuditRepository {
+	return &AuditRepository{tenantID: tenantID}
+}
+
+func (r *AuditRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	FlowID     string
+	InstanceID string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+func (r *AuditRepository) Create(entry *model.AuditLog) error {
+	if r.tenantID != "" {
+		entry.TenantID = r.tenantID
+	}
+	return db.Create(entry).Error
+}
+
+func (r *AuditRepository) List(filter AuditFilter, offset, limit int) ([]model.AuditLog, int64) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := r.query().Model(&model.AuditLog{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.FlowID != "" {
+		query = query.Where("flow_id = ?", filter.FlowID)
+	}
+	if filter.InstanceID != "" {
+		query = query.Where("instance_id = ?", filter.InstanceID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	query.Count(&total)
+
+	query.Offset(offset).Limit(limit).Order("created_at DESC, id DESC").Find(&logs)
+
+	return logs, total
+}
+
+type BulkJobRepository struct {
+	tenantID string
+}
+
+func (r *BulkJobRepository) ForTenant(tenantID string) *BulkJobRepository {
+	return &BulkJobRepository{tenantID: tenantID}
+}
+
+func (r *BulkJobRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
+
+func (r *BulkJobRepository) Create(job *model.ExceptionBulkJob) error {
+	if r.tenantID != "" {
+		job.TenantID = r.tenantID
+	}
+	return db.Create(job).Error
+}
+
+func (r *BulkJobRepository) GetByID(id string) (*model.ExceptionBulkJob, error) {
+	var job model.ExceptionBulkJob
+	if err := r.query().First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *BulkJobRepository) Update(job *model.ExceptionBulkJob) error {
+	return r.query().Select("*").Updates(job).Error
+}
+
+// ListUnfinished 返回未完成的任务，服务重启后继续处理
+func (r *BulkJobRepository) ListUnfinished() ([]model.ExceptionBulkJob, error) {
+	var jobs []model.ExceptionBulkJob
+	err := r.query().Where("status IN ?", []string{"pending", "running"}).Order("created_at ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+type OutboxRepository struct{}
+
+// Claim 领取到期的待投递消息，并将下次尝试时间推后 lease，避免多个 relay 重复投递
+func (r *OutboxRepository) Claim(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", "pending", now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+		return tx.Model(&model.OutboxMessage{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return messages, err
+}
+
+func (r *OutboxRepository) MarkSent(id int64, msgID string) error {
+	return db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     "sent",
+		"msg_id":     msgID,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": "",
+		"sent_at":    time.Now(),
+	}).Error
+}
+
+// MarkRetry 记录投递失败；nextAttemptAt 为 nil 时表示重试次数耗尽
+func (r *OutboxRepository) MarkRetry(id int64, nextAttemptAt *time.Time, lastError string) error {
+	updates := map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": lastError,
+	}
+	if nextAttemptAt != nil {
+		updates["next_attempt_at"] = *nextAttemptAt
+	} else {
+		updates["status"] = "failed"
+	}
+	return db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+type RateLimitRepository struct{}
+
+// Take 从令牌桶取一个令牌，令牌不足时不扣减并返回需要等待的时长。
+// 以数据库时间计算补充的令牌，避免各副本时钟不一致
+func (r *RateLimitRepository) Take(key string, rate float64, burst int) (time.Duration, error) {
+	var wait time.Duration
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 首次使用时以满桶创建
+		if err := tx.Exec("INSERT IGNORE INTO rate_limit_bucket (bucket_key, tokens, refilled_at) VALUES (?, ?, NOW(3))", key, burst).Error; err != nil {
+			return err
+		}
+
+		var bucket model.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("bucket_key = ?", key).Take(&bucket).Error; err != nil {
+			return err
+		}
+		var now time.Time
+		if err := tx.Raw("SELECT NOW(3)").Row().Scan(&now); err != nil {
+			return err
+		}
+
+		tokens := bucket.Tokens
+		if elapsed := now.Sub(bucket.RefilledAt).Seconds(); elapsed > 0 {
+			tokens = math.Min(float64(burst), tokens+elapsed*rate)
+		}
+		if tokens >= 1 {
+			tokens--
+		} else {
+			wait = time.Duration((1 - tokens) / rate * float64(time.Second))
+		}
+
+		return tx.Model(&model.RateLimitBucket{}).Where("bucket_key = ?", key).Updates(map[string]interface{}{
+			"tokens":      tokens,
+			"refilled_at": now,
+		}).Error
+	})
+	return wait, err
+}
+
+type WorkerRepository struct {
+	tenantID string
+}
+
+func (r *WorkerRepository) ForTenant(tenantID string) *WorkerRepository {
+	return &WorkerRepository{tenantID: tenantID}
+}
+
+func (r *WorkerRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
+
+// Register 注册 worker，同一租户下已存在时更新处理的任务类型；不同租户的同名 worker 互不影响
+func (r *WorkerRepository) Register(worker *model.Worker) error {
+	if r.tenantID != "" {
+		worker.TenantID = r.tenantID
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"identity", "task_types", "last_seen_at"}),
+	}).Create(worker).Error
+}
+
+func (r *WorkerRepository) GetByID(id string) (*model.Worker, error) {
+	var worker model.Worker
+	if err := r.query().First(&worker, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+func (r *WorkerRepository) Touch(id string) error {
+	return r.query().Model(&model.Worker{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+}
+
+func (r *WorkerRepository) List() ([]model.Worker, error) {
+	var workers []model.Worker
+	err := r.query().Order("last_seen_at DESC").Find(&workers).Error
+	return workers, err
+}
+
+type WorkerTaskRepository struct {
+	tenantID string
+}
+
+func (r *WorkerTaskRepository) ForTenant(tenantID string) *WorkerTaskRepository {
+	return &WorkerTaskRepository{tenantID: tenantID}
+}
+
+func (r *WorkerTaskRepository) query() *gorm.DB {
+	return scoped(r.tenantID, "tenant_id")
+}
+
+// Enqueue 派发任务；重试时覆盖上一次的记录
+func (r *WorkerTaskRepository) Enqueue(task *model.WorkerTask) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(task).Error
+}
+
+func (r *WorkerTaskRepository) GetByID(id string) (*model.WorkerTask, error) {
+	var task model.WorkerTask
+	if err := r.query().First(&task, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Lease 按派发顺序领取一个指定类型的任务，没有可领取的任务时返回 nil
+func (r *WorkerTaskRepository) Lease(taskTypes []string, workerID, token string, now time.Time) (*model.WorkerTask, error) {
+	var leased *model.WorkerTask
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("task_type IN ? AND status = ?", taskTypes, "queued")
+		if r.tenantID != "" {
+			query = query.Where("tenant_id = ?", r.tenantID)
+		}
+
+		var tasks []model.WorkerTask
+		if err := query.Order("created_at ASC").Limit(1).Find(&tasks).Error; err != nil || len(tasks) == 0 {
+			return err
+		}
+
+		task := &tasks[0]
+		deadline := now.Add(time.Duration(task.StartToClose) * time.Second)
+		task.Status = "leased"
+		task.WorkerID = workerID
+		task.LeaseToken = token
+		task.LeasedAt = &now
+		task.HeartbeatAt = &now
+		task.DeadlineAt = &deadline
+		if err := tx.Save(task).Error; err != nil {
+			return err
+		}
+		leased = task
+		return nil
+	})
+	return leased, err
+}
+
+func (r *WorkerTaskRepository) Heartbeat(id, token, details string, now time.Time) error {
+	updates := map[string]interface{}{"heartbeat_at": now}
+	if details != "" {
+		updates["heartbeat_details"] = details
+	}
+	return r.query().Model(&model.WorkerTask{}).Where("id = ? AND lease_token = ?", id, token).Updates(updates).Error
+}
+
+func (r *WorkerTaskRepository) Delete(id string) error {
+	return r.query().Delete(&model.WorkerTask{}, "id = ?", id).Error
+}
@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	"dist_task/internal/repository/repotest"
+)
+
+func TestUpdate_TenantScoped(t *testing.T) {
+	conn := repotest.Open(t)
+
+	task := &model.DistTask{ID: "inst_a", TenantID: "acme", GroupID: "inst", TaskKey: "a", Type: "http", Status: "pending"}
+	exception := &model.ExceptionRecord{TenantID: "acme", GroupID: "inst", TaskID: "inst_a", TaskName: "a", OccurredAt: time.Now()}
+	job := &model.ExceptionBulkJob{ID: "job", TenantID: "acme", Action: "handle", Filter: "{}", Params: "{}", Status: "pending"}
+	for _, record := range []interface{}{task, exception, job} {
+		if err := conn.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update func(tenantID string) error
+		status func() string
+	}{
+		{
+			name: "task",
+			update: func(tenantID string) error {
+				task.Status = "success"
+				return (&repository.TaskRepository{}).ForTenant(tenantID).Update(task)
+			},
+			status: func() string {
+				var stored model.DistTask
+				conn.First(&stored, "id = ?", task.ID)
+				return stored.Status
+			},
+		},
+		{
+			name: "exception",
+			update: func(tenantID string) error {
+				exception.Assignee = "bob"
+				return (&repository.ExceptionRepository{}).ForTenant(tenantID).Update(exception)
+			},
+			status: func() string {
+				var stored model.ExceptionRecord
+				conn.First(&stored, exception.ID)
+				return stored.Assignee
+			},
+		},
+		{
+			name: "bulk job",
+			update: func(tenantID string) error {
+				job.Status = "completed"
+				return (&repository.BulkJobRepository{}).ForTenant(tenantID).Update(job)
+			},
+			status: func() string {
+				var stored model.ExceptionBulkJob
+				conn.First(&stored, "id = ?", job.ID)
+				return stored.Status
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.status()
+			if err := tt.update("other"); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := tt.status(); got != before {
+				t.Errorf("other tenant updated the record: %q -> %q", before, got)
+			}
+
+			if err := tt.update("acme"); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := tt.status(); got == before {
+				t.Errorf("owner update not applied, still %q", got)
+			}
+		})
+	}
+}
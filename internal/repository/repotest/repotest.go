@@ -0,0 +1,57 @@
+// Package repotest 为依赖仓储的测试提供内存 SQLite 数据库
+package repotest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Open 创建按测试隔离的内存数据库并建表，替换仓储使用的全局连接，测试结束后恢复。
+// SQLite 不支持行锁和 MySQL 方言的原生 SQL，涉及这些语句的仓储方法不能在此测试
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	conn, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+
+	sqlDB, _ := conn.DB()
+	// 引擎会并发写入，SQLite 只允许单个写连接
+	sqlDB.SetMaxOpenConns(1)
+
+	err = conn.AutoMigrate(
+		&model.TaskGroupFlow{},
+		&model.TaskGroupInstance{},
+		&model.DistTask{},
+		&model.ExceptionRecord{},
+		&model.ExecutionLog{},
+		&model.AuditLog{},
+		&model.ExceptionBulkJob{},
+		&model.OutboxMessage{},
+		&model.Worker{},
+		&model.WorkerTask{},
+	)
+	if err != nil {
+		t.Fatalf("migrate sqlite failed: %v", err)
+	}
+
+	previous := repository.GetDB()
+	repository.SetDB(conn)
+	t.Cleanup(func() {
+		repository.SetDB(previous)
+		sqlDB.Close()
+	})
+	return conn
+}
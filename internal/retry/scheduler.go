@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"log"
 	"strconv"
 	"sync"
@@ -10,6 +11,7 @@ import (
 	"dist_task/internal/engine"
 	"dist_task/internal/model"
 	"dist_task/internal/repository"
+	apperrors "dist_task/pkg/errors"
 	"dist_task/pkg/logger"
 )
 
@@ -86,6 +88,14 @@ func (s *RetryScheduler) processRetry(ex *model.ExceptionRecord) {
 	}
 
 	err = s.retryTask(ctx, instance, ex)
+	var typed *apperrors.Error
+	if errors.As(err, &typed) && typed.Category == apperrors.CategoryCircuitOpen {
+		// 下游熔断中，请求未发出，不消耗重试次数，等冷却结束后再试
+		nextAt := time.Now().Add(typed.RetryAfter)
+		s.exceptionRepo.Postpone(strconv.FormatInt(ex.ID, 10), nextAt)
+		logger.Warn().Str("exception_id", strconv.FormatInt(ex.ID, 10)).Time("next_at", nextAt).Msg("Retry postponed, circuit open")
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Str("exception_id", strconv.FormatInt(ex.ID, 10)).Msg("Retry failed")
 
@@ -108,12 +118,6 @@ func (s *RetryScheduler) getInstanceByGroupID(groupID string) (*model.TaskGroupI
 }
 
 func (s *RetryScheduler) retryTask(ctx context.Context, instance *model.TaskGroupInstance, ex *model.ExceptionRecord) error {
-	flowRepo := &repository.FlowRepository{}
-	flow, err := flowRepo.GetByID(instance.FlowID)
-	if err != nil {
-		return err
-	}
-
 	tasks, err := s.getTasksByGroupID(ex.GroupID)
 	if err != nil {
 		return err
@@ -121,7 +125,7 @@ func (s *RetryScheduler) retryTask(ctx context.Context, instance *model.TaskGrou
 
 	for _, task := range tasks {
 		if task.ID == ex.TaskID {
-			return s.engine.RetryTask(ctx, instance, flow, task.Name, task.Config)
+			return s.engine.RetryTask(ctx, instance, task.TaskKey)
 		}
 	}
 
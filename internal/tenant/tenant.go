@@ -0,0 +1,106 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"dist_task/internal/config"
+
+	"golang.org/x/time/rate"
+)
+
+const DefaultTenant = "default"
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Manager 维护各租户的配额和执行器白名单
+type Manager struct {
+	defaults config.TenantConfig
+	policies map[string]config.TenantConfig
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func NewManager(cfg *config.TenancyConfig) (*Manager, error) {
+	m := &Manager{
+		defaults: cfg.Defaults,
+		policies: make(map[string]config.TenantConfig, len(cfg.Tenants)),
+		limiters: make(map[string]*rate.Limiter),
+	}
+
+	for _, t := range cfg.Tenants {
+		if !ValidID(t.ID) {
+			return nil, fmt.Errorf("invalid tenant id: %q", t.ID)
+		}
+		if _, ok := m.policies[t.ID]; ok {
+			return nil, fmt.Errorf("duplicate tenant id: %s", t.ID)
+		}
+		m.policies[t.ID] = t
+	}
+	return m, nil
+}
+
+func (m *Manager) Policy(tenantID string) config.TenantConfig {
+	if policy, ok := m.policies[tenantID]; ok {
+		return policy
+	}
+	policy := m.defaults
+	policy.ID = tenantID
+	return policy
+}
+
+// AllowStart 按租户速率限制判断是否可以启动新实例
+func (m *Manager) AllowStart(tenantID string) bool {
+	policy := m.Policy(tenantID)
+	if policy.RateLimit <= 0 {
+		return true
+	}
+
+	m.mu.Lock()
+	limiter, ok := m.limiters[tenantID]
+	if !ok {
+		burst := policy.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(policy.RateLimit), burst)
+		m.limiters[tenantID] = limiter
+	}
+	m.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// CheckConcurrency 判断当前未结束实例数是否已达上限
+func (m *Manager) CheckConcurrency(tenantID string, active int64) error {
+	policy := m.Policy(tenantID)
+	if policy.MaxConcurrent > 0 && active >= int64(policy.MaxConcurrent) {
+		return fmt.Errorf("tenant %s reached max concurrent instances %d", tenantID, policy.MaxConcurrent)
+	}
+	return nil
+}
+
+// CheckTaskTypes 校验任务类型是否在租户的执行器白名单内
+func (m *Manager) CheckTaskTypes(tenantID string, taskTypes []string) error {
+	policy := m.Policy(tenantID)
+	if len(policy.AllowedTaskTypes) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(policy.AllowedTaskTypes))
+	for _, t := range policy.AllowedTaskTypes {
+		allowed[t] = true
+	}
+	for _, t := range taskTypes {
+		if !allowed[t] {
+			return fmt.Errorf("task type %s is not allowed for tenant %s", t, tenantID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package tenant
+
+import (
+	"testing"
+
+	"dist_task/internal/config"
+)
+
+func TestManager_CheckTaskTypes(t *testing.T) {
+	m, err := NewManager(&config.TenancyConfig{
+		Tenants: []config.TenantConfig{
+			{ID: "team-a", AllowedTaskTypes: []string{"rpc", "http"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tenant  string
+		types   []string
+		wantErr bool
+	}{
+		{"allowed", "team-a", []string{"rpc", "http"}, false},
+		{"not allowed", "team-a", []string{"rpc", "db"}, true},
+		{"unconfigured tenant", "team-b", []string{"db"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.CheckTaskTypes(tt.tenant, tt.types); (err != nil) != tt.wantErr {
+				t.Errorf("CheckTaskTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManager_Quota(t *testing.T) {
+	m, err := NewManager(&config.TenancyConfig{
+		Defaults: config.TenantConfig{MaxConcurrent: 2, RateLimit: 0.001, Burst: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.CheckConcurrency("team-a", 1); err != nil {
+		t.Errorf("CheckConcurrency(1) error = %v", err)
+	}
+	if err := m.CheckConcurrency("team-a", 2); err == nil {
+		t.Errorf("CheckConcurrency(2) should exceed quota")
+	}
+
+	for i := 0; i < 2; i++ {
+		if !m.AllowStart("team-a") {
+			t.Fatalf("AllowStart() #%d should be allowed within burst", i+1)
+		}
+	}
+	if m.AllowStart("team-a") {
+		t.Errorf("AllowStart() should be limited after burst")
+	}
+	if !m.AllowStart("team-b") {
+		t.Errorf("AllowStart() limiter should be per tenant")
+	}
+}
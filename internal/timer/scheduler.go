@@ -0,0 +1,87 @@
+package timer
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"dist_task/internal/engine"
+	"dist_task/internal/model"
+	"dist_task/internal/repository"
+	apperrors "dist_task/pkg/errors"
+	"dist_task/pkg/logger"
+)
+
+type TimerScheduler struct {
+	taskRepo *repository.TaskRepository
+	engine   *engine.Engine
+	interval time.Duration
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewTimerScheduler(taskRepo *repository.TaskRepository, eng *engine.Engine, intervalSeconds int) *TimerScheduler {
+	if intervalSeconds <= 0 {
+		intervalSeconds = 5
+	}
+	return &TimerScheduler{
+		taskRepo: taskRepo,
+		engine:   eng,
+		interval: time.Duration(intervalSeconds) * time.Second,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+func (s *TimerScheduler) Start() {
+	s.wg.Add(1)
+	go s.run()
+	log.Printf("Timer scheduler started with interval: %v", s.interval)
+}
+
+func (s *TimerScheduler) Stop() {
+	close(s.stopCh)
+	s.wg.Wait()
+	log.Println("Timer scheduler stopped")
+}
+
+func (s *TimerScheduler) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.processDue()
+		}
+	}
+}
+
+func (s *TimerScheduler) processDue() {
+	tasks, err := s.taskRepo.ListDueWaiting(time.Now())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get due timer tasks")
+		return
+	}
+
+	for i := range tasks {
+		s.process(&tasks[i])
+	}
+}
+
+func (s *TimerScheduler) process(task *model.DistTask) {
+	err := s.engine.Wake(task)
+	if err == apperrors.ErrTaskNotWaiting || err == apperrors.ErrInstancePaused {
+		// 已被信号抢先处理，或实例暂停中
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("task_id", task.ID).Msg("Failed to wake timer task")
+		return
+	}
+
+	logger.Info().Str("task_id", task.ID).Msg("Timer task fired")
+}
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE task_group_instance
+    MODIFY status ENUM('pending', 'running', 'waiting', 'success', 'failed') DEFAULT 'pending',
+    ADD COLUMN params JSON AFTER status;
+
+ALTER TABLE dist_task
+    ADD COLUMN task_key VARCHAR(64) NOT NULL DEFAULT '' AFTER group_id,
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait') NOT NULL,
+    MODIFY status ENUM('pending', 'running', 'waiting', 'success', 'failed') DEFAULT 'pending',
+    ADD COLUMN resume_at TIMESTAMP NULL AFTER error_stack,
+    ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP AFTER resume_at,
+    ADD INDEX idx_task_key_status (task_key, status),
+    ADD INDEX idx_status_resume (status, resume_at);
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete') NOT NULL;
+
+ALTER TABLE dist_task
+    DROP INDEX idx_status_resume,
+    DROP INDEX idx_task_key_status,
+    DROP COLUMN created_at,
+    DROP COLUMN resume_at,
+    MODIFY status ENUM('pending', 'running', 'success', 'failed') DEFAULT 'pending',
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db') NOT NULL,
+    DROP COLUMN task_key;
+
+ALTER TABLE task_group_instance
+    DROP COLUMN params,
+    MODIFY status ENUM('pending', 'running', 'success', 'failed') DEFAULT 'pending';
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE dist_task
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait', 'delay') NOT NULL,
+    MODIFY status ENUM('pending', 'running', 'waiting', 'success', 'failed', 'cancelled') DEFAULT 'pending';
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout') NOT NULL;
+
+ALTER TABLE dist_task
+    MODIFY status ENUM('pending', 'running', 'waiting', 'success', 'failed') DEFAULT 'pending',
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait') NOT NULL;
+
+-- +goose StatementEnd
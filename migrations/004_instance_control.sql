@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE task_group_instance
+    MODIFY status ENUM('pending', 'running', 'waiting', 'paused', 'success', 'failed', 'cancelled') DEFAULT 'pending';
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel', 'pause', 'resume', 'compensate') NOT NULL,
+    ADD INDEX idx_group (group_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE execution_log
+    DROP INDEX idx_group,
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel') NOT NULL;
+
+ALTER TABLE task_group_instance
+    MODIFY status ENUM('pending', 'running', 'waiting', 'success', 'failed') DEFAULT 'pending';
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type ENUM('flow', 'instance', 'exception') NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    flow_id VARCHAR(64),
+    instance_id VARCHAR(64),
+    client_ip VARCHAR(64),
+    before_state JSON,
+    after_state JSON,
+    remark TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_target (target_type, target_id),
+    INDEX idx_actor (actor, created_at),
+    INDEX idx_flow (flow_id),
+    INDEX idx_instance (instance_id),
+    INDEX idx_created_at (created_at)
+);
+
+-- 审计日志只允许追加
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TABLE IF EXISTS audit_log;
+
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE task_group_flow
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_tenant (tenant_id);
+
+ALTER TABLE task_group_instance
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_tenant_status (tenant_id, status);
+
+ALTER TABLE dist_task
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_tenant (tenant_id);
+
+ALTER TABLE exception_record
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_tenant_handled (tenant_id, handled);
+
+ALTER TABLE audit_log
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_tenant (tenant_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE audit_log DROP INDEX idx_tenant, DROP COLUMN tenant_id;
+ALTER TABLE exception_record DROP INDEX idx_tenant_handled, DROP COLUMN tenant_id;
+ALTER TABLE dist_task DROP INDEX idx_tenant, DROP COLUMN tenant_id;
+ALTER TABLE task_group_instance DROP INDEX idx_tenant_status, DROP COLUMN tenant_id;
+ALTER TABLE task_group_flow DROP INDEX idx_tenant, DROP COLUMN tenant_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE exception_record
+    ADD COLUMN assignee VARCHAR(100) AFTER handled_remark,
+    ADD INDEX idx_occurred_at (occurred_at),
+    ADD INDEX idx_error_code (error_code),
+    ADD FULLTEXT INDEX ft_error_message (error_message);
+
+CREATE TABLE exception_bulk_job (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    action ENUM('retry', 'handle', 'reassign') NOT NULL,
+    filter JSON,
+    params JSON,
+    status ENUM('pending', 'running', 'completed', 'failed') DEFAULT 'pending',
+    total BIGINT DEFAULT 0,
+    processed BIGINT DEFAULT 0,
+    succeeded BIGINT DEFAULT 0,
+    failed BIGINT DEFAULT 0,
+    last_id BIGINT DEFAULT 0,
+    error_message TEXT,
+    created_by VARCHAR(100),
+    client_ip VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_tenant_status (tenant_id, status)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS exception_bulk_job;
+
+ALTER TABLE exception_record
+    DROP INDEX ft_error_message,
+    DROP INDEX idx_error_code,
+    DROP INDEX idx_occurred_at,
+    DROP COLUMN assignee;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE dist_task
+    ADD COLUMN idempotency_key VARCHAR(255) AFTER task_key,
+    ADD INDEX idx_idempotency_key (idempotency_key);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE dist_task
+    DROP INDEX idx_idempotency_key,
+    DROP COLUMN idempotency_key;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE mq_outbox
+    MODIFY message_key VARCHAR(512),
+    ADD COLUMN tags VARCHAR(255) AFTER topic,
+    ADD COLUMN sharding_key VARCHAR(255) AFTER properties,
+    ADD COLUMN delay_level INT DEFAULT 0 AFTER sharding_key,
+    ADD COLUMN deliver_at TIMESTAMP NULL AFTER delay_level;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE mq_outbox
+    DROP COLUMN deliver_at,
+    DROP COLUMN delay_level,
+    DROP COLUMN sharding_key,
+    DROP COLUMN tags,
+    MODIFY message_key VARCHAR(255);
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE mq_outbox
+    ADD COLUMN broker VARCHAR(32) AFTER group_id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE mq_outbox
+    DROP COLUMN broker;
+
+-- +goose StatementEnd
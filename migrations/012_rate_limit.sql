@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE rate_limit_bucket (
+    bucket_key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE NOT NULL,
+    refilled_at DATETIME(3) NOT NULL
+);
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel', 'pause', 'resume', 'compensate', 'throttle') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel', 'pause', 'resume', 'compensate') NOT NULL;
+
+DROP TABLE IF EXISTS rate_limit_bucket;
+
+-- +goose StatementEnd
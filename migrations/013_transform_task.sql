@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE dist_task
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait', 'delay', 'transform') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE dist_task
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait', 'delay') NOT NULL;
+
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE worker (
+    id VARCHAR(128) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    identity VARCHAR(255),
+    task_types JSON,
+    last_seen_at DATETIME(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tenant (tenant_id)
+);
+
+CREATE TABLE worker_task (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    group_id VARCHAR(64) NOT NULL,
+    task_key VARCHAR(64) NOT NULL,
+    task_type VARCHAR(128) NOT NULL,
+    payload MEDIUMTEXT,
+    status ENUM('queued', 'leased') DEFAULT 'queued',
+    worker_id VARCHAR(128),
+    lease_token VARCHAR(64),
+    attempt INT DEFAULT 0,
+    heartbeat_timeout INT DEFAULT 0,
+    start_to_close_timeout INT DEFAULT 0,
+    heartbeat_details JSON,
+    deadline_at DATETIME(3) NULL,
+    leased_at DATETIME(3) NULL,
+    heartbeat_at DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    INDEX idx_poll (tenant_id, task_type, status, created_at)
+);
+
+ALTER TABLE dist_task
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait', 'delay', 'transform', 'command', 'worker') NOT NULL;
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel', 'pause', 'resume', 'compensate', 'throttle', 'dispatch', 'lease') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE execution_log
+    MODIFY action ENUM('start', 'retry', 'success', 'failed', 'complete', 'wait', 'signal', 'timeout', 'fire', 'cancel', 'pause', 'resume', 'compensate', 'throttle') NOT NULL;
+
+ALTER TABLE dist_task
+    MODIFY type ENUM('rpc', 'mq', 'http', 'db', 'wait', 'delay', 'transform', 'command') NOT NULL;
+
+DROP TABLE IF EXISTS worker_task;
+DROP TABLE IF EXISTS worker;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE audit_log
+    MODIFY target_type ENUM('flow', 'instance', 'exception', 'bulk_job') NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE audit_log
+    MODIFY target_type ENUM('flow', 'instance', 'exception') NOT NULL;
+
+-- +goose StatementEnd
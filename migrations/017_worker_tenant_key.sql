@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- worker ID 由 worker 自行指定，不同租户可能重复，按租户区分，避免重复注册时覆盖其他租户的 worker
+ALTER TABLE worker
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (tenant_id, id),
+    DROP INDEX idx_tenant;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE worker
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (id),
+    ADD INDEX idx_tenant (tenant_id);
+
+-- +goose StatementEnd
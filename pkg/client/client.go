@@ -0,0 +1,272 @@
+// Package client 是 dist_task HTTP API 的 Go SDK。
+//
+// 封装了 /api/v1 下 flow、事务、异常与统计接口的调用和 {code, message, data} 响应解析，
+// 并提供在代码中构造 FlowDefinition 的 builder（见 NewFlow）。
+// 单元测试可使用 clienttest 子包中的内存服务端。
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// Client dist_task API 客户端，可在多个 goroutine 中共用
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	operator   string
+	maxRetries int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client（超时、代理、TLS 等）
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey 通过 X-API-Key 认证
+func WithAPIKey(key string) Option {
+	return WithHeader("X-API-Key", key)
+}
+
+// WithToken 通过 Authorization: Bearer 认证
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithTenant 设置租户，服务端修改了 [tenant] header 时使用 WithHeader
+func WithTenant(tenant string) Option {
+	return WithHeader("X-Tenant-ID", tenant)
+}
+
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithOperator 设置请求体中的操作人（operator / create_user / handled_by），
+// 服务端启用认证时以认证主体为准
+func WithOperator(operator string) Option {
+	return func(c *Client) {
+		c.operator = operator
+	}
+}
+
+// WithRetry 设置幂等请求的最大重试次数和初始退避，maxRetries 为 0 时不重试
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New 创建客户端，baseURL 为服务地址，如 http://dist-task:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		header:     make(http.Header),
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError 服务端返回的错误响应
+type APIError struct {
+	StatusCode int    // HTTP 状态码
+	Code       int    // 响应体中的 code
+	Message    string // 响应体中的 message
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("dist_task: %s (status %d, code %d)", e.Message, e.StatusCode, e.Code)
+}
+
+// Temporary 是否为可重试的错误（限流或服务端错误）
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	// retry 仅对可安全重放的请求开启：GET 以及带实例 ID 的事务启动
+	retry bool
+}
+
+// do 发送请求并把 data 解析到 out，out 为 nil 时忽略 data
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("marshal request failed: %w", err)
+		}
+	}
+
+	endpoint := c.baseURL + "/api/v1" + req.path
+	if len(req.query) > 0 {
+		endpoint += "?" + req.query.Encode()
+	}
+
+	attempts := 1
+	if req.retry {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.retryDelay(attempt)); err != nil {
+				return lastErr
+			}
+		}
+
+		lastErr = c.send(ctx, req.method, endpoint, body, out)
+		if lastErr == nil || !retryable(ctx, lastErr) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.header {
+		httpReq.Header[key] = values
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &APIError{StatusCode: resp.StatusCode, Code: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		}
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest || env.Code != 0 {
+		return &APIError{StatusCode: resp.StatusCode, Code: env.Code, Message: env.Message}
+	}
+
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("decode response data failed: %w", err)
+	}
+	return nil
+}
+
+// retryDelay 指数退避，上限 maxBackoff
+func (c *Client) retryDelay(attempt int) time.Duration {
+	delay := c.backoff << (attempt - 1)
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// 其余为网络错误
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func pageQuery(page, pageSize int) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", fmt.Sprint(page))
+	}
+	if pageSize > 0 {
+		query.Set("page_size", fmt.Sprint(pageSize))
+	}
+	return query
+}
@@ -0,0 +1,118 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"dist_task/pkg/client"
+	"dist_task/pkg/client/clienttest"
+)
+
+func newFlow(t *testing.T, srv *clienttest.Server) string {
+	f := client.NewFlow("order")
+	f.Task("approve", "wait")
+	def, err := f.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv.AddFlow("order", def)
+}
+
+func TestStartTransaction_Retry(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	flowID := newFlow(t, srv)
+	c := srv.Client()
+	ctx := context.Background()
+
+	srv.FailNext(2, http.StatusServiceUnavailable)
+	result, err := c.StartTransaction(ctx, &client.StartRequest{FlowID: flowID})
+	if err != nil {
+		t.Fatalf("StartTransaction() error = %v", err)
+	}
+	if result.InstanceID == "" || result.Status != client.StatusPending {
+		t.Errorf("StartTransaction() = %+v", result)
+	}
+	if got := srv.Requests(http.MethodPost, "/api/v1/transactions"); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+
+	// 重复启动同一实例返回已有实例
+	srv.SetStatus(result.InstanceID, client.StatusRunning)
+	again, err := c.StartTransaction(ctx, &client.StartRequest{InstanceID: result.InstanceID, FlowID: flowID})
+	if err != nil {
+		t.Fatalf("StartTransaction() error = %v", err)
+	}
+	if again.Status != client.StatusRunning {
+		t.Errorf("status = %s, want running", again.Status)
+	}
+
+	// 4xx 不重试
+	_, err = c.StartTransaction(ctx, &client.StartRequest{FlowID: "missing"})
+	if !client.IsNotFound(err) {
+		t.Errorf("StartTransaction() error = %v, want not found", err)
+	}
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	result, err := c.StartTransaction(ctx, &client.StartRequest{FlowID: newFlow(t, srv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		srv.SetStatus(result.InstanceID, client.StatusSuccess)
+	}()
+	txn, err := c.WaitForCompletion(ctx, result.InstanceID, 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForCompletion() error = %v", err)
+	}
+	if txn.Status != client.StatusSuccess || txn.CompletedAt == nil {
+		t.Errorf("WaitForCompletion() = %+v", txn)
+	}
+
+	// 未结束时随 ctx 超时返回
+	result, _ = c.StartTransaction(ctx, &client.StartRequest{FlowID: txn.FlowID})
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForCompletion(timeout, result.InstanceID, 5*time.Millisecond); err == nil {
+		t.Error("WaitForCompletion() expected timeout error")
+	}
+}
+
+func TestSignal(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	c := srv.Client(client.WithOperator("alice"))
+	ctx := context.Background()
+
+	result, _ := c.StartTransaction(ctx, &client.StartRequest{FlowID: newFlow(t, srv)})
+	srv.SetStatus(result.InstanceID, client.StatusWaiting)
+	srv.SetTask(result.InstanceID, client.Task{TaskKey: "approve", Type: "wait", Status: client.StatusWaiting})
+
+	waiting, err := c.ListWaiting(ctx, nil)
+	if err != nil || len(waiting.List) != 1 {
+		t.Fatalf("ListWaiting() = %+v, %v", waiting, err)
+	}
+
+	if _, err := c.Signal(ctx, result.InstanceID, "approve", &client.Signal{Decision: "approve"}); err != nil {
+		t.Fatalf("Signal() error = %v", err)
+	}
+	_, err = c.Signal(ctx, result.InstanceID, "approve", &client.Signal{Decision: "approve"})
+	if !client.IsConflict(err) {
+		t.Errorf("Signal() error = %v, want conflict", err)
+	}
+
+	txn, _ := c.GetTransaction(ctx, result.InstanceID)
+	if task := txn.Task("approve"); task == nil || task.Status != client.StatusSuccess {
+		t.Errorf("task = %+v", task)
+	}
+}
@@ -0,0 +1,671 @@
+// Package clienttest 提供内存实现的 dist_task 服务端，供使用 client 包的服务编写单元测试。
+//
+//	srv := clienttest.NewServer()
+//	defer srv.Close()
+//	c := srv.Client()
+//	// ... 调用被测代码后
+//	srv.SetStatus(instanceID, client.StatusSuccess)
+//
+// 服务端不执行任务，事务启动后保持 pending，由测试通过 SetStatus / SetTask 推进状态。
+package clienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"dist_task/pkg/client"
+)
+
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	nextID       int64
+	flows        map[string]*client.Flow
+	transactions map[string]*client.Transaction
+	exceptions   map[int64]*client.Exception
+	bulkJobs     map[string]*client.BulkJob
+	failures     []int
+	requests     map[string]int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		flows:        make(map[string]*client.Flow),
+		transactions: make(map[string]*client.Transaction),
+		exceptions:   make(map[int64]*client.Exception),
+		bulkJobs:     make(map[string]*client.BulkJob),
+		requests:     make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/flows", s.createFlow)
+	mux.HandleFunc("GET /api/v1/flows", s.listFlows)
+	mux.HandleFunc("GET /api/v1/flows/{id}", s.getFlow)
+	mux.HandleFunc("POST /api/v1/transactions", s.startTransaction)
+	mux.HandleFunc("GET /api/v1/transactions/waiting", s.listWaiting)
+	mux.HandleFunc("GET /api/v1/transactions/{id}", s.getTransaction)
+	mux.HandleFunc("POST /api/v1/transactions/{id}/retry", s.retryTransaction)
+	mux.HandleFunc("POST /api/v1/transactions/{id}/signal/{task}", s.signalTransaction)
+	mux.HandleFunc("POST /api/v1/transactions/{id}/cancel", s.transition(client.StatusCancelled, client.StatusPending, client.StatusRunning, client.StatusWaiting, client.StatusPaused))
+	mux.HandleFunc("POST /api/v1/transactions/{id}/pause", s.transition(client.StatusPaused, client.StatusPending, client.StatusRunning, client.StatusWaiting))
+	mux.HandleFunc("POST /api/v1/transactions/{id}/resume", s.transition(client.StatusRunning, client.StatusPaused))
+	mux.HandleFunc("GET /api/v1/exceptions", s.listExceptions)
+	mux.HandleFunc("POST /api/v1/exceptions/{id}/handle", s.handleException)
+	mux.HandleFunc("POST /api/v1/exceptions/{id}/retry", s.retryException)
+	mux.HandleFunc("POST /api/v1/exceptions/bulk", s.bulkExceptions)
+	mux.HandleFunc("GET /api/v1/exceptions/bulk/{job_id}", s.getBulkJob)
+
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// Client 返回指向该服务端的客户端，默认关闭重试退避等待
+func (s *Server) Client(opts ...client.Option) *client.Client {
+	opts = append([]client.Option{client.WithRetry(3, time.Millisecond)}, opts...)
+	return client.New(s.URL, opts...)
+}
+
+// FailNext 让接下来的 n 个请求返回指定 HTTP 状态码，用于测试重试
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// Requests 返回某个路由收到的请求数，key 形如 "POST /api/v1/transactions"
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// AddFlow 直接写入流程，返回流程 ID
+func (s *Server) AddFlow(name string, def *client.FlowDefinition) string {
+	definition, _ := def.JSON()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	flow := &client.Flow{
+		ID:         s.newID(),
+		TenantID:   "default",
+		Name:       name,
+		FlowType:   "sequential",
+		Version:    1,
+		Definition: definition,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	s.flows[flow.ID] = flow
+	return flow.ID
+}
+
+// Transaction 返回事务的副本
+func (s *Server) Transaction(id string) (client.Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[id]
+	if !ok {
+		return client.Transaction{}, false
+	}
+	return copyTransaction(txn), true
+}
+
+// SetStatus 设置事务状态，结束状态同时写入 completed_at
+func (s *Server) SetStatus(id, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if txn, ok := s.transactions[id]; ok {
+		s.setStatus(txn, status)
+	}
+}
+
+// SetTask 写入或按 TaskKey 覆盖事务下的任务
+func (s *Server) SetTask(id string, task client.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[id]
+	if !ok {
+		return
+	}
+	task.GroupID = id
+	if task.ID == "" {
+		task.ID = s.newID()
+	}
+	if existing := txn.Task(task.TaskKey); existing != nil {
+		*existing = task
+		return
+	}
+	txn.Tasks = append(txn.Tasks, task)
+}
+
+// AddException 写入异常记录，返回记录 ID
+func (s *Server) AddException(exception client.Exception) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	exception.ID = s.nextID
+	if exception.TenantID == "" {
+		exception.TenantID = "default"
+	}
+	if exception.OccurredAt.IsZero() {
+		exception.OccurredAt = time.Now()
+	}
+	s.exceptions[exception.ID] = &exception
+	return exception.ID
+}
+
+// Exception 返回异常记录的副本
+func (s *Server) Exception(id int64) (client.Exception, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exception, ok := s.exceptions[id]
+	if !ok {
+		return client.Exception{}, false
+	}
+	return *exception, true
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := next.(*http.ServeMux).Handler(r)
+
+		s.mu.Lock()
+		s.requests[pattern]++
+		var status int
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			writeError(w, status, "injected failure")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) createFlow(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		FlowType    string `json:"flow_type"`
+		Definition  string `json:"definition"`
+		CreateUser  string `json:"create_user"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Name == "" || req.FlowType == "" || req.Definition == "" {
+		writeError(w, http.StatusBadRequest, "name, flow_type and definition are required")
+		return
+	}
+	var def client.FlowDefinition
+	if err := json.Unmarshal([]byte(req.Definition), &def); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid flow definition")
+		return
+	}
+	if err := def.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	flow := &client.Flow{
+		ID:          s.newID(),
+		TenantID:    "default",
+		Name:        req.Name,
+		Description: req.Description,
+		FlowType:    req.FlowType,
+		Version:     1,
+		Definition:  req.Definition,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreateUser:  req.CreateUser,
+		UpdatedUser: req.CreateUser,
+	}
+	s.flows[flow.ID] = flow
+	writeData(w, http.StatusOK, flow)
+}
+
+func (s *Server) listFlows(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flows := make([]client.Flow, 0, len(s.flows))
+	for _, flow := range s.flows {
+		flows = append(flows, *flow)
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].ID > flows[j].ID })
+	writeData(w, http.StatusOK, paginate(r, flows))
+}
+
+func (s *Server) getFlow(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.flows[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "flow not found")
+		return
+	}
+	writeData(w, http.StatusOK, flow)
+}
+
+func (s *Server) startTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		InstanceID string                 `json:"instance_id"`
+		FlowID     string                 `json:"flow_id"`
+		Params     map[string]interface{} `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.InstanceID == "" || req.FlowID == "" {
+		writeError(w, http.StatusBadRequest, "instance_id and flow_id are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if txn, ok := s.transactions[req.InstanceID]; ok {
+		writeData(w, http.StatusOK, map[string]interface{}{
+			"instance_id": txn.InstanceID,
+			"status":      txn.Status,
+		})
+		return
+	}
+	if _, ok := s.flows[req.FlowID]; !ok {
+		writeError(w, http.StatusNotFound, "flow not found")
+		return
+	}
+
+	txn := &client.Transaction{
+		InstanceID: req.InstanceID,
+		FlowID:     req.FlowID,
+		Status:     client.StatusPending,
+		Tasks:      []client.Task{},
+		CreatedAt:  time.Now(),
+	}
+	s.transactions[txn.InstanceID] = txn
+	writeData(w, http.StatusOK, client.StartResult{
+		InstanceID: txn.InstanceID,
+		FlowID:     txn.FlowID,
+		Status:     txn.Status,
+		CreatedAt:  &txn.CreatedAt,
+	})
+}
+
+func (s *Server) getTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "transaction not found")
+		return
+	}
+	writeData(w, http.StatusOK, txn)
+}
+
+func (s *Server) listWaiting(w http.ResponseWriter, r *http.Request) {
+	flowID, taskKey := r.URL.Query().Get("flow_id"), r.URL.Query().Get("task")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	waiting := []client.WaitingTask{}
+	for _, txn := range s.transactions {
+		if flowID != "" && txn.FlowID != flowID {
+			continue
+		}
+		for _, task := range txn.Tasks {
+			if task.Type != "wait" || task.Status != client.StatusWaiting {
+				continue
+			}
+			if taskKey != "" && task.TaskKey != taskKey {
+				continue
+			}
+			waiting = append(waiting, client.WaitingTask{
+				InstanceID:   txn.InstanceID,
+				Task:         task.TaskKey,
+				TaskID:       task.ID,
+				WaitingSince: task.StartedAt,
+				ResumeAt:     task.ResumeAt,
+			})
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].TaskID < waiting[j].TaskID })
+	writeData(w, http.StatusOK, paginate(r, waiting))
+}
+
+func (s *Server) retryTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "instance not found")
+		return
+	}
+	if txn.Status != client.StatusFailed {
+		writeError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+	s.setStatus(txn, client.StatusRunning)
+	writeData(w, http.StatusOK, client.ActionResult{InstanceID: txn.InstanceID, Status: txn.Status})
+}
+
+func (s *Server) signalTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Decision string                 `json:"decision"`
+		Payload  map[string]interface{} `json:"payload"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Decision != "approve" && req.Decision != "reject" {
+		writeError(w, http.StatusBadRequest, "decision must be approve or reject")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.transactions[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "instance not found")
+		return
+	}
+	task := txn.Task(r.PathValue("task"))
+	if task == nil {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	if task.Status != client.StatusWaiting {
+		writeError(w, http.StatusConflict, "task is not waiting for signal")
+		return
+	}
+
+	now := time.Now()
+	output, _ := json.Marshal(map[string]interface{}{"decision": req.Decision, "payload": req.Payload})
+	task.OutputData = string(output)
+	task.CompletedAt = &now
+	task.ResumeAt = nil
+	if req.Decision == "approve" {
+		task.Status = client.StatusSuccess
+		s.setStatus(txn, client.StatusRunning)
+	} else {
+		task.Status = client.StatusFailed
+		s.setStatus(txn, client.StatusFailed)
+	}
+	writeData(w, http.StatusOK, client.ActionResult{InstanceID: txn.InstanceID, Status: txn.Status})
+}
+
+// transition 返回把事务从 from 中的状态切换为 to 的处理函数
+func (s *Server) transition(to string, from ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		txn, ok := s.transactions[r.PathValue("id")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "instance not found")
+			return
+		}
+		for _, status := range from {
+			if txn.Status == status {
+				s.setStatus(txn, to)
+				writeData(w, http.StatusOK, client.ActionResult{InstanceID: txn.InstanceID, Status: txn.Status})
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("instance is %s", txn.Status))
+	}
+}
+
+func (s *Server) listExceptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exceptions := []client.Exception{}
+	for _, exception := range s.exceptions {
+		if value := query.Get("handled"); value != "" && exception.Handled != (value == "true") {
+			continue
+		}
+		if value := query.Get("group_id"); value != "" && exception.GroupID != value {
+			continue
+		}
+		if value := query.Get("task_name"); value != "" && exception.TaskName != value {
+			continue
+		}
+		if value := query.Get("error_code"); value != "" && exception.ErrorCode != value {
+			continue
+		}
+		if value := query.Get("assignee"); value != "" && exception.Assignee != value {
+			continue
+		}
+		exceptions = append(exceptions, *exception)
+	}
+	sort.Slice(exceptions, func(i, j int) bool { return exceptions[i].ID > exceptions[j].ID })
+	writeData(w, http.StatusOK, paginate(r, exceptions))
+}
+
+func (s *Server) handleException(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Remark    string `json:"remark"`
+		HandledBy string `json:"handled_by"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exception, ok := s.exception(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "exception not found")
+		return
+	}
+	now := time.Now()
+	exception.Handled = true
+	exception.HandledBy = req.HandledBy
+	exception.HandledAt = &now
+	exception.HandledRemark = req.Remark
+	writeData(w, http.StatusOK, map[string]interface{}{
+		"exception_id": r.PathValue("id"),
+		"handled":      true,
+	})
+}
+
+func (s *Server) retryException(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exception, ok := s.exception(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "exception not found")
+		return
+	}
+	nextAt := time.Now()
+	exception.RetryTimes++
+	exception.RetryNextAt = &nextAt
+	writeData(w, http.StatusOK, client.RetryExceptionResult{
+		ExceptionID:    r.PathValue("id"),
+		RetryScheduled: true,
+		RetryNextAt:    &nextAt,
+	})
+}
+
+// bulkExceptions 立即执行批量操作，返回的任务已是完成状态
+func (s *Server) bulkExceptions(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action   string                 `json:"action"`
+		Filter   client.ExceptionFilter `json:"filter"`
+		Remark   string                 `json:"remark"`
+		Assignee string                 `json:"assignee"`
+		Operator string                 `json:"operator"`
+		DryRun   bool                   `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch req.Action {
+	case "retry", "handle":
+	case "reassign":
+		if req.Assignee == "" {
+			writeError(w, http.StatusBadRequest, "assignee is required for reassign")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported bulk action %q", req.Action))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []*client.Exception
+	for _, exception := range s.exceptions {
+		if matchException(exception, &req.Filter) {
+			matched = append(matched, exception)
+		}
+	}
+	if req.DryRun {
+		writeData(w, http.StatusOK, client.BulkResult{Action: req.Action, Matched: int64(len(matched))})
+		return
+	}
+
+	now := time.Now()
+	for _, exception := range matched {
+		switch req.Action {
+		case "retry":
+			exception.RetryTimes++
+			exception.RetryNextAt = &now
+		case "handle":
+			exception.Handled = true
+			exception.HandledBy = req.Operator
+			exception.HandledAt = &now
+			exception.HandledRemark = req.Remark
+		case "reassign":
+			exception.Assignee = req.Assignee
+		}
+	}
+
+	filter, _ := json.Marshal(req.Filter)
+	total := int64(len(matched))
+	job := &client.BulkJob{
+		ID:          s.newID(),
+		Action:      req.Action,
+		Filter:      string(filter),
+		Status:      "completed",
+		Total:       total,
+		Processed:   total,
+		Succeeded:   total,
+		CreatedBy:   req.Operator,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CompletedAt: &now,
+	}
+	s.bulkJobs[job.ID] = job
+	writeData(w, http.StatusAccepted, client.BulkResult{JobID: job.ID, Action: job.Action, Matched: total})
+}
+
+func (s *Server) getBulkJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.bulkJobs[r.PathValue("job_id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "bulk job not found")
+		return
+	}
+	writeData(w, http.StatusOK, job)
+}
+
+func (s *Server) exception(r *http.Request) (*client.Exception, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	exception, ok := s.exceptions[id]
+	return exception, ok
+}
+
+func matchException(e *client.Exception, f *client.ExceptionFilter) bool {
+	switch {
+	case f.Handled != nil && e.Handled != *f.Handled,
+		f.GroupID != "" && e.GroupID != f.GroupID,
+		f.TaskName != "" && e.TaskName != f.TaskName,
+		f.ErrorType != nil && e.ErrorType != *f.ErrorType,
+		f.ErrorCode != "" && e.ErrorCode != f.ErrorCode,
+		f.RetryStrategy != "" && e.RetryStrategy != f.RetryStrategy,
+		f.Assignee != "" && e.Assignee != f.Assignee,
+		f.Since != nil && e.OccurredAt.Before(*f.Since),
+		f.Until != nil && e.OccurredAt.After(*f.Until):
+		return false
+	}
+	return true
+}
+
+func (s *Server) setStatus(txn *client.Transaction, status string) {
+	txn.Status = status
+	if txn.Done() {
+		now := time.Now()
+		txn.CompletedAt = &now
+	} else {
+		txn.CompletedAt = nil
+	}
+}
+
+// newID 调用方需持有 s.mu
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.FormatInt(s.nextID, 10)
+}
+
+func copyTransaction(txn *client.Transaction) client.Transaction {
+	cp := *txn
+	cp.Tasks = append([]client.Task(nil), txn.Tasks...)
+	return cp
+}
+
+func paginate[T any](r *http.Request, items []T) client.Page[T] {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	start := min((page-1)*pageSize, len(items))
+	end := min(start+pageSize, len(items))
+	return client.Page[T]{
+		List: items[start:end],
+		Pagination: client.Pagination{
+			Page:     page,
+			PageSize: pageSize,
+			Total:    int64(len(items)),
+		},
+	}
+}
+
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, status, map[string]interface{}{"code": 0, "message": "success", "data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"code": status, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
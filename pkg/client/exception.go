@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ExceptionQuery struct {
+	ExceptionFilter
+	Page     int
+	PageSize int
+}
+
+func (c *Client) ListExceptions(ctx context.Context, q *ExceptionQuery) (*Page[Exception], error) {
+	if q == nil {
+		q = &ExceptionQuery{}
+	}
+	query := pageQuery(q.Page, q.PageSize)
+	f := q.ExceptionFilter
+	if f.Handled != nil {
+		query.Set("handled", strconv.FormatBool(*f.Handled))
+	}
+	if f.ErrorType != nil {
+		query.Set("error_type", strconv.Itoa(*f.ErrorType))
+	}
+	if f.Since != nil {
+		query.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if f.Until != nil {
+		query.Set("until", f.Until.Format(time.RFC3339))
+	}
+	for key, value := range map[string]string{
+		"flow_id":        f.FlowID,
+		"group_id":       f.GroupID,
+		"task_name":      f.TaskName,
+		"error_code":     f.ErrorCode,
+		"retry_strategy": f.RetryStrategy,
+		"assignee":       f.Assignee,
+		"q":              f.Query,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	var exceptions Page[Exception]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/exceptions", query: query, retry: true}, &exceptions); err != nil {
+		return nil, err
+	}
+	return &exceptions, nil
+}
+
+// HandleException 将异常标记为已处理
+func (c *Client) HandleException(ctx context.Context, id int64, remark string) error {
+	body := map[string]interface{}{
+		"remark":     remark,
+		"handled_by": c.operator,
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: exceptionPath(id) + "/handle", body: body}, nil)
+}
+
+// RetryException 按异常记录重新调度失败的任务
+func (c *Client) RetryException(ctx context.Context, id int64) (*RetryExceptionResult, error) {
+	body := map[string]interface{}{"operator": c.operator}
+	var result RetryExceptionResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: exceptionPath(id) + "/retry", body: body}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type BulkRequest struct {
+	Action   string // retry / handle / reassign
+	Filter   ExceptionFilter
+	Remark   string
+	Assignee string
+	DryRun   bool // 只返回匹配数量，不执行
+}
+
+// BulkExceptions 提交批量操作，执行进度通过 GetBulkJob 查询
+func (c *Client) BulkExceptions(ctx context.Context, req *BulkRequest) (*BulkResult, error) {
+	body := map[string]interface{}{
+		"action":   req.Action,
+		"filter":   req.Filter,
+		"remark":   req.Remark,
+		"assignee": req.Assignee,
+		"operator": c.operator,
+		"dry_run":  req.DryRun,
+	}
+	var result BulkResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/exceptions/bulk", body: body}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetBulkJob(ctx context.Context, jobID string) (*BulkJob, error) {
+	var job BulkJob
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/exceptions/bulk/" + url.PathEscape(jobID), retry: true}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func exceptionPath(id int64) string {
+	return fmt.Sprintf("/exceptions/%d", id)
+}
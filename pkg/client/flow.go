@@ -0,0 +1,272 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// FlowDefinition 流程定义，JSON 结构与服务端一致，见 docs/flow-definition.md
+type FlowDefinition struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Tasks       []FlowTask `json:"tasks"`
+}
+
+type FlowTask struct {
+	ID          string          `json:"id"`
+	TaskName    string          `json:"task_name"`
+	Description string          `json:"description"`
+	DependsOn   []string        `json:"depends_on"`
+	Config      json.RawMessage `json:"config"`
+	Retry       *RetryConfig    `json:"retry,omitempty"`
+	Compensate  *CompensateTask `json:"compensate,omitempty"`
+}
+
+type CompensateTask struct {
+	TaskName string          `json:"task_name"`
+	Config   json.RawMessage `json:"config"`
+}
+
+type RetryConfig struct {
+	Strategy    string `json:"strategy"`               // manual / auto / no_retry
+	MaxAttempts int    `json:"max_attempts,omitempty"` // 最大重试次数
+	Interval    int    `json:"interval,omitempty"`     // 重试间隔（秒）
+}
+
+// AutoRetry 自动重试，interval 单位为秒
+func AutoRetry(maxAttempts, interval int) *RetryConfig {
+	return &RetryConfig{Strategy: "auto", MaxAttempts: maxAttempts, Interval: interval}
+}
+
+func ManualRetry() *RetryConfig {
+	return &RetryConfig{Strategy: "manual"}
+}
+
+func NoRetry() *RetryConfig {
+	return &RetryConfig{Strategy: "no_retry"}
+}
+
+// Validate 校验任务 ID 唯一、依赖存在且无环（与服务端创建 flow 时的规则一致），另外要求 task_name 非空
+func (d *FlowDefinition) Validate() error {
+	tasks := make(map[string]*FlowTask, len(d.Tasks))
+	for i := range d.Tasks {
+		t := &d.Tasks[i]
+		if t.ID == "" {
+			return fmt.Errorf("flow %s: task id is required", d.Name)
+		}
+		if t.TaskName == "" {
+			return fmt.Errorf("flow %s: task %s task_name is required", d.Name, t.ID)
+		}
+		if _, ok := tasks[t.ID]; ok {
+			return fmt.Errorf("flow %s: duplicate task id %s", d.Name, t.ID)
+		}
+		tasks[t.ID] = t
+	}
+
+	for _, t := range d.Tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := tasks[dep]; !ok {
+				return fmt.Errorf("flow %s: task %s depends on unknown task %s", d.Name, t.ID, dep)
+			}
+		}
+	}
+
+	// 0: 未访问，1: 访问中，2: 已完成
+	visited := make(map[string]int, len(tasks))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch visited[id] {
+		case 1:
+			return fmt.Errorf("flow %s: dependency cycle detected at task %s", d.Name, id)
+		case 2:
+			return nil
+		}
+		visited[id] = 1
+		for _, dep := range tasks[id].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visited[id] = 2
+		return nil
+	}
+
+	for _, t := range d.Tasks {
+		if err := visit(t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSON 返回 CreateFlow 接口 definition 字段使用的 JSON
+func (d *FlowDefinition) JSON() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// FlowBuilder 在代码中构造流程定义：
+//
+//	flow := client.NewFlow("order_create")
+//	flow.Task("deduct", "http").Config(deductConfig).Retry(client.AutoRetry(3, 10))
+//	flow.Task("notify", "mq").After("deduct").Config(notifyConfig)
+//	def, err := flow.Build()
+type FlowBuilder struct {
+	def   FlowDefinition
+	tasks []*TaskBuilder
+}
+
+func NewFlow(name string) *FlowBuilder {
+	return &FlowBuilder{def: FlowDefinition{Name: name}}
+}
+
+func (b *FlowBuilder) Describe(description string) *FlowBuilder {
+	b.def.Description = description
+	return b
+}
+
+// Task 添加任务，id 为流程内唯一的任务 ID，taskName 为执行器类型（http / db / mq / wait ...）
+func (b *FlowBuilder) Task(id, taskName string) *TaskBuilder {
+	t := &TaskBuilder{task: FlowTask{ID: id, TaskName: taskName}}
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// Build 生成并校验流程定义
+func (b *FlowBuilder) Build() (*FlowDefinition, error) {
+	def := FlowDefinition{
+		Name:        b.def.Name,
+		Description: b.def.Description,
+		Tasks:       make([]FlowTask, 0, len(b.tasks)),
+	}
+	for _, t := range b.tasks {
+		if t.err != nil {
+			return nil, fmt.Errorf("flow %s: task %s: %w", def.Name, t.task.ID, t.err)
+		}
+		def.Tasks = append(def.Tasks, t.task)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+type TaskBuilder struct {
+	task FlowTask
+	err  error
+}
+
+func (t *TaskBuilder) Describe(description string) *TaskBuilder {
+	t.task.Description = description
+	return t
+}
+
+// After 声明依赖的上游任务
+func (t *TaskBuilder) After(deps ...string) *TaskBuilder {
+	t.task.DependsOn = append(t.task.DependsOn, deps...)
+	return t
+}
+
+// Config 设置执行器配置，v 可以是 map、结构体或 json.RawMessage
+func (t *TaskBuilder) Config(v interface{}) *TaskBuilder {
+	t.task.Config, t.err = marshalConfig(v, t.err)
+	return t
+}
+
+func (t *TaskBuilder) Retry(retry *RetryConfig) *TaskBuilder {
+	t.task.Retry = retry
+	return t
+}
+
+// Compensate 设置事务回滚时执行的补偿任务
+func (t *TaskBuilder) Compensate(taskName string, config interface{}) *TaskBuilder {
+	compensate := &CompensateTask{TaskName: taskName}
+	compensate.Config, t.err = marshalConfig(config, t.err)
+	t.task.Compensate = compensate
+	return t
+}
+
+func marshalConfig(v interface{}, prev error) (json.RawMessage, error) {
+	if v == nil {
+		return nil, prev
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, prev
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config failed: %w", err)
+	}
+	return data, prev
+}
+
+// Param 返回全局参数占位符 ${params.<path>}
+func Param(path string) string {
+	return "${params." + path + "}"
+}
+
+// Input 返回当前任务入参占位符 ${input.<path>}
+func Input(path string) string {
+	return "${input." + path + "}"
+}
+
+// Output 返回上游任务输出占位符 ${outputs.<task>.<path>}
+func Output(task, path string) string {
+	return "${outputs." + task + "." + path + "}"
+}
+
+type CreateFlowRequest struct {
+	Name        string
+	Description string
+	FlowType    string
+	Definition  *FlowDefinition
+}
+
+// CreateFlow 创建流程，Definition 会先在本地校验
+func (c *Client) CreateFlow(ctx context.Context, req *CreateFlowRequest) (*Flow, error) {
+	if req.Definition == nil {
+		return nil, fmt.Errorf("flow definition is required")
+	}
+	if err := req.Definition.Validate(); err != nil {
+		return nil, err
+	}
+	definition, err := req.Definition.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+		"flow_type":   req.FlowType,
+		"definition":  definition,
+		"create_user": c.operator,
+	}
+	var flow Flow
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/flows", body: body}, &flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func (c *Client) GetFlow(ctx context.Context, id string) (*Flow, error) {
+	var flow Flow
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/flows/" + url.PathEscape(id), retry: true}, &flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func (c *Client) ListFlows(ctx context.Context, page, pageSize int) (*Page[Flow], error) {
+	var flows Page[Flow]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/flows", query: pageQuery(page, pageSize), retry: true}, &flows); err != nil {
+		return nil, err
+	}
+	return &flows, nil
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFlowBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func() *FlowBuilder
+		wantErr string
+	}{
+		{
+			name: "valid",
+			build: func() *FlowBuilder {
+				f := NewFlow("order")
+				f.Task("deduct", "http").Config(map[string]interface{}{"url": "http://stock/deduct"})
+				f.Task("notify", "mq").After("deduct")
+				return f
+			},
+		},
+		{
+			name: "duplicate id",
+			build: func() *FlowBuilder {
+				f := NewFlow("order")
+				f.Task("deduct", "http")
+				f.Task("deduct", "http")
+				return f
+			},
+			wantErr: "duplicate task id deduct",
+		},
+		{
+			name: "unknown dependency",
+			build: func() *FlowBuilder {
+				f := NewFlow("order")
+				f.Task("notify", "mq").After("deduct")
+				return f
+			},
+			wantErr: "depends on unknown task deduct",
+		},
+		{
+			name: "cycle",
+			build: func() *FlowBuilder {
+				f := NewFlow("order")
+				f.Task("a", "http").After("b")
+				f.Task("b", "http").After("a")
+				return f
+			},
+			wantErr: "dependency cycle",
+		},
+		{
+			name: "unmarshalable config",
+			build: func() *FlowBuilder {
+				f := NewFlow("order")
+				f.Task("a", "http").Config(map[string]interface{}{"ch": make(chan int)})
+				return f
+			},
+			wantErr: "marshal config failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.build().Build()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlowDefinition_JSON(t *testing.T) {
+	f := NewFlow("order").Describe("下单")
+	f.Task("deduct", "http").
+		Config(map[string]interface{}{"order_id": Param("order_id")}).
+		Retry(AutoRetry(3, 10)).
+		Compensate("http", map[string]interface{}{"id": Output("deduct", "id")})
+
+	def, err := f.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	data, err := def.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	json.Unmarshal([]byte(data), &got)
+	task := got["tasks"].([]interface{})[0].(map[string]interface{})
+	if task["config"].(map[string]interface{})["order_id"] != "${params.order_id}" {
+		t.Errorf("config = %v", task["config"])
+	}
+	if task["retry"].(map[string]interface{})["strategy"] != "auto" {
+		t.Errorf("retry = %v", task["retry"])
+	}
+	if task["compensate"].(map[string]interface{})["config"].(map[string]interface{})["id"] != "${outputs.deduct.id}" {
+		t.Errorf("compensate = %v", task["compensate"])
+	}
+}
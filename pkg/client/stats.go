@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// 统计接口在服务端仍处于开发中，未上线的服务端返回 404（IsNotFound 为 true）
+
+func (c *Client) Overview(ctx context.Context) (*Overview, error) {
+	var overview Overview
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/stats/overview", retry: true}, &overview); err != nil {
+		return nil, err
+	}
+	return &overview, nil
+}
+
+func (c *Client) FlowStats(ctx context.Context, flowID string) (*FlowStats, error) {
+	var stats FlowStats
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/stats/flows/" + url.PathEscape(flowID), retry: true}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
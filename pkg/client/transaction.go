@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultPollInterval = time.Second
+
+type StartRequest struct {
+	// InstanceID 事务实例 ID，同一 ID 重复启动只会创建一个实例。
+	// 为空时自动生成，网络错误或服务端错误重试时沿用同一 ID
+	InstanceID string
+	FlowID     string
+	Params     map[string]interface{}
+}
+
+// StartTransaction 启动事务。实例 ID 保证了重放安全，失败时按 WithRetry 的设置自动重试
+func (c *Client) StartTransaction(ctx context.Context, req *StartRequest) (*StartResult, error) {
+	instanceID := req.InstanceID
+	if instanceID == "" {
+		instanceID = NewInstanceID()
+	}
+
+	body := map[string]interface{}{
+		"instance_id": instanceID,
+		"flow_id":     req.FlowID,
+		"params":      req.Params,
+	}
+	var result StartResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/transactions", body: body, retry: true}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// NewInstanceID 生成随机的事务实例 ID
+func NewInstanceID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (c *Client) GetTransaction(ctx context.Context, id string) (*Transaction, error) {
+	var txn Transaction
+	if err := c.do(ctx, request{method: http.MethodGet, path: transactionPath(id), retry: true}, &txn); err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// WaitForCompletion 轮询事务直到结束（success / failed / cancelled），interval 为 0 时每秒查询一次。
+// 结束前 ctx 超时或取消时返回 ctx 的错误
+func (c *Client) WaitForCompletion(ctx context.Context, id string, interval time.Duration) (*Transaction, error) {
+	return c.WaitForStatus(ctx, id, interval, StatusSuccess, StatusFailed, StatusCancelled)
+}
+
+// WaitForStatus 轮询事务直到进入任一指定状态，如等待审批时使用 StatusWaiting
+func (c *Client) WaitForStatus(ctx context.Context, id string, interval time.Duration, statuses ...string) (*Transaction, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	for {
+		txn, err := c.GetTransaction(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if txn.Status == status {
+				return txn, nil
+			}
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return txn, err
+		}
+	}
+}
+
+// RetryTransaction 重试失败的事务，fromTask 为空时从失败的任务开始
+func (c *Client) RetryTransaction(ctx context.Context, id, fromTask string) (*ActionResult, error) {
+	body := map[string]interface{}{
+		"from_task": fromTask,
+		"operator":  c.operator,
+	}
+	return c.action(ctx, transactionPath(id)+"/retry", body)
+}
+
+type Signal struct {
+	Decision string                 // approve / reject
+	Payload  map[string]interface{} // 作为 wait 任务的输出
+}
+
+// Signal 向等待中的 wait 任务发送审批决策
+func (c *Client) Signal(ctx context.Context, id, task string, signal *Signal) (*ActionResult, error) {
+	body := map[string]interface{}{
+		"decision": signal.Decision,
+		"payload":  signal.Payload,
+		"operator": c.operator,
+	}
+	return c.action(ctx, transactionPath(id)+"/signal/"+url.PathEscape(task), body)
+}
+
+// Cancel 取消事务，compensate 为 true 时对已成功的任务执行补偿
+func (c *Client) Cancel(ctx context.Context, id string, compensate bool) (*ActionResult, error) {
+	body := map[string]interface{}{
+		"operator":   c.operator,
+		"compensate": compensate,
+	}
+	return c.action(ctx, transactionPath(id)+"/cancel", body)
+}
+
+func (c *Client) Pause(ctx context.Context, id string) (*ActionResult, error) {
+	return c.action(ctx, transactionPath(id)+"/pause", map[string]interface{}{"operator": c.operator})
+}
+
+func (c *Client) Resume(ctx context.Context, id string) (*ActionResult, error) {
+	return c.action(ctx, transactionPath(id)+"/resume", map[string]interface{}{"operator": c.operator})
+}
+
+func (c *Client) action(ctx context.Context, path string, body interface{}) (*ActionResult, error) {
+	var result ActionResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: body}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type WaitingQuery struct {
+	FlowID   string
+	Task     string
+	Page     int
+	PageSize int
+}
+
+// ListWaiting 列出等待审批的任务
+func (c *Client) ListWaiting(ctx context.Context, q *WaitingQuery) (*Page[WaitingTask], error) {
+	if q == nil {
+		q = &WaitingQuery{}
+	}
+	query := pageQuery(q.Page, q.PageSize)
+	if q.FlowID != "" {
+		query.Set("flow_id", q.FlowID)
+	}
+	if q.Task != "" {
+		query.Set("task", q.Task)
+	}
+
+	var waiting Page[WaitingTask]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/transactions/waiting", query: query, retry: true}, &waiting); err != nil {
+		return nil, err
+	}
+	return &waiting, nil
+}
+
+func transactionPath(id string) string {
+	return fmt.Sprintf("/transactions/%s", url.PathEscape(id))
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 事务（实例）状态
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusWaiting   = "waiting"
+	StatusPaused    = "paused"
+	StatusSuccess   = "success"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+type Pagination struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// Page 分页列表
+type Page[T any] struct {
+	List       []T        `json:"list"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type Flow struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	FlowType    string    `json:"flow_type"`
+	Version     int       `json:"version"`
+	Definition  string    `json:"definition"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreateUser  string    `json:"create_user"`
+	UpdatedUser string    `json:"updated_user"`
+}
+
+// ParseDefinition 解析 Definition 字段
+func (f *Flow) ParseDefinition() (*FlowDefinition, error) {
+	var def FlowDefinition
+	if err := json.Unmarshal([]byte(f.Definition), &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+type Transaction struct {
+	InstanceID  string     `json:"instance_id"`
+	FlowID      string     `json:"flow_id"`
+	Status      string     `json:"status"`
+	Tasks       []Task     `json:"tasks"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// Done 事务是否已结束（success / failed / cancelled）
+func (t *Transaction) Done() bool {
+	switch t.Status {
+	case StatusSuccess, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Task 按 flow 中的任务 ID 查找任务，不存在时返回 nil
+func (t *Transaction) Task(key string) *Task {
+	for i := range t.Tasks {
+		if t.Tasks[i].TaskKey == key {
+			return &t.Tasks[i]
+		}
+	}
+	return nil
+}
+
+type Task struct {
+	ID             string     `json:"id"`
+	GroupID        string     `json:"group_id"`
+	TaskKey        string     `json:"task_key"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	MaxRetry       int        `json:"max_retry"`
+	RetryCount     int        `json:"retry_count"`
+	Config         string     `json:"config"`
+	InputData      string     `json:"input_data"`
+	OutputData     string     `json:"output_data"`
+	ErrorMessage   string     `json:"error_message"`
+	ResumeAt       *time.Time `json:"resume_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	StartedAt      *time.Time `json:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+// Output 把任务输出解析到 v
+func (t *Task) Output(v interface{}) error {
+	if t.OutputData == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(t.OutputData), v)
+}
+
+// StartResult 启动事务的结果。重复启动同一实例 ID 时只返回 InstanceID 和当前 Status
+type StartResult struct {
+	InstanceID string     `json:"instance_id"`
+	FlowID     string     `json:"flow_id"`
+	Status     string     `json:"status"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// ActionResult 重试、审批、取消、暂停、恢复等操作的结果
+type ActionResult struct {
+	InstanceID string `json:"instance_id"`
+	Status     string `json:"status"`
+}
+
+type WaitingTask struct {
+	InstanceID   string     `json:"instance_id"`
+	Task         string     `json:"task"`
+	TaskID       string     `json:"task_id"`
+	WaitingSince *time.Time `json:"waiting_since"`
+	ResumeAt     *time.Time `json:"resume_at"`
+}
+
+type Exception struct {
+	ID            int64      `json:"id"`
+	TenantID      string     `json:"tenant_id"`
+	GroupID       string     `json:"group_id"`
+	GroupName     string     `json:"group_name"`
+	TaskID        string     `json:"task_id"`
+	TaskName      string     `json:"task_name"`
+	ErrorType     int        `json:"error_type"`
+	ErrorCode     string     `json:"error_code"`
+	ErrorMessage  string     `json:"error_message"`
+	StackTrace    string     `json:"stack_trace"`
+	RetryStrategy string     `json:"retry_strategy"`
+	RetryTimes    int        `json:"retry_times"`
+	RetryMax      int        `json:"retry_max"`
+	RetryInterval int        `json:"retry_interval"`
+	RetryNextAt   *time.Time `json:"retry_next_at"`
+	Handled       bool       `json:"handled"`
+	HandledBy     string     `json:"handled_by"`
+	HandledAt     *time.Time `json:"handled_at"`
+	HandledRemark string     `json:"handled_remark"`
+	Assignee      string     `json:"assignee"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+}
+
+// ExceptionFilter 异常筛选条件，与 GET /exceptions 的查询参数及批量操作的 filter 对应
+type ExceptionFilter struct {
+	Handled       *bool      `json:"handled,omitempty"`
+	FlowID        string     `json:"flow_id,omitempty"`
+	GroupID       string     `json:"group_id,omitempty"`
+	TaskName      string     `json:"task_name,omitempty"`
+	ErrorType     *int       `json:"error_type,omitempty"`
+	ErrorCode     string     `json:"error_code,omitempty"`
+	RetryStrategy string     `json:"retry_strategy,omitempty"`
+	Assignee      string     `json:"assignee,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+	Query         string     `json:"q,omitempty"` // 错误信息全文检索
+}
+
+type RetryExceptionResult struct {
+	ExceptionID    string     `json:"exception_id"`
+	RetryScheduled bool       `json:"retry_scheduled"`
+	RetryNextAt    *time.Time `json:"retry_next_at"`
+}
+
+// BulkResult 批量操作的提交结果，DryRun 时 JobID 为空
+type BulkResult struct {
+	JobID   string `json:"job_id"`
+	Action  string `json:"action"`
+	Matched int64  `json:"matched"`
+}
+
+type BulkJob struct {
+	ID           string     `json:"id"`
+	Action       string     `json:"action"`
+	Filter       string     `json:"filter"`
+	Params       string     `json:"params"`
+	Status       string     `json:"status"`
+	Total        int64      `json:"total"`
+	Processed    int64      `json:"processed"`
+	Succeeded    int64      `json:"succeeded"`
+	Failed       int64      `json:"failed"`
+	ErrorMessage string     `json:"error_message"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+}
+
+type Overview struct {
+	TotalTransactions int64   `json:"total_transactions"`
+	SuccessRate       float64 `json:"success_rate"`
+	AvgDuration       float64 `json:"avg_duration"` // 秒
+	ExceptionsToday   int64   `json:"exceptions_today"`
+}
+
+type FlowStats struct {
+	FlowID       string `json:"flow_id"`
+	TotalRuns    int64  `json:"total_runs"`
+	SuccessCount int64  `json:"success_count"`
+	FailedCount  int64  `json:"failed_count"`
+}
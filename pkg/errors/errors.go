@@ -11,4 +11,8 @@ var (
 	ErrFlowNotFound      = errors.New("flow not found")
 	ErrExecutionFailed   = errors.New("execution failed")
 	ErrParseParamsFailed = errors.New("parse params failed")
+	ErrTaskNotWaiting    = errors.New("task not waiting")
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrInstancePaused    = errors.New("instance paused")
+	ErrLeaseLost         = errors.New("lease lost")
 )
@@ -0,0 +1,182 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Category 错误分类，持久化到 exception_record.error_type
+type Category int
+
+const (
+	CategoryUnknown      Category = iota + 1
+	CategoryTimeout               // 超时
+	CategoryConnection            // 连接失败
+	CategoryClient                // HTTP 4xx
+	CategoryServer                // HTTP 5xx
+	CategoryBroker                // MQ Broker 错误
+	CategoryConstraint            // 数据库约束冲突
+	CategoryValidation            // 入参校验失败
+	CategoryConfig                // 任务配置错误
+	CategoryCircuitOpen           // 下游熔断中，请求未发出
+	CategoryBulkheadFull          // 下游并发已满，请求未发出
+)
+
+var categories = map[Category]struct {
+	name      string
+	retryable bool
+}{
+	CategoryUnknown:      {"unknown", true},
+	CategoryTimeout:      {"timeout", true},
+	CategoryConnection:   {"connection", true},
+	CategoryClient:       {"client", false},
+	CategoryServer:       {"server", true},
+	CategoryBroker:       {"broker", true},
+	CategoryConstraint:   {"constraint", false},
+	CategoryValidation:   {"validation", false},
+	CategoryConfig:       {"config", false},
+	CategoryCircuitOpen:  {"circuit_open", true},
+	CategoryBulkheadFull: {"bulkhead_full", true},
+}
+
+func (c Category) String() string {
+	if info, ok := categories[c]; ok {
+		return info.name
+	}
+	return categories[CategoryUnknown].name
+}
+
+// Retryable 该分类的错误默认是否可以自动重试
+func (c Category) Retryable() bool {
+	if info, ok := categories[c]; ok {
+		return info.retryable
+	}
+	return true
+}
+
+const (
+	CodeUnknown           = "UNKNOWN"
+	CodeTimeout           = "TIMEOUT"
+	CodeConnectionRefused = "CONNECTION_REFUSED"
+	CodeConnectionFailed  = "CONNECTION_FAILED"
+	CodeBrokerError       = "MQ_BROKER_ERROR"
+	CodeDuplicateKey      = "DB_DUPLICATE_KEY"
+	CodeForeignKey        = "DB_FOREIGN_KEY"
+	CodeNotNull           = "DB_NOT_NULL"
+	CodeCheckConstraint   = "DB_CHECK_CONSTRAINT"
+	CodeDBError           = "DB_ERROR"
+	CodeVersionConflict   = "DB_VERSION_CONFLICT"
+	CodeValidationFailed  = "VALIDATION_FAILED"
+	CodeConfigInvalid     = "CONFIG_INVALID"
+	CodeUnsupportedType   = "UNSUPPORTED_TASK_TYPE"
+	CodeCircuitOpen       = "CIRCUIT_OPEN"
+	CodeBulkheadFull      = "BULKHEAD_FULL"
+	CodeAssertionFailed   = "HTTP_ASSERTION_FAILED"
+	CodeTransformFailed   = "TRANSFORM_FAILED"
+	CodeCommandDisabled   = "COMMAND_DISABLED"
+	CodeWorkerFailed      = "WORKER_FAILED"
+)
+
+// Error 带分类和错误码的执行错误
+type Error struct {
+	Category  Category
+	Code      string
+	Retryable bool
+	Message   string
+	Err       error
+
+	RetryAfter time.Duration // 建议的最早重试时间，如熔断剩余冷却时间
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(category Category, code, message string) *Error {
+	return &Error{Category: category, Code: code, Retryable: category.Retryable(), Message: message}
+}
+
+func Newf(category Category, code, format string, args ...interface{}) *Error {
+	return New(category, code, fmt.Sprintf(format, args...))
+}
+
+func Wrap(category Category, code string, err error, message string) *Error {
+	return &Error{Category: category, Code: code, Retryable: category.Retryable(), Message: message, Err: err}
+}
+
+// HTTPStatus 按响应状态码分类，408 和 429 视为可重试
+func HTTPStatus(status int, message string) *Error {
+	category := CategoryServer
+	if status < 500 {
+		category = CategoryClient
+	}
+
+	e := New(category, fmt.Sprintf("HTTP_%d", status), message)
+	if status == http.StatusRequestTimeout || status == http.StatusTooManyRequests {
+		e.Retryable = true
+	}
+	return e
+}
+
+// Classify 将任意错误转换为 *Error：已分类的错误原样返回，
+// 超时、连接失败和数据库约束冲突自动识别，其余归入 fallback
+func Classify(err error, fallback Category, code, message string) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return Wrap(CategoryTimeout, CodeTimeout, err, message)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Wrap(CategoryConnection, CodeConnectionRefused, err, message)
+	}
+
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return Wrap(CategoryConnection, CodeConnectionFailed, err, message)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return Wrap(CategoryConstraint, CodeDuplicateKey, err, message)
+		case 1216, 1217, 1451, 1452:
+			return Wrap(CategoryConstraint, CodeForeignKey, err, message)
+		case 1048, 1364:
+			return Wrap(CategoryConstraint, CodeNotNull, err, message)
+		case 3819:
+			return Wrap(CategoryConstraint, CodeCheckConstraint, err, message)
+		}
+	}
+
+	if code == "" {
+		code = CodeUnknown
+	}
+	return Wrap(fallback, code, err, message)
+}
@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCategory  Category
+		wantCode      string
+		wantRetryable bool
+	}{
+		{
+			name:          "deadline exceeded",
+			err:           fmt.Errorf("call: %w", context.DeadlineExceeded),
+			wantCategory:  CategoryTimeout,
+			wantCode:      CodeTimeout,
+			wantRetryable: true,
+		},
+		{
+			name:          "connection refused",
+			err:           &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			wantCategory:  CategoryConnection,
+			wantCode:      CodeConnectionRefused,
+			wantRetryable: true,
+		},
+		{
+			name:          "duplicate key",
+			err:           &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"},
+			wantCategory:  CategoryConstraint,
+			wantCode:      CodeDuplicateKey,
+			wantRetryable: false,
+		},
+		{
+			name:          "already classified",
+			err:           fmt.Errorf("outer: %w", New(CategoryConfig, CodeConfigInvalid, "bad config")),
+			wantCategory:  CategoryConfig,
+			wantCode:      CodeConfigInvalid,
+			wantRetryable: false,
+		},
+		{
+			name:          "fallback",
+			err:           fmt.Errorf("broker busy"),
+			wantCategory:  CategoryBroker,
+			wantCode:      CodeBrokerError,
+			wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err, CategoryBroker, CodeBrokerError, "")
+			if got.Category != tt.wantCategory || got.Code != tt.wantCode || got.Retryable != tt.wantRetryable {
+				t.Errorf("Classify() = %v/%s/%v, want %v/%s/%v",
+					got.Category, got.Code, got.Retryable, tt.wantCategory, tt.wantCode, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantCategory  Category
+		wantRetryable bool
+	}{
+		{400, CategoryClient, false},
+		{404, CategoryClient, false},
+		{429, CategoryClient, true},
+		{500, CategoryServer, true},
+		{503, CategoryServer, true},
+	}
+
+	for _, tt := range tests {
+		got := HTTPStatus(tt.status, "failed")
+		if got.Category != tt.wantCategory || got.Retryable != tt.wantRetryable {
+			t.Errorf("HTTPStatus(%d) = %v/%v, want %v/%v", tt.status, got.Category, got.Retryable, tt.wantCategory, tt.wantRetryable)
+		}
+		if want := fmt.Sprintf("HTTP_%d", tt.status); got.Code != want {
+			t.Errorf("HTTPStatus(%d).Code = %s, want %s", tt.status, got.Code, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// KeyfileProvider 从本地密钥文件加载主密钥。
+// 文件每行一个 `<key_id>=<base64 编码的 32 字节密钥>`，第一行为当前加密使用的密钥，其余行仅用于解密历史数据
+type KeyfileProvider struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+func NewKeyfileProvider(path string) (*KeyfileProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open key file failed: %w", err)
+	}
+	defer file.Close()
+
+	p := &KeyfileProvider{keys: make(map[string][]byte)}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(line, "=")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key file line: %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes base64 encoded", id)
+		}
+
+		if p.activeID == "" {
+			p.activeID = id
+		}
+		p.keys[id] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read key file failed: %w", err)
+	}
+
+	if p.activeID == "" {
+		return nil, fmt.Errorf("key file %s has no key", path)
+	}
+	return p, nil
+}
+
+func (p *KeyfileProvider) KeyID() string {
+	return p.activeID
+}
+
+func (p *KeyfileProvider) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(p.keys[p.activeID], dataKey)
+}
+
+func (p *KeyfileProvider) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %s", keyID)
+	}
+	return open(key, wrapped)
+}
@@ -0,0 +1,138 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// 密文格式：enc:v1:<key_id>:<被主密钥加密的数据密钥>:<被数据密钥加密的明文>
+const tokenPrefix = "enc:v1:"
+
+const RedactedValue = "******"
+
+var tokenPattern = regexp.MustCompile(`enc:v1:[A-Za-z0-9_-]+:[A-Za-z0-9_-]+:[A-Za-z0-9_-]+`)
+
+var encoding = base64.RawURLEncoding
+
+// KeyProvider 管理主密钥，负责数据密钥的加密（wrap）和解密（unwrap）
+type KeyProvider interface {
+	// KeyID 返回当前用于加密的主密钥 ID
+	KeyID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Cipher 对单个字段做信封加密：每次加密生成随机数据密钥，数据密钥再由主密钥加密后随密文保存
+type Cipher struct {
+	provider KeyProvider
+}
+
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider}
+}
+
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("generate data key failed: %w", err)
+	}
+
+	sealed, err := seal(dataKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := c.provider.Wrap(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key failed: %w", err)
+	}
+
+	return tokenPrefix + c.provider.KeyID() + ":" + encoding.EncodeToString(wrapped) + ":" + encoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) Decrypt(token string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(token, tokenPrefix), ":")
+	if !strings.HasPrefix(token, tokenPrefix) || len(parts) != 3 {
+		return nil, fmt.Errorf("invalid secret token")
+	}
+
+	wrapped, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode data key failed: %w", err)
+	}
+	sealed, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode ciphertext failed: %w", err)
+	}
+
+	dataKey, err := c.provider.Unwrap(parts[0], wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key failed: %w", err)
+	}
+
+	return open(dataKey, sealed)
+}
+
+// IsToken 判断字符串是否恰为一个密文
+func IsToken(s string) bool {
+	return strings.HasPrefix(s, tokenPrefix) && tokenPattern.FindString(s) == s
+}
+
+// ReplaceTokens 替换字符串中出现的所有密文
+func ReplaceTokens(s string, fn func(token string) string) string {
+	if !strings.Contains(s, tokenPrefix) {
+		return s
+	}
+	return tokenPattern.ReplaceAllStringFunc(s, fn)
+}
+
+// Redact 将字符串中的密文替换为 RedactedValue，用于日志和接口返回
+func Redact(s string) string {
+	return ReplaceTokens(s, func(string) string { return RedactedValue })
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce failed: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher failed: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
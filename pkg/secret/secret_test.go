@@ -0,0 +1,102 @@
+package secret
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestCipher(t *testing.T, lines ...string) *Cipher {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewKeyfileProvider(path)
+	if err != nil {
+		t.Fatalf("NewKeyfileProvider() error = %v", err)
+	}
+	return NewCipher(provider)
+}
+
+func testKey(b byte) string {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestCipher_EncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, "k1="+testKey(1))
+
+	token, err := c.Encrypt([]byte(`"6222000011112222"`))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsToken(token) {
+		t.Fatalf("Encrypt() = %s, not a token", token)
+	}
+	if strings.Contains(token, "6222000011112222") {
+		t.Fatalf("Encrypt() leaks plaintext: %s", token)
+	}
+
+	plaintext, err := c.Decrypt(token)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(plaintext) != `"6222000011112222"` {
+		t.Errorf("Decrypt() = %s", plaintext)
+	}
+
+	tampered := token[:len(token)-2] + "AA"
+	if _, err := c.Decrypt(tampered); err == nil {
+		t.Errorf("Decrypt() tampered token should fail")
+	}
+}
+
+func TestCipher_KeyRotation(t *testing.T) {
+	old := newTestCipher(t, "k1="+testKey(1))
+	token, err := old.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newTestCipher(t, "k2="+testKey(2), "k1="+testKey(1))
+	plaintext, err := rotated.Decrypt(token)
+	if err != nil || string(plaintext) != "secret" {
+		t.Errorf("Decrypt() after rotation = %s, %v", plaintext, err)
+	}
+
+	removed := newTestCipher(t, "k2="+testKey(2))
+	if _, err := removed.Decrypt(token); err == nil {
+		t.Errorf("Decrypt() with unknown key should fail")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	c := newTestCipher(t, "k1="+testKey(1))
+	token, _ := c.Encrypt([]byte("secret"))
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", `{"user_id":"u1"}`, `{"user_id":"u1"}`},
+		{"token", `{"card_no":"` + token + `"}`, `{"card_no":"******"}`},
+		{"embedded", `card ` + token + ` end`, `card ****** end`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.input); got != tt.expected {
+				t.Errorf("Redact() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
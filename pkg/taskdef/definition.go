@@ -4,31 +4,112 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"time"
 )
 
 type Field struct {
-	Name     string      `json:"name"`
-	Type     string      `json:"type"`
-	Required bool        `json:"required"`
-	Default  interface{} `json:"default,omitempty"`
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Required  bool        `json:"required"`
+	Default   interface{} `json:"default,omitempty"`
+	Sensitive bool        `json:"sensitive,omitempty"` // 敏感字段，持久化和展示时脱敏
 }
 
+const MaskedValue = "******"
+
 type TaskConfig struct {
 	Service string            `json:"service,omitempty"`
 	Method  string            `json:"method,omitempty"`
 	Topic   string            `json:"topic,omitempty"`
 	URL     string            `json:"url,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+
+	// RPC 调用选项
+	Protocol string            `json:"protocol,omitempty"` // http（默认，JSON over HTTP）/ grpc
+	Target   string            `json:"target,omitempty"`   // gRPC 服务地址 host:port，service 为完整服务名
+	Metadata map[string]string `json:"metadata,omitempty"` // gRPC metadata
+	Timeout  int               `json:"timeout,omitempty"`  // 调用超时（秒），gRPC 默认使用 [grpc] timeout，HTTP 默认 30 秒
+	TLS      *TLSConfig        `json:"tls,omitempty"`
+
+	// HTTP 请求选项，body、form、multipart 三选一
+	Query     map[string]interface{} `json:"query,omitempty"`
+	Body      interface{}            `json:"body,omitempty"` // JSON 请求体模板，可使用 ${input.xxx}、${outputs.xxx} 占位符；字符串原样发送
+	Form      map[string]interface{} `json:"form,omitempty"` // application/x-www-form-urlencoded
+	Multipart []MultipartField       `json:"multipart,omitempty"`
+	Auth      *HTTPAuth              `json:"auth,omitempty"`
+	Success   *HTTPSuccess           `json:"success,omitempty"` // 为 nil 时状态码小于 400 即成功
+	Extract   map[string]string      `json:"extract,omitempty"` // 输出字段名 -> 响应体 JSONPath
+
+	// MQ 消息选项
+	Broker        string            `json:"broker,omitempty"` // rocketmq / kafka / nats / memory，为空时使用默认 broker
+	Tags          string            `json:"tags,omitempty"`
+	Keys          []string          `json:"keys,omitempty"`
+	Properties    map[string]string `json:"properties,omitempty"`
+	DelayLevel    int               `json:"delay_level,omitempty"`   // RocketMQ 延迟级别 1-18
+	DeliverAt     string            `json:"deliver_at,omitempty"`    // 定时投递时间，RFC3339，与 delay_level 二选一
+	ShardingKey   string            `json:"sharding_key,omitempty"`  // 相同 sharding key 的消息投递到同一队列，保证顺序
+	Transactional bool              `json:"transactional,omitempty"` // 以事务半消息发送，任务成功后才对消费者可见
+}
+
+// TLSConfig 为 nil 时使用明文连接
+type TLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"` // 双向认证时的客户端证书
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+type MultipartField struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`     // 字段值；设置 file_name 时为文件内容，通常通过占位符引用入参或上游输出
+	Encoding    string `json:"encoding,omitempty"`  // value 的编码，base64 时解码后上传，用于二进制文件
+	FileName    string `json:"file_name,omitempty"` // 上传的文件名，设置后作为文件字段发送
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// HTTPAuth type 为 basic / bearer / hmac
+type HTTPAuth struct {
+	Type      string `json:"type"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Token     string `json:"token,omitempty"`
+	KeyID     string `json:"key_id,omitempty"`    // hmac：通过 X-Key-Id 请求头发送
+	Secret    string `json:"secret,omitempty"`    // hmac 签名密钥
+	Algorithm string `json:"algorithm,omitempty"` // hmac：sha256（默认）/ sha1 / sha512
+	Header    string `json:"header,omitempty"`    // hmac 签名请求头，默认 X-Signature
 }
 
+type HTTPSuccess struct {
+	Status     []string        `json:"status,omitempty"` // 成功的状态码，如 "200"、"2xx"、"200-299"
+	Assertions []HTTPAssertion `json:"assertions,omitempty"`
+}
+
+// HTTPAssertion 对响应体的断言，op 为 eq（默认）/ ne / gt / gte / lt / lte / exists / not_exists / contains / matches / in
+type HTTPAssertion struct {
+	Path  string      `json:"path"` // JSONPath，如 $.data.items[0].status
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// DefaultIdempotencyKey 默认幂等键格式，同一实例内同一任务的每次执行（含重试）保持不变
+const DefaultIdempotencyKey = "${instance_id}:${task_id}"
+
 type TaskDefinition struct {
-	Name        string     `json:"name"`
-	Type        string     `json:"type"`
-	Description string     `json:"description"`
-	InputFields []Field    `json:"input_fields"`
-	Config      TaskConfig `json:"config"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	Description    string     `json:"description"`
+	InputFields    []Field    `json:"input_fields"`
+	Config         TaskConfig `json:"config"`
+	IdempotencyKey string     `json:"idempotency_key,omitempty"` // 幂等键格式，为空时使用 DefaultIdempotencyKey
+	RateLimit      *RateLimit `json:"rate_limit,omitempty"`      // 该任务所有实例共享的调用频率上限
+}
+
+type RateLimit struct {
+	Rate  float64 `json:"rate"`            // 每秒调用次数
+	Burst int     `json:"burst,omitempty"` // 允许的突发调用数，默认为 rate 向上取整
 }
 
 var TaskDefinitions = map[string]TaskDefinition{
@@ -45,6 +126,7 @@ var TaskDefinitions = map[string]TaskDefinition{
 			Service: "PaymentService",
 			Method:  "deduct",
 		},
+		IdempotencyKey: "deduct:${input.order_id}:${instance_id}",
 	},
 	"notify": {
 		Name:        "发送通知",
@@ -68,6 +150,42 @@ var TaskDefinitions = map[string]TaskDefinition{
 		},
 		Config: TaskConfig{},
 	},
+	"db": {
+		Name:        "数据库操作",
+		Type:        "db",
+		Description: "在平台数据库或 [db_task.datasources] 中的数据源执行 SQL",
+		Config:      TaskConfig{},
+	},
+	"transform": {
+		Name:        "数据转换",
+		Type:        "transform",
+		Description: "用 Starlark 表达式或脚本转换全局参数和上游输出",
+		Config:      TaskConfig{},
+	},
+	"command": {
+		Name:        "执行命令",
+		Type:        "command",
+		Description: "执行 [command] allowed 中的程序，用于运维流程",
+		Config:      TaskConfig{},
+	},
+	"worker": {
+		Name:        "外部 worker",
+		Type:        "worker",
+		Description: "派发给注册了 task_type 的外部 worker 进程执行",
+		Config:      TaskConfig{},
+	},
+	"wait": {
+		Name:        "等待信号",
+		Type:        "wait",
+		Description: "挂起实例，等待外部审批信号",
+		Config:      TaskConfig{},
+	},
+	"delay": {
+		Name:        "延迟",
+		Type:        "delay",
+		Description: "等待指定时长或到达指定时间后继续",
+		Config:      TaskConfig{},
+	},
 }
 
 func GetTaskDefinition(name string) (*TaskDefinition, error) {
@@ -78,11 +196,40 @@ func GetTaskDefinition(name string) (*TaskDefinition, error) {
 	return &def, nil
 }
 
+// SensitiveTasks 返回声明了敏感字段的任务名，按名称排序
+func SensitiveTasks() []string {
+	var names []string
+	for name, def := range TaskDefinitions {
+		for _, field := range def.InputFields {
+			if field.Sensitive {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (t *TaskDefinition) GetInputFieldsJSON() string {
 	data, _ := json.Marshal(t.InputFields)
 	return string(data)
 }
 
+// Mask 返回脱敏后的参数副本，schema 中标记为 sensitive 的字段替换为 MaskedValue
+func Mask(fields []Field, params map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		result[k] = v
+	}
+	for _, field := range fields {
+		if _, ok := result[field.Name]; ok && field.Sensitive {
+			result[field.Name] = MaskedValue
+		}
+	}
+	return result
+}
+
 type ParseError struct {
 	Field    string
 	Reason   string
@@ -284,3 +284,45 @@ func TestValidateAll(t *testing.T) {
 		})
 	}
 }
+
+func TestMask(t *testing.T) {
+	fields := []Field{
+		{Name: "user_id", Type: "string"},
+		{Name: "card_no", Type: "string", Sensitive: true},
+		{Name: "cvv", Type: "string", Sensitive: true},
+	}
+
+	params := map[string]interface{}{
+		"user_id": "user_001",
+		"card_no": "6222000011112222",
+	}
+
+	result := Mask(fields, params)
+
+	expected := map[string]interface{}{
+		"user_id": "user_001",
+		"card_no": MaskedValue,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Mask() = %v, expected %v", result, expected)
+	}
+	if params["card_no"] != "6222000011112222" {
+		t.Errorf("Mask() modified original params: %v", params)
+	}
+}
+
+func TestSensitiveTasks(t *testing.T) {
+	if got := SensitiveTasks(); len(got) != 0 {
+		t.Fatalf("SensitiveTasks() = %v, built-in definitions have no sensitive fields", got)
+	}
+
+	TaskDefinitions["pay"] = TaskDefinition{
+		Type:        "http",
+		InputFields: []Field{{Name: "card_no", Type: "string", Sensitive: true}},
+	}
+	defer delete(TaskDefinitions, "pay")
+
+	if got := SensitiveTasks(); !reflect.DeepEqual(got, []string{"pay"}) {
+		t.Errorf("SensitiveTasks() = %v, expected [pay]", got)
+	}
+}